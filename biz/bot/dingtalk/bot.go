@@ -8,15 +8,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/yi-nology/common/biz/bot/transport"
 )
 
 const (
 	webhookURL = "https://oapi.dingtalk.com/robot/send?access_token=%s"
 
+	platform = "dd"
+
 	SecurityNone    = "none"
 	SecuritySign    = "sign"
 	SecurityKeyword = "keyword"
@@ -30,6 +33,7 @@ type Robot struct {
 	SecurityType string
 	Secret       string
 	Keywords     string
+	Options      *transport.Options
 }
 
 // Resp 钉钉API响应
@@ -39,12 +43,14 @@ type Resp struct {
 }
 
 // New 创建钉钉机器人实例
-func New(botKey string) *Robot {
+func New(botKey string, opts ...transport.Option) *Robot {
+	options := transport.NewOptions(platform, opts...)
 	return &Robot{
 		Key:          botKey,
-		RequestUrl:   fmt.Sprintf(webhookURL, botKey),
-		Client:       &http.Client{Timeout: 5 * time.Second},
+		RequestUrl:   options.ResolveURL(fmt.Sprintf(webhookURL, botKey)),
+		Client:       options.HTTPClient(),
 		SecurityType: SecurityNone,
+		Options:      options,
 	}
 }
 
@@ -82,7 +88,7 @@ func (r *Robot) SendRaw(msgBytes []byte) (bool, error) {
 	// 签名模式：在URL中追加timestamp和sign参数
 	if r.SecurityType == SecuritySign && r.Secret != "" {
 		timestamp := time.Now().UnixNano() / 1e6
-		sign := r.sign(timestamp, r.Secret)
+		sign := signature(timestamp, r.Secret)
 		requestUrl = fmt.Sprintf("%s&timestamp=%d&sign=%s", requestUrl, timestamp, url.QueryEscape(sign))
 	}
 
@@ -91,30 +97,34 @@ func (r *Robot) SendRaw(msgBytes []byte) (bool, error) {
 		msgBytes = r.injectKeyword(msgBytes)
 	}
 
-	resp, err := r.Client.Post(requestUrl, "application/json", bytes.NewReader(msgBytes))
+	req, err := http.NewRequest(http.MethodPost, requestUrl, bytes.NewReader(msgBytes))
 	if err != nil {
 		return false, err
 	}
-	defer resp.Body.Close()
+	req.Header.Set("Content-Type", "application/json")
 
-	body, err := io.ReadAll(resp.Body)
+	result, err := r.Options.Do(r.Client, req, decodeResp)
 	if err != nil {
 		return false, err
 	}
 
-	ret := &Resp{}
-	if err := json.Unmarshal(body, ret); err != nil {
-		return false, err
+	if result.ErrCode == 0 {
+		return true, nil
 	}
+	return false, errors.New(result.ErrMsg)
+}
 
-	if ret.ErrCode == 0 {
-		return true, nil
+// decodeResp 解析钉钉响应中的错误码
+func decodeResp(body []byte) (int, string, error) {
+	ret := &Resp{}
+	if err := json.Unmarshal(body, ret); err != nil {
+		return 0, "", err
 	}
-	return false, errors.New(ret.ErrMsg)
+	return ret.ErrCode, ret.ErrMsg, nil
 }
 
-// sign 生成HMAC-SHA256签名
-func (r *Robot) sign(timestamp int64, secret string) string {
+// signature 生成HMAC-SHA256签名
+func signature(timestamp int64, secret string) string {
 	stringToSign := fmt.Sprintf("%d\n%s", timestamp, secret)
 	h := hmac.New(sha256.New, []byte(secret))
 	h.Write([]byte(stringToSign))
@@ -0,0 +1,170 @@
+package dingtalk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/yi-nology/common/biz/bot/media"
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+const (
+	defaultOpenApiBaseUrl = "https://oapi.dingtalk.com"
+
+	// 上传接口默认超时，可通过 transport.WithTimeout 覆盖
+	mediaTimeout = 30 * time.Second
+)
+
+// MediaLimits 钉钉自定义机器人只能在 Markdown 中引用图片 media_id
+var MediaLimits = map[media.Type]media.Limit{
+	media.Image: {MaxSize: 20 << 20, Formats: []string{"jpg", "png", "gif", "bmp"}},
+}
+
+// MediaUploader 钉钉媒体文件上传，需要企业内部应用的 appkey 与 appsecret
+type MediaUploader struct {
+	AppKey    string
+	AppSecret string
+	BaseUrl   string
+	Client    *http.Client
+	Options   *transport.Options
+
+	tokens transport.TokenCache
+}
+
+type accessTokenResp struct {
+	Resp
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type uploadMediaResp struct {
+	Resp
+	Type    string `json:"type"`
+	MediaID string `json:"media_id"`
+}
+
+// NewMediaUploader 创建媒体上传器，opts 可指定 HTTP 客户端、地址覆盖与请求钩子
+func NewMediaUploader(appKey, appSecret string, opts ...transport.Option) *MediaUploader {
+	options := transport.NewOptions(platform, append([]transport.Option{transport.WithTimeout(mediaTimeout)}, opts...)...)
+	return &MediaUploader{
+		AppKey:    appKey,
+		AppSecret: appSecret,
+		BaseUrl:   options.ResolveURL(defaultOpenApiBaseUrl),
+		Client:    options.HTTPClient(),
+		Options:   options,
+	}
+}
+
+// Upload 统一媒体上传，图片校验压缩后上传并返回 media_id
+func (u *MediaUploader) Upload(ctx context.Context, mediaType media.Type, name string, r io.Reader) (*media.Handle, error) {
+	limit, ok := MediaLimits[mediaType]
+	if !ok {
+		return nil, media.ErrUnsupported
+	}
+	data, format, err := media.Prepare(mediaType, name, r, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := u.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("media", name)
+	if err != nil {
+		return nil, err
+	}
+	part.Write(data)
+	writer.Close()
+
+	query := url.Values{}
+	query.Set("access_token", token)
+	query.Set("type", string(mediaType))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.baseUrl()+"/media/upload?"+query.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	ret := uploadMediaResp{}
+	if err := u.do(req, &ret); err != nil {
+		return nil, err
+	}
+	return &media.Handle{
+		Type:   mediaType,
+		Name:   name,
+		Format: format,
+		Size:   int64(len(data)),
+		ID:     ret.MediaID,
+	}, nil
+}
+
+// accessToken 获取 access_token，带缓存
+func (u *MediaUploader) accessToken(ctx context.Context) (string, error) {
+	return u.tokens.Get(ctx, u.fetchToken)
+}
+
+// fetchToken 换取 access_token
+func (u *MediaUploader) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	query := url.Values{}
+	query.Set("appkey", u.AppKey)
+	query.Set("appsecret", u.AppSecret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.baseUrl()+"/gettoken?"+query.Encode(), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	ret := accessTokenResp{}
+	if err := u.do(req, &ret); err != nil {
+		return "", 0, err
+	}
+	return ret.AccessToken, time.Duration(ret.ExpiresIn) * time.Second, nil
+}
+
+func (u *MediaUploader) baseUrl() string {
+	if u.BaseUrl == "" {
+		return defaultOpenApiBaseUrl
+	}
+	return u.BaseUrl
+}
+
+// do 通过 transport 发送请求，检查 HTTP 状态码与 errcode
+func (u *MediaUploader) do(req *http.Request, ret interface{ resp() *Resp }) error {
+	result, err := u.Options.Do(u.Client, req, decodeResp)
+	if result != nil && (result.StatusCode < 200 || result.StatusCode >= 300) {
+		return fmt.Errorf("dingtalk api error, status: %d, body: %s", result.StatusCode, result.Body)
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(result.Body, ret); err != nil {
+		return err
+	}
+	if r := ret.resp(); r.ErrCode != 0 {
+		return fmt.Errorf("dingtalk api error, code: %d, msg: %s", r.ErrCode, r.ErrMsg)
+	}
+	return nil
+}
+
+func (r *Resp) resp() *Resp {
+	return r
+}
+
+// ImageMarkdown 返回引用已上传图片的 Markdown 片段
+func ImageMarkdown(h *media.Handle) string {
+	return fmt.Sprintf("![%s](%s)", h.Name, h.ID)
+}
+
+// NewImageMarkDown 创建仅包含一张图片的 Markdown 消息
+func NewImageMarkDown(title string, h *media.Handle) *MarkDownMessage {
+	return NewMarkDown().SetContent(title, ImageMarkdown(h))
+}
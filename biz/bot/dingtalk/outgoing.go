@@ -0,0 +1,172 @@
+package dingtalk
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerTimestamp = "timestamp"
+	headerSign      = "sign"
+
+	// 钉钉要求回调时间戳与当前时间相差不超过 1 小时
+	maxTimestampSkew = time.Hour
+
+	maxCallbackBodySize = 1 << 20
+)
+
+var (
+	ErrInvalidSignature = errors.New("dingtalk: invalid callback signature")
+	ErrTimestampExpired = errors.New("dingtalk: callback timestamp expired")
+	ErrSessionExpired   = errors.New("dingtalk: session webhook expired")
+)
+
+// MessageHandler 处理 outgoing 机器人收到的消息，返回值作为同步回复（任意钉钉消息类型），返回 nil 表示不回复
+type MessageHandler func(ctx context.Context, msg *OutgoingMessage) (interface{}, error)
+
+// AtUser 被@的用户
+type AtUser struct {
+	DingtalkID string `json:"dingtalkId"`
+	StaffID    string `json:"staffId"`
+}
+
+// OutgoingMessage outgoing 机器人回调消息
+type OutgoingMessage struct {
+	MsgID                     string   `json:"msgId"`
+	MsgType                   string   `json:"msgtype"`
+	CreateAt                  int64    `json:"createAt"`
+	ConversationID            string   `json:"conversationId"`
+	ConversationType          string   `json:"conversationType"` // 1 单聊，2 群聊
+	ConversationTitle         string   `json:"conversationTitle"`
+	SenderID                  string   `json:"senderId"`
+	SenderNick                string   `json:"senderNick"`
+	SenderCorpID              string   `json:"senderCorpId"`
+	SenderStaffID             string   `json:"senderStaffId"`
+	IsAdmin                   bool     `json:"isAdmin"`
+	ChatbotCorpID             string   `json:"chatbotCorpId"`
+	ChatbotUserID             string   `json:"chatbotUserId"`
+	RobotCode                 string   `json:"robotCode"`
+	IsInAtList                bool     `json:"isInAtList"`
+	AtUsers                   []AtUser `json:"atUsers"`
+	SessionWebhook            string   `json:"sessionWebhook"`
+	SessionWebhookExpiredTime int64    `json:"sessionWebhookExpiredTime"`
+	Text                      Text     `json:"text"`
+
+	client *http.Client
+}
+
+// Content 返回去掉首尾空白的文本内容
+func (m *OutgoingMessage) Content() string {
+	return strings.TrimSpace(m.Text.Content)
+}
+
+// SessionRobot 返回绑定 sessionWebhook 的机器人，可用于异步回复
+func (m *OutgoingMessage) SessionRobot() *Robot {
+	client := m.client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &Robot{
+		RequestUrl:   m.SessionWebhook,
+		Client:       client,
+		SecurityType: SecurityNone,
+	}
+}
+
+// Reply 通过 sessionWebhook 回复消息
+func (m *OutgoingMessage) Reply(message interface{}) (bool, error) {
+	if m.SessionWebhookExpiredTime > 0 && time.Now().UnixMilli() > m.SessionWebhookExpiredTime {
+		return false, ErrSessionExpired
+	}
+	return m.SessionRobot().Send(message)
+}
+
+// CallbackHandler outgoing 机器人回调处理器，实现 http.Handler
+type CallbackHandler struct {
+	AppSecret string
+	Client    *http.Client // 用于 sessionWebhook 回复
+	handler   MessageHandler
+}
+
+// NewCallbackHandler 创建 outgoing 机器人回调处理器，appSecret 为空时不校验签名
+func NewCallbackHandler(appSecret string, handler MessageHandler) *CallbackHandler {
+	return &CallbackHandler{
+		AppSecret: appSecret,
+		Client:    &http.Client{Timeout: 5 * time.Second},
+		handler:   handler,
+	}
+}
+
+// ServeHTTP 处理钉钉回调请求
+func (h *CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := h.Verify(r.Header.Get(headerTimestamp), r.Header.Get(headerSign)); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	msg := &OutgoingMessage{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	msg.client = h.Client
+
+	var reply interface{}
+	if h.handler != nil {
+		reply, err = h.handler(r.Context(), msg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if reply == nil {
+		w.Write([]byte("{}"))
+		return
+	}
+	b, err := json.Marshal(reply)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+}
+
+// Verify 校验回调请求头中的 timestamp 与 sign
+func (h *CallbackHandler) Verify(timestamp, sign string) error {
+	if h.AppSecret == "" {
+		return nil
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	skew := time.Since(time.UnixMilli(ts))
+	if skew > maxTimestampSkew || skew < -maxTimestampSkew {
+		return ErrTimestampExpired
+	}
+	expected := signature(ts, h.AppSecret)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(sign)) != 1 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
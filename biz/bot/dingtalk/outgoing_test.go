@@ -0,0 +1,92 @@
+package dingtalk
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testAppSecret = "app-secret"
+
+func postOutgoing(t *testing.T, url string, body string, timestamp int64, sign string) (int, []byte) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	assert.Nil(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(headerSign, sign)
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, data
+}
+
+func TestOutgoingSyncReply(t *testing.T) {
+	var got *OutgoingMessage
+	srv := httptest.NewServer(NewCallbackHandler(testAppSecret, func(ctx context.Context, msg *OutgoingMessage) (interface{}, error) {
+		got = msg
+		return NewText().SetContent("pong: " + msg.Content()), nil
+	}))
+	defer srv.Close()
+
+	body := `{"msgtype":"text","text":{"content":" ping "},"senderNick":"张三","senderStaffId":"s1","conversationId":"cid1","conversationType":"2","isInAtList":true,"atUsers":[{"dingtalkId":"bot","staffId":""}]}`
+	ts := time.Now().UnixMilli()
+	status, resp := postOutgoing(t, srv.URL, body, ts, signature(ts, testAppSecret))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "张三", got.SenderNick)
+	assert.Equal(t, "cid1", got.ConversationID)
+	assert.Len(t, got.AtUsers, 1)
+
+	var reply TextMessage
+	assert.Nil(t, json.Unmarshal(resp, &reply))
+	assert.Equal(t, TEXT, reply.MsgType)
+	assert.Equal(t, "pong: ping", reply.Text.Content)
+}
+
+func TestOutgoingVerify(t *testing.T) {
+	srv := httptest.NewServer(NewCallbackHandler(testAppSecret, nil))
+	defer srv.Close()
+
+	ts := time.Now().UnixMilli()
+	status, _ := postOutgoing(t, srv.URL, `{}`, ts, "bad-sign")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	old := time.Now().Add(-2 * time.Hour).UnixMilli()
+	status, _ = postOutgoing(t, srv.URL, `{}`, old, signature(old, testAppSecret))
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, resp := postOutgoing(t, srv.URL, `{}`, ts, signature(ts, testAppSecret))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "{}", string(resp))
+}
+
+func TestOutgoingSessionReply(t *testing.T) {
+	var received map[string]interface{}
+	session := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &received)
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer session.Close()
+
+	msg := &OutgoingMessage{
+		SessionWebhook:            session.URL + "/robot/sendBySession?session=abc",
+		SessionWebhookExpiredTime: time.Now().Add(time.Hour).UnixMilli(),
+	}
+	ok, err := msg.Reply(NewMarkDown().SetContent("title", "**done**"))
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, "markdown", received["msgtype"])
+
+	msg.SessionWebhookExpiredTime = time.Now().Add(-time.Minute).UnixMilli()
+	_, err = msg.Reply(NewText().SetContent("late"))
+	assert.ErrorIs(t, err, ErrSessionExpired)
+}
@@ -4,6 +4,11 @@ import (
 	"github.com/yi-nology/common/biz/bot/dingtalk"
 	"github.com/yi-nology/common/biz/bot/lanxin"
 	"github.com/yi-nology/common/biz/bot/lark"
+	"github.com/yi-nology/common/biz/bot/slack"
+	"github.com/yi-nology/common/biz/bot/teams"
+	"github.com/yi-nology/common/biz/bot/telegram"
+	"github.com/yi-nology/common/biz/bot/transport"
+	"github.com/yi-nology/common/biz/bot/webhook"
 	"github.com/yi-nology/common/biz/bot/wxwork"
 )
 
@@ -22,6 +27,10 @@ const (
 	Dingtalk BotType = "dd"
 	Lark     BotType = "lark"
 	Lanxin   BotType = "lanxin"
+	Slack    BotType = "slack"
+	Teams    BotType = "teams"
+	Telegram BotType = "telegram"
+	Webhook  BotType = "webhook"
 )
 
 // SecurityType 安全模式
@@ -34,37 +43,53 @@ const (
 )
 
 // SwitchOne 根据类型创建机器人实例（向后兼容：secret非空时默认签名模式）
-func SwitchOne(botType BotType, botKey string, secret string) BotOne {
+func SwitchOne(botType BotType, botKey string, secret string, opts ...transport.Option) BotOne {
 	switch botType {
 	case WXWork:
-		return wxwork.New(botKey)
+		return wxwork.New(botKey, opts...)
 	case Dingtalk:
-		return dingtalk.New(botKey).AddSign(secret)
+		return dingtalk.New(botKey, opts...).AddSign(secret)
 	case Lark:
-		return lark.New(botKey).AddSign(secret)
+		return lark.New(botKey, opts...).AddSign(secret)
 	case Lanxin:
-		return lanxin.New(botKey).AddSign(secret)
+		return lanxin.New(botKey, opts...).AddSign(secret)
+	case Slack, Teams, Telegram, Webhook:
+		return NewBot(botType, botKey, SecuritySign, secret, "", opts...)
 	default:
-		return wxwork.New(botKey)
+		return wxwork.New(botKey, opts...)
 	}
 }
 
-// NewBot 创建机器人实例，支持完整安全模式配置
-func NewBot(botType BotType, botKey string, securityType SecurityType, secret string, keyword string) BotOne {
+// NewBot 创建机器人实例，支持完整安全模式配置，opts 可指定 HTTP 客户端、地址覆盖与请求钩子
+func NewBot(botType BotType, botKey string, securityType SecurityType, secret string, keyword string, opts ...transport.Option) BotOne {
 	switch botType {
 	case WXWork:
-		return wxwork.New(botKey)
+		return wxwork.New(botKey, opts...)
 	case Dingtalk:
-		bot := dingtalk.New(botKey)
+		bot := dingtalk.New(botKey, opts...)
 		return applyDingtalkSecurity(bot, securityType, secret, keyword)
 	case Lark:
-		bot := lark.New(botKey)
+		bot := lark.New(botKey, opts...)
 		return applyLarkSecurity(bot, securityType, secret, keyword)
 	case Lanxin:
-		bot := lanxin.New(botKey)
+		bot := lanxin.New(botKey, opts...)
 		return applyLanxinSecurity(bot, securityType, secret, keyword)
+	case Slack:
+		bot := slack.New(botKey, opts...)
+		if securityType == SecurityKeyword {
+			return bot.AddKeyword(keyword)
+		}
+		return bot
+	case Teams:
+		return teams.New(botKey, opts...)
+	case Telegram:
+		return newTelegram(botKey, securityType, keyword, opts...)
+	case Webhook:
+		// 默认模板必然可解析，自定义模板请直接使用 webhook.New
+		bot, _ := webhook.New(botKey, "", opts...)
+		return applyWebhookSecurity(bot, securityType, secret, keyword)
 	default:
-		return wxwork.New(botKey)
+		return wxwork.New(botKey, opts...)
 	}
 }
 
@@ -100,3 +125,27 @@ func applyLanxinSecurity(bot *lanxin.Robot, securityType SecurityType, secret, k
 		return bot
 	}
 }
+
+// newTelegram botKey 格式为 "<bot_token>/<chat_id>"，格式错误时 chat_id 为空，需在消息中指定
+func newTelegram(botKey string, securityType SecurityType, keyword string, opts ...transport.Option) BotOne {
+	token, chatID, err := telegram.ParseKey(botKey)
+	if err != nil {
+		token = botKey
+	}
+	bot := telegram.New(token, chatID, opts...)
+	if securityType == SecurityKeyword {
+		return bot.AddKeyword(keyword)
+	}
+	return bot
+}
+
+func applyWebhookSecurity(bot *webhook.Bot, securityType SecurityType, secret, keyword string) BotOne {
+	switch securityType {
+	case SecuritySign:
+		return bot.AddSign(secret)
+	case SecurityKeyword:
+		return bot.AddKeyword(keyword)
+	default:
+		return bot
+	}
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+func TestNewBotWithOptions(t *testing.T) {
+	var mu sync.Mutex
+	paths := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		paths[r.URL.Path] = string(b)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/services/T/B/X":
+			w.Write([]byte("ok"))
+			return
+		case "/webhookb2/x":
+			w.Write([]byte("1"))
+			return
+		case "/bot123:abc/sendMessage":
+			w.Write([]byte(`{"ok":true}`))
+			return
+		case "/open-apis/bot/v2/hook/k-lark":
+			w.Write([]byte(`{"code":19024,"msg":"Key Words Not Found"}`))
+			return
+		}
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer srv.Close()
+
+	var infos []*transport.ResponseInfo
+	opts := []transport.Option{
+		transport.WithBaseURL(srv.URL),
+		transport.WithAfterHook(func(info *transport.ResponseInfo) {
+			mu.Lock()
+			infos = append(infos, info)
+			mu.Unlock()
+		}),
+	}
+
+	cases := []struct {
+		botType BotType
+		key     string
+		msg     string
+		path    string
+		ok      bool
+	}{
+		{WXWork, "k-wx", `{"msgtype":"text","text":{"content":"hi"}}`, "/cgi-bin/webhook/send", true},
+		{Dingtalk, "k-dd", `{"msgtype":"text","text":{"content":"hi"}}`, "/robot/send", true},
+		{Lanxin, "https://apigw.lanxin.cn/v1/bot/hook/messages/create?hook_token=k", `{"msgtype":"text","text":{"content":"hi"}}`, "/v1/bot/hook/messages/create", true},
+		{Lark, "k-lark", `{"msg_type":"text","content":{"text":"hi"}}`, "/open-apis/bot/v2/hook/k-lark", false},
+		{Slack, "T/B/X", `{"text":"hi"}`, "/services/T/B/X", true},
+		{Telegram, "123:abc/42", `{"chat_id":"42","text":"hi"}`, "/bot123:abc/sendMessage", true},
+		{Teams, "https://example.webhook.office.com/webhookb2/x", `{"type":"message"}`, "/webhookb2/x", true},
+		{Webhook, "https://example.com/hooks/x", `{"text":"hi"}`, "/hooks/x", true},
+	}
+	for _, c := range cases {
+		bot := NewBot(c.botType, c.key, SecurityNone, "", "", opts...)
+		ok, err := bot.SendRaw([]byte(c.msg))
+		assert.Equal(t, c.ok, ok, c.botType)
+		assert.Equal(t, c.ok, err == nil, c.botType)
+		assert.Equal(t, c.msg, paths[c.path], c.botType)
+	}
+
+	assert.Len(t, infos, len(cases))
+	assert.Equal(t, "lark", infos[3].Platform)
+	assert.Equal(t, 19024, infos[3].ErrCode)
+	assert.Equal(t, http.StatusOK, infos[0].StatusCode)
+}
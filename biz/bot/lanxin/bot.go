@@ -8,13 +8,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/yi-nology/common/biz/bot/transport"
 )
 
 const (
+	platform = "lanxin"
+
 	SecurityNone    = "none"
 	SecuritySign    = "sign"
 	SecurityKeyword = "keyword"
@@ -27,6 +30,7 @@ type Robot struct {
 	SecurityType string
 	Secret       string
 	Keywords     string
+	Options      *transport.Options
 }
 
 // Resp 蓝信API响应
@@ -36,11 +40,13 @@ type Resp struct {
 }
 
 // New 创建蓝信机器人实例（传入完整的webhook URL）
-func New(webhookUrl string) *Robot {
+func New(webhookUrl string, opts ...transport.Option) *Robot {
+	options := transport.NewOptions(platform, opts...)
 	return &Robot{
-		WebHookUrl:   webhookUrl,
-		Client:       &http.Client{Timeout: 5 * time.Second},
+		WebHookUrl:   options.ResolveURL(webhookUrl),
+		Client:       options.HTTPClient(),
 		SecurityType: SecurityNone,
+		Options:      options,
 	}
 }
 
@@ -87,26 +93,30 @@ func (r *Robot) SendRaw(msgBytes []byte) (bool, error) {
 		msgBytes = r.injectKeyword(msgBytes)
 	}
 
-	resp, err := r.Client.Post(requestUrl, "application/json", bytes.NewReader(msgBytes))
+	req, err := http.NewRequest(http.MethodPost, requestUrl, bytes.NewReader(msgBytes))
 	if err != nil {
 		return false, err
 	}
-	defer resp.Body.Close()
+	req.Header.Set("Content-Type", "application/json")
 
-	body, err := io.ReadAll(resp.Body)
+	result, err := r.Options.Do(r.Client, req, decodeResp)
 	if err != nil {
 		return false, err
 	}
 
-	ret := &Resp{}
-	if err := json.Unmarshal(body, ret); err != nil {
-		return false, err
+	if result.ErrCode == 0 {
+		return true, nil
 	}
+	return false, errors.New(result.ErrMsg)
+}
 
-	if ret.ErrCode == 0 {
-		return true, nil
+// decodeResp 解析蓝信响应中的错误码
+func decodeResp(body []byte) (int, string, error) {
+	ret := &Resp{}
+	if err := json.Unmarshal(body, ret); err != nil {
+		return 0, "", err
 	}
-	return false, errors.New(ret.ErrMsg)
+	return ret.ErrCode, ret.ErrMsg, nil
 }
 
 // sign 生成HMAC-SHA256签名
@@ -163,7 +173,7 @@ func (r *Robot) CheckMessage(msg string) bool {
 		return false
 	}
 	switch msgType {
-	case "text", "markdown":
+	case "text", "markdown", "image", "file":
 		return true
 	default:
 		return false
@@ -0,0 +1,47 @@
+package lanxin
+
+import "github.com/yi-nology/common/biz/bot/media"
+
+// ImageMessage 图片消息
+type ImageMessage struct {
+	MsgType MsgType `json:"msgtype"`
+	Image   *Image  `json:"image"`
+}
+
+// Image 图片内容，mediaIds 为媒体上传返回的 mediaId
+type Image struct {
+	MediaIDs []string `json:"mediaIds"`
+}
+
+// NewImage 创建图片消息
+func NewImage() *ImageMessage {
+	return &ImageMessage{MsgType: IMAGE, Image: &Image{}}
+}
+
+// SetMedia 设置已上传的图片
+func (m *ImageMessage) SetMedia(h *media.Handle) *ImageMessage {
+	m.Image.MediaIDs = append(m.Image.MediaIDs, h.ID)
+	return m
+}
+
+// FileMessage 文件消息
+type FileMessage struct {
+	MsgType MsgType `json:"msgtype"`
+	File    *File   `json:"file"`
+}
+
+// File 文件内容
+type File struct {
+	MediaID string `json:"mediaId"`
+}
+
+// NewFile 创建文件消息
+func NewFile() *FileMessage {
+	return &FileMessage{MsgType: FILE, File: &File{}}
+}
+
+// SetMedia 设置已上传的文件
+func (m *FileMessage) SetMedia(h *media.Handle) *FileMessage {
+	m.File.MediaID = h.ID
+	return m
+}
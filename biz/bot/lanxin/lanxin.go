@@ -6,6 +6,8 @@ type MsgType string
 const (
 	TEXT     MsgType = "text"
 	MARKDOWN MsgType = "markdown"
+	IMAGE    MsgType = "image"
+	FILE     MsgType = "file"
 )
 
 // At @功能
@@ -0,0 +1,172 @@
+package lanxin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/yi-nology/common/biz/bot/media"
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+const (
+	defaultOpenApiBaseUrl = "https://apigw.lanxin.cn"
+
+	// 上传接口默认超时，可通过 transport.WithTimeout 覆盖
+	mediaTimeout = 30 * time.Second
+)
+
+// MediaLimits 蓝信机器人支持的媒体类型
+var MediaLimits = map[media.Type]media.Limit{
+	media.Image: {MaxSize: 10 << 20, Formats: []string{"jpg", "png", "gif", "bmp"}},
+	media.File:  {MaxSize: 20 << 20},
+}
+
+// MediaUploader 蓝信媒体文件上传，需要开放平台应用的 appid 与 secret；私有化部署时 BaseUrl 设为网关地址
+type MediaUploader struct {
+	AppID     string
+	AppSecret string
+	BaseUrl   string
+	Client    *http.Client
+	Options   *transport.Options
+
+	tokens transport.TokenCache
+}
+
+// openApiResp 蓝信开放平台响应，与机器人 webhook 响应字段不同
+type openApiResp struct {
+	ErrCode int             `json:"errCode"`
+	ErrMsg  string          `json:"errMsg"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type appTokenData struct {
+	AppToken  string `json:"appToken"`
+	ExpiresIn int64  `json:"expiresIn"`
+}
+
+type uploadMediaData struct {
+	MediaID string `json:"mediaId"`
+}
+
+// NewMediaUploader 创建媒体上传器，opts 可指定 HTTP 客户端、地址覆盖与请求钩子
+func NewMediaUploader(appID, appSecret string, opts ...transport.Option) *MediaUploader {
+	options := transport.NewOptions(platform, append([]transport.Option{transport.WithTimeout(mediaTimeout)}, opts...)...)
+	return &MediaUploader{
+		AppID:     appID,
+		AppSecret: appSecret,
+		BaseUrl:   options.ResolveURL(defaultOpenApiBaseUrl),
+		Client:    options.HTTPClient(),
+		Options:   options,
+	}
+}
+
+// Upload 统一媒体上传，图片校验压缩后上传并返回 mediaId
+func (u *MediaUploader) Upload(ctx context.Context, mediaType media.Type, name string, r io.Reader) (*media.Handle, error) {
+	limit, ok := MediaLimits[mediaType]
+	if !ok {
+		return nil, media.ErrUnsupported
+	}
+	data, format, err := media.Prepare(mediaType, name, r, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := u.appToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("media", name)
+	if err != nil {
+		return nil, err
+	}
+	part.Write(data)
+	writer.Close()
+
+	query := url.Values{}
+	query.Set("app_token", token)
+	query.Set("type", string(mediaType))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.baseUrl()+"/v1/medias/create?"+query.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	ret := uploadMediaData{}
+	if err := u.do(req, &ret); err != nil {
+		return nil, err
+	}
+	return &media.Handle{
+		Type:   mediaType,
+		Name:   name,
+		Format: format,
+		Size:   int64(len(data)),
+		ID:     ret.MediaID,
+	}, nil
+}
+
+// appToken 获取 app_token，带缓存
+func (u *MediaUploader) appToken(ctx context.Context) (string, error) {
+	return u.tokens.Get(ctx, u.fetchToken)
+}
+
+// fetchToken 换取 app_token
+func (u *MediaUploader) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	query := url.Values{}
+	query.Set("grant_type", "client_credential")
+	query.Set("appid", u.AppID)
+	query.Set("secret", u.AppSecret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.baseUrl()+"/v1/apptoken/create?"+query.Encode(), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	ret := appTokenData{}
+	if err := u.do(req, &ret); err != nil {
+		return "", 0, err
+	}
+	return ret.AppToken, time.Duration(ret.ExpiresIn) * time.Second, nil
+}
+
+func (u *MediaUploader) baseUrl() string {
+	if u.BaseUrl == "" {
+		return defaultOpenApiBaseUrl
+	}
+	return u.BaseUrl
+}
+
+// do 通过 transport 发送请求，检查 HTTP 状态码与 errCode 后解析 data
+func (u *MediaUploader) do(req *http.Request, data interface{}) error {
+	result, err := u.Options.Do(u.Client, req, decodeOpenApiResp)
+	if result != nil && (result.StatusCode < 200 || result.StatusCode >= 300) {
+		return fmt.Errorf("lanxin api error, status: %d, body: %s", result.StatusCode, result.Body)
+	}
+	if err != nil {
+		return err
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("lanxin api error, code: %d, msg: %s", result.ErrCode, result.ErrMsg)
+	}
+	ret := openApiResp{}
+	if err := json.Unmarshal(result.Body, &ret); err != nil {
+		return err
+	}
+	return json.Unmarshal(ret.Data, data)
+}
+
+// decodeOpenApiResp 解析开放平台响应中的错误码
+func decodeOpenApiResp(body []byte) (int, string, error) {
+	ret := &openApiResp{}
+	if err := json.Unmarshal(body, ret); err != nil {
+		return 0, "", err
+	}
+	return ret.ErrCode, ret.ErrMsg, nil
+}
@@ -0,0 +1,63 @@
+package lanxin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yi-nology/common/biz/bot/media"
+)
+
+func TestMediaUploader(t *testing.T) {
+	tokens := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/apptoken/create":
+			tokens++
+			assert.Equal(t, "app", r.URL.Query().Get("appid"))
+			w.Write([]byte(`{"errCode":0,"errMsg":"ok","data":{"appToken":"tk","expiresIn":7200}}`))
+		case "/v1/medias/create":
+			assert.Equal(t, "tk", r.URL.Query().Get("app_token"))
+			assert.Equal(t, "file", r.URL.Query().Get("type"))
+			file, header, err := r.FormFile("media")
+			assert.NoError(t, err)
+			file.Close()
+			assert.Equal(t, "report.pdf", header.Filename)
+			w.Write([]byte(`{"errCode":0,"errMsg":"ok","data":{"mediaId":"m-1"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	u := NewMediaUploader("app", "secret")
+	u.BaseUrl = srv.URL
+	for i := 0; i < 2; i++ {
+		h, err := u.Upload(context.Background(), media.File, "report.pdf", strings.NewReader("%PDF-1.4 ..."))
+		assert.NoError(t, err)
+		assert.Equal(t, "m-1", h.ID)
+		assert.Equal(t, "pdf", h.Format)
+	}
+	assert.Equal(t, 1, tokens)
+
+	b, _ := json.Marshal(NewFile().SetMedia(&media.Handle{ID: "m-1"}))
+	assert.JSONEq(t, `{"msgtype":"file","file":{"mediaId":"m-1"}}`, string(b))
+
+	_, err := u.Upload(context.Background(), media.Voice, "a.amr", strings.NewReader("#!AMR\n"))
+	assert.ErrorIs(t, err, media.ErrUnsupported)
+}
+
+func TestMediaUploaderHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	u := NewMediaUploader("app", "secret")
+	u.BaseUrl = srv.URL
+	_, err := u.Upload(context.Background(), media.File, "a.txt", strings.NewReader("hello"))
+	assert.ErrorContains(t, err, "status: 502")
+}
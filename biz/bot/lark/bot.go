@@ -7,15 +7,18 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strconv"
 	"time"
+
+	"github.com/yi-nology/common/biz/bot/transport"
 )
 
 const (
 	defaultWebHookUrlTemplate = "https://open.feishu.cn/open-apis/bot/v2/hook/%s"
 
+	platform = "lark"
+
 	SecurityNone    = "none"
 	SecuritySign    = "sign"
 	SecurityKeyword = "keyword"
@@ -29,6 +32,7 @@ type LarkBot struct {
 	SecurityType string
 	Secret       string
 	Keywords     string
+	Options      *transport.Options
 }
 
 // Resp 飞书API响应
@@ -42,12 +46,14 @@ type Resp struct {
 }
 
 // New 创建飞书机器人实例
-func New(botKey string) *LarkBot {
+func New(botKey string, opts ...transport.Option) *LarkBot {
+	options := transport.NewOptions(platform, opts...)
 	return &LarkBot{
 		Key:          botKey,
-		WebHookUrl:   fmt.Sprintf(defaultWebHookUrlTemplate, botKey),
-		Client:       &http.Client{Timeout: 5 * time.Second},
+		WebHookUrl:   options.ResolveURL(fmt.Sprintf(defaultWebHookUrlTemplate, botKey)),
+		Client:       options.HTTPClient(),
 		SecurityType: SecurityNone,
+		Options:      options,
 	}
 }
 
@@ -100,26 +106,24 @@ func (l *LarkBot) SendRaw(msgBytes []byte) (bool, error) {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := l.Client.Do(req)
+	result, err := l.Options.Do(l.Client, req, decodeResp)
 	if err != nil {
 		return false, err
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, err
+	if result.ErrCode != 0 {
+		return false, fmt.Errorf("send message failed, code: %d, msg: %s", result.ErrCode, result.ErrMsg)
 	}
 
+	return true, nil
+}
+
+// decodeResp 解析飞书响应中的错误码
+func decodeResp(body []byte) (int, string, error) {
 	r := Resp{}
-	if err = json.Unmarshal(body, &r); err != nil {
-		return false, err
+	if err := json.Unmarshal(body, &r); err != nil {
+		return 0, "", err
 	}
-	if r.Code != 0 {
-		return false, fmt.Errorf("send message failed, code: %d, msg: %s", r.Code, r.Msg)
-	}
-
-	return true, nil
+	return r.Code, r.Msg, nil
 }
 
 // sign 生成飞书签名（timestamp + "\n" + secret -> HMAC-SHA256 -> Base64）
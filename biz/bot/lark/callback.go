@@ -0,0 +1,496 @@
+package lark
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	headerRequestTimestamp = "X-Lark-Request-Timestamp"
+	headerRequestNonce     = "X-Lark-Request-Nonce"
+	headerSignature        = "X-Lark-Signature"
+
+	callbackTypeURLVerification = "url_verification"
+
+	// EventTypeCardAction 卡片回传交互事件（新版卡片回调）
+	EventTypeCardAction = "card.action.trigger"
+	// EventTypeMessageReceive 接收消息事件
+	EventTypeMessageReceive = "im.message.receive_v1"
+
+	// 签名时间戳与当前时间允许的最大偏差，超出视为重放
+	maxTimestampSkew = 5 * time.Minute
+
+	maxCallbackBodySize = 1 << 20
+)
+
+var (
+	ErrInvalidSignature = errors.New("lark: invalid callback signature")
+	ErrInvalidToken     = errors.New("lark: invalid verification token")
+	ErrDecryptFailed    = errors.New("lark: decrypt callback body failed")
+	ErrTimestampExpired = errors.New("lark: callback timestamp expired")
+)
+
+// CardActionHandler 卡片交互处理函数，返回值会作为更新后的卡片（或 toast 等响应体）回写给飞书，返回 nil 表示不更新
+type CardActionHandler func(ctx context.Context, action *CardAction) (interface{}, error)
+
+// MessageHandler 消息事件处理函数
+type MessageHandler func(ctx context.Context, event *MessageEvent) error
+
+// EventHandler 通用事件处理函数
+type EventHandler func(ctx context.Context, event *Event) error
+
+// CallbackHandler 飞书事件订阅与卡片回调处理器，实现 http.Handler；
+// 配置 EncryptKey 时必须携带签名且请求体必须加密，只配置 VerificationToken 时必须携带正确的 token，
+// 两者都为空时不做校验，仅用于本地调试
+type CallbackHandler struct {
+	VerificationToken string
+	EncryptKey        string
+
+	mu            sync.RWMutex
+	cardHandler   CardActionHandler
+	msgHandler    MessageHandler
+	eventHandlers map[string]EventHandler
+}
+
+// EventHeader 事件公共头（2.0 版本事件）
+type EventHeader struct {
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	CreateTime string `json:"create_time"`
+	Token      string `json:"token"`
+	AppID      string `json:"app_id"`
+	TenantKey  string `json:"tenant_key"`
+}
+
+// Event 2.0 版本事件
+type Event struct {
+	Schema string          `json:"schema"`
+	Header EventHeader     `json:"header"`
+	Event  json.RawMessage `json:"event"`
+}
+
+// UserID 用户标识
+type UserID struct {
+	OpenID  string `json:"open_id"`
+	UserID  string `json:"user_id"`
+	UnionID string `json:"union_id"`
+}
+
+// CardActionValue 卡片交互组件回传信息
+type CardActionValue struct {
+	Tag      string                 `json:"tag"`
+	Value    map[string]interface{} `json:"value"`
+	Option   string                 `json:"option"`
+	Timezone string                 `json:"timezone"`
+}
+
+// CardAction 卡片交互回调，兼容旧版消息卡片回调与新版 card.action.trigger 事件
+type CardAction struct {
+	OpenID        string          `json:"open_id"`
+	UserID        string          `json:"user_id"`
+	OpenMessageID string          `json:"open_message_id"`
+	OpenChatID    string          `json:"open_chat_id"`
+	TenantKey     string          `json:"tenant_key"`
+	Token         string          `json:"token"`
+	Action        CardActionValue `json:"action"`
+}
+
+// MessageMention 消息中的 @ 信息
+type MessageMention struct {
+	Key       string `json:"key"`
+	ID        UserID `json:"id"`
+	Name      string `json:"name"`
+	TenantKey string `json:"tenant_key"`
+}
+
+// MessageEvent 接收消息事件
+type MessageEvent struct {
+	Sender struct {
+		SenderID   UserID `json:"sender_id"`
+		SenderType string `json:"sender_type"`
+		TenantKey  string `json:"tenant_key"`
+	} `json:"sender"`
+	Message struct {
+		MessageID   string           `json:"message_id"`
+		RootID      string           `json:"root_id"`
+		ParentID    string           `json:"parent_id"`
+		CreateTime  string           `json:"create_time"`
+		ChatID      string           `json:"chat_id"`
+		ChatType    string           `json:"chat_type"`
+		MessageType string           `json:"message_type"`
+		Content     string           `json:"content"`
+		Mentions    []MessageMention `json:"mentions"`
+	} `json:"message"`
+}
+
+// Text 解析文本消息内容
+func (e *MessageEvent) Text() string {
+	if e.Message.MessageType != "text" {
+		return ""
+	}
+	var t Text
+	if err := json.Unmarshal([]byte(e.Message.Content), &t); err != nil {
+		return ""
+	}
+	return t.Text
+}
+
+// NewCallbackHandler 创建回调处理器，verificationToken 与 encryptKey 为开放平台应用配置，可为空
+func NewCallbackHandler(verificationToken, encryptKey string) *CallbackHandler {
+	return &CallbackHandler{
+		VerificationToken: verificationToken,
+		EncryptKey:        encryptKey,
+		eventHandlers:     make(map[string]EventHandler),
+	}
+}
+
+// OnCardAction 注册卡片交互处理函数
+func (h *CallbackHandler) OnCardAction(handler CardActionHandler) *CallbackHandler {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cardHandler = handler
+	return h
+}
+
+// OnMessage 注册接收消息处理函数
+func (h *CallbackHandler) OnMessage(handler MessageHandler) *CallbackHandler {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.msgHandler = handler
+	return h
+}
+
+// OnEvent 注册指定类型事件的处理函数
+func (h *CallbackHandler) OnEvent(eventType string, handler EventHandler) *CallbackHandler {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.eventHandlers[eventType] = handler
+	return h
+}
+
+// ServeHTTP 处理飞书回调请求
+func (h *CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.Handle(r.Context(), r.Header, body)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidSignature) || errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTimestampExpired) {
+			status = http.StatusUnauthorized
+		} else if errors.Is(err, ErrDecryptFailed) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// Handle 校验、解密并分发一次回调，返回需要写回的响应体
+func (h *CallbackHandler) Handle(ctx context.Context, header http.Header, body []byte) ([]byte, error) {
+	plain, err := h.decryptBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		Type      string          `json:"type"`
+		Challenge string          `json:"challenge"`
+		Token     string          `json:"token"`
+		Schema    string          `json:"schema"`
+		Header    EventHeader     `json:"header"`
+		Event     json.RawMessage `json:"event"`
+		Action    json.RawMessage `json:"action"`
+	}
+	if err := json.Unmarshal(plain, &raw); err != nil {
+		return nil, err
+	}
+
+	// URL 校验，飞书不对 challenge 请求签名，仅校验 Token
+	if raw.Type == callbackTypeURLVerification {
+		if err := h.checkToken(raw.Token); err != nil {
+			return nil, err
+		}
+		return marshal(map[string]string{"challenge": raw.Challenge})
+	}
+
+	if err := h.checkSignature(header, body); err != nil {
+		return nil, err
+	}
+
+	// 2.0 版本事件
+	if raw.Schema != "" {
+		if err := h.checkToken(raw.Header.Token); err != nil {
+			return nil, err
+		}
+		event := &Event{Schema: raw.Schema, Header: raw.Header, Event: raw.Event}
+		return h.dispatchEvent(ctx, event)
+	}
+
+	// 旧版消息卡片回调
+	if len(raw.Action) != 0 {
+		action := &CardAction{}
+		if err := json.Unmarshal(plain, action); err != nil {
+			return nil, err
+		}
+		if err := h.checkToken(action.Token); err != nil {
+			return nil, err
+		}
+		return h.dispatchCardAction(ctx, action, false)
+	}
+
+	return []byte("{}"), nil
+}
+
+// checkSignature 校验请求签名与时间戳，配置 EncryptKey 时签名必填
+func (h *CallbackHandler) checkSignature(header http.Header, body []byte) error {
+	sig := header.Get(headerSignature)
+	if sig == "" {
+		if h.EncryptKey != "" {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+	timestamp := header.Get(headerRequestTimestamp)
+	if err := checkTimestamp(timestamp); err != nil {
+		return err
+	}
+	if !h.verifySignature(timestamp, header.Get(headerRequestNonce), sig, body) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// dispatchEvent 分发 2.0 版本事件
+func (h *CallbackHandler) dispatchEvent(ctx context.Context, event *Event) ([]byte, error) {
+	switch event.Header.EventType {
+	case EventTypeCardAction:
+		var payload struct {
+			Operator UserID          `json:"operator"`
+			Token    string          `json:"token"`
+			Action   CardActionValue `json:"action"`
+			Context  struct {
+				OpenMessageID string `json:"open_message_id"`
+				OpenChatID    string `json:"open_chat_id"`
+			} `json:"context"`
+		}
+		if err := json.Unmarshal(event.Event, &payload); err != nil {
+			return nil, err
+		}
+		action := &CardAction{
+			OpenID:        payload.Operator.OpenID,
+			UserID:        payload.Operator.UserID,
+			OpenMessageID: payload.Context.OpenMessageID,
+			OpenChatID:    payload.Context.OpenChatID,
+			TenantKey:     event.Header.TenantKey,
+			Token:         payload.Token,
+			Action:        payload.Action,
+		}
+		return h.dispatchCardAction(ctx, action, true)
+	case EventTypeMessageReceive:
+		h.mu.RLock()
+		handler := h.msgHandler
+		h.mu.RUnlock()
+		if handler != nil {
+			msg := &MessageEvent{}
+			if err := json.Unmarshal(event.Event, msg); err != nil {
+				return nil, err
+			}
+			if err := handler(ctx, msg); err != nil {
+				return nil, err
+			}
+			return []byte("{}"), nil
+		}
+	}
+
+	h.mu.RLock()
+	handler, ok := h.eventHandlers[event.Header.EventType]
+	h.mu.RUnlock()
+	if ok {
+		if err := handler(ctx, event); err != nil {
+			return nil, err
+		}
+	}
+	return []byte("{}"), nil
+}
+
+// dispatchCardAction 分发卡片交互，新版回调需要将卡片包装为 {"card":{"type":"raw","data":...}}
+func (h *CallbackHandler) dispatchCardAction(ctx context.Context, action *CardAction, wrap bool) ([]byte, error) {
+	h.mu.RLock()
+	handler := h.cardHandler
+	h.mu.RUnlock()
+	if handler == nil {
+		return []byte("{}"), nil
+	}
+
+	ret, err := handler(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		return []byte("{}"), nil
+	}
+	if wrap {
+		switch ret.(type) {
+		case CardInteractive, *CardInteractive:
+			ret = map[string]interface{}{
+				"card": map[string]interface{}{"type": "raw", "data": ret},
+			}
+		}
+	}
+	return marshal(ret)
+}
+
+// checkTimestamp 校验签名时间戳（秒）与当前时间的偏差
+func checkTimestamp(timestamp string) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	skew := time.Since(time.Unix(ts, 0))
+	if skew > maxTimestampSkew || skew < -maxTimestampSkew {
+		return ErrTimestampExpired
+	}
+	return nil
+}
+
+// checkToken 校验 Verification Token
+func (h *CallbackHandler) checkToken(token string) error {
+	if h.VerificationToken == "" {
+		return nil
+	}
+	if !secureEqual(token, h.VerificationToken) {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// verifySignature 校验请求签名
+// 事件订阅：sha256(timestamp + nonce + encryptKey + body)
+// 消息卡片回调：sha1(timestamp + nonce + verificationToken + body)
+func (h *CallbackHandler) verifySignature(timestamp, nonce, signature string, body []byte) bool {
+	if h.EncryptKey != "" {
+		s := sha256.New()
+		s.Write([]byte(timestamp + nonce + h.EncryptKey))
+		s.Write(body)
+		if secureEqual(hex.EncodeToString(s.Sum(nil)), signature) {
+			return true
+		}
+	}
+	if h.VerificationToken != "" {
+		s := sha1.New()
+		s.Write([]byte(timestamp + nonce + h.VerificationToken))
+		s.Write(body)
+		if secureEqual(hex.EncodeToString(s.Sum(nil)), signature) {
+			return true
+		}
+	}
+	return h.EncryptKey == "" && h.VerificationToken == ""
+}
+
+// decryptBody 如果请求体为 {"encrypt": "..."} 则解密，配置了 EncryptKey 时拒绝明文请求体
+func (h *CallbackHandler) decryptBody(body []byte) ([]byte, error) {
+	var enc struct {
+		Encrypt string `json:"encrypt"`
+	}
+	if err := json.Unmarshal(body, &enc); err != nil {
+		return nil, err
+	}
+	if enc.Encrypt == "" {
+		if h.EncryptKey != "" {
+			return nil, fmt.Errorf("%w: plaintext body is not allowed", ErrDecryptFailed)
+		}
+		return body, nil
+	}
+	if h.EncryptKey == "" {
+		return nil, fmt.Errorf("%w: encrypt key is not configured", ErrDecryptFailed)
+	}
+	plain, err := Decrypt(enc.Encrypt, h.EncryptKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptFailed, err)
+	}
+	return plain, nil
+}
+
+// Decrypt 解密飞书加密事件（AES-256-CBC，key 为 sha256(encryptKey)，密文前 16 字节为 IV）
+func Decrypt(encrypted string, encryptKey string) ([]byte, error) {
+	buf, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) < aes.BlockSize || len(buf)%aes.BlockSize != 0 {
+		return nil, errors.New("invalid ciphertext length")
+	}
+	key := sha256.Sum256([]byte(encryptKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	iv, data := buf[:aes.BlockSize], buf[aes.BlockSize:]
+	if len(data) == 0 {
+		return nil, errors.New("empty ciphertext")
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > aes.BlockSize || pad > len(plain) {
+		return nil, errors.New("invalid padding")
+	}
+	for _, b := range plain[len(plain)-pad:] {
+		if int(b) != pad {
+			return nil, errors.New("invalid padding")
+		}
+	}
+	return plain[:len(plain)-pad], nil
+}
+
+// Encrypt 按飞书规则加密数据，主要用于本地调试与测试
+func Encrypt(plain []byte, encryptKey string, iv []byte) (string, error) {
+	if len(iv) != aes.BlockSize {
+		return "", errors.New("iv must be 16 bytes")
+	}
+	key := sha256.Sum256([]byte(encryptKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return "", err
+	}
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	data := append(append([]byte{}, plain...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	out := make([]byte, aes.BlockSize+len(data))
+	copy(out, iv)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out[aes.BlockSize:], data)
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+// secureEqual 常量时间比较字符串
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
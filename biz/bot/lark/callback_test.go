@@ -0,0 +1,199 @@
+package lark
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testToken      = "verification-token"
+	testEncryptKey = "encrypt-key"
+)
+
+func postCallback(t *testing.T, url string, body []byte, header map[string]string) (int, []byte) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	assert.Nil(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, data
+}
+
+func encryptedBody(t *testing.T, plain string) []byte {
+	enc, err := Encrypt([]byte(plain), testEncryptKey, []byte("0123456789abcdef"))
+	assert.Nil(t, err)
+	body, _ := json.Marshal(map[string]string{"encrypt": enc})
+	return body
+}
+
+func signHeader(nonce string, body []byte) map[string]string {
+	return signHeaderAt(strconv.FormatInt(time.Now().Unix(), 10), nonce, body)
+}
+
+func signHeaderAt(timestamp, nonce string, body []byte) map[string]string {
+	s := sha256.New()
+	s.Write([]byte(timestamp + nonce + testEncryptKey))
+	s.Write(body)
+	return map[string]string{
+		headerRequestTimestamp: timestamp,
+		headerRequestNonce:     nonce,
+		headerSignature:        hex.EncodeToString(s.Sum(nil)),
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	enc, err := Encrypt([]byte(`{"hello":"lark"}`), testEncryptKey, []byte("0123456789abcdef"))
+	assert.Nil(t, err)
+	plain, err := Decrypt(enc, testEncryptKey)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"hello":"lark"}`, string(plain))
+
+	_, err = Decrypt(enc, "wrong-key")
+	assert.NotNil(t, err)
+
+	// 只有最后一个字节正确的填充
+	iv := []byte("0123456789abcdef")
+	data := append([]byte("0123456789"), 1, 2, 3, 4, 5, 6)
+	key := sha256.Sum256([]byte(testEncryptKey))
+	block, _ := aes.NewCipher(key[:])
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, data)
+	_, err = Decrypt(base64.StdEncoding.EncodeToString(append(iv, out...)), testEncryptKey)
+	assert.NotNil(t, err)
+}
+
+func TestCallbackURLVerification(t *testing.T) {
+	srv := httptest.NewServer(NewCallbackHandler(testToken, testEncryptKey))
+	defer srv.Close()
+
+	// 飞书不对 challenge 请求签名
+	body := encryptedBody(t, `{"challenge":"ch-123","token":"verification-token","type":"url_verification"}`)
+	status, resp := postCallback(t, srv.URL, body, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"challenge":"ch-123"}`, string(resp))
+
+	body = encryptedBody(t, `{"challenge":"ch-123","token":"bad-token","type":"url_verification"}`)
+	status, _ = postCallback(t, srv.URL, body, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestCallbackInvalidSignature(t *testing.T) {
+	srv := httptest.NewServer(NewCallbackHandler(testToken, testEncryptKey))
+	defer srv.Close()
+
+	body := encryptedBody(t, `{"schema":"2.0","header":{"event_type":"im.message.receive_v1","token":"verification-token"},"event":{}}`)
+	status, _ := postCallback(t, srv.URL, body, signHeader("nonce", body))
+	assert.Equal(t, http.StatusOK, status)
+
+	header := signHeader("nonce", body)
+	header[headerSignature] = "deadbeef"
+	status, _ = postCallback(t, srv.URL, body, header)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// 配置 EncryptKey 时缺少签名
+	status, _ = postCallback(t, srv.URL, body, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// 过期的时间戳，签名本身正确
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	status, _ = postCallback(t, srv.URL, body, signHeaderAt(stale, "nonce", body))
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// 配置 EncryptKey 时拒绝签名正确的明文请求体
+	plain := []byte(`{"challenge":"ch-123","token":"verification-token","type":"url_verification"}`)
+	status, _ = postCallback(t, srv.URL, plain, signHeader("nonce", plain))
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestCallbackTokenOnly(t *testing.T) {
+	called := false
+	handler := NewCallbackHandler(testToken, "").OnMessage(func(ctx context.Context, event *MessageEvent) error {
+		called = true
+		return nil
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	status, _ := postCallback(t, srv.URL, []byte(`{"schema":"2.0","header":{"event_type":"im.message.receive_v1"},"event":{}}`), nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.False(t, called)
+
+	status, _ = postCallback(t, srv.URL, []byte(`{"schema":"2.0","header":{"event_type":"im.message.receive_v1","token":"verification-token"},"event":{}}`), nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, called)
+}
+
+func TestCallbackCardAction(t *testing.T) {
+	handler := NewCallbackHandler(testToken, testEncryptKey).OnCardAction(func(ctx context.Context, action *CardAction) (interface{}, error) {
+		card := CardInteractive{}
+		card.Header.Title.Tag = "plain_text"
+		card.Header.Title.Content = "Approved by " + action.OpenID + " " + action.Action.Value["op"].(string)
+		return card, nil
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	// 旧版消息卡片回调
+	body := encryptedBody(t, `{"open_id":"ou_1","open_message_id":"om_1","token":"verification-token","action":{"tag":"button","value":{"op":"approve"}}}`)
+	status, resp := postCallback(t, srv.URL, body, signHeader("n1", body))
+	assert.Equal(t, http.StatusOK, status)
+	var card CardInteractive
+	assert.Nil(t, json.Unmarshal(resp, &card))
+	assert.Equal(t, "Approved by ou_1 approve", card.Header.Title.Content)
+
+	// 新版 card.action.trigger 回调
+	body = encryptedBody(t, `{"schema":"2.0","header":{"event_type":"card.action.trigger","token":"verification-token"},"event":{"operator":{"open_id":"ou_2"},"action":{"tag":"button","value":{"op":"reject"}},"context":{"open_message_id":"om_2"}}}`)
+	status, resp = postCallback(t, srv.URL, body, signHeader("n2", body))
+	assert.Equal(t, http.StatusOK, status)
+	var wrapped struct {
+		Card struct {
+			Type string          `json:"type"`
+			Data CardInteractive `json:"data"`
+		} `json:"card"`
+	}
+	assert.Nil(t, json.Unmarshal(resp, &wrapped))
+	assert.Equal(t, "raw", wrapped.Card.Type)
+	assert.Equal(t, "Approved by ou_2 reject", wrapped.Card.Data.Header.Title.Content)
+}
+
+func TestCallbackMessageAndEvent(t *testing.T) {
+	var gotText, gotEvent string
+	handler := NewCallbackHandler("", "").
+		OnMessage(func(ctx context.Context, event *MessageEvent) error {
+			gotText = event.Text()
+			return nil
+		}).
+		OnEvent("im.chat.member.bot.added_v1", func(ctx context.Context, event *Event) error {
+			gotEvent = event.Header.EventID
+			return nil
+		})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	status, _ := postCallback(t, srv.URL, []byte(`{"schema":"2.0","header":{"event_type":"im.message.receive_v1"},"event":{"message":{"message_type":"text","content":"{\"text\":\"@_user_1 deploy\"}"}}}`), nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "@_user_1 deploy", gotText)
+
+	status, _ = postCallback(t, srv.URL, []byte(`{"schema":"2.0","header":{"event_id":"ev_1","event_type":"im.chat.member.bot.added_v1"},"event":{}}`), nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ev_1", gotEvent)
+}
@@ -1,5 +1,12 @@
 package lark
 
+import "github.com/yi-nology/common/biz/bot/media"
+
 type Image struct {
 	ImageKey string `json:"image_key"`
 }
+
+// NewImageFromMedia 使用统一上传接口返回的图片
+func NewImageFromMedia(h *media.Handle) Image {
+	return Image{ImageKey: h.ID}
+}
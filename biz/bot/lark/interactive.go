@@ -1,5 +1,7 @@
 package lark
 
+import "encoding/json"
+
 type CardInteractive struct {
 	Elements []struct {
 		Tag  string `json:"tag"`
@@ -26,3 +28,22 @@ type CardInteractive struct {
 		} `json:"title"`
 	} `json:"header"`
 }
+
+// NewMarkdownCard 创建带标题、正文为 lark_md 的交互式卡片
+func NewMarkdownCard(title, content string) CardInteractive {
+	raw := map[string]interface{}{
+		"header": map[string]interface{}{
+			"title": map[string]string{"tag": "plain_text", "content": title},
+		},
+		"elements": []interface{}{
+			map[string]interface{}{
+				"tag":  "div",
+				"text": map[string]string{"tag": "lark_md", "content": content},
+			},
+		},
+	}
+	card := CardInteractive{}
+	b, _ := json.Marshal(raw)
+	json.Unmarshal(b, &card)
+	return card
+}
@@ -0,0 +1,157 @@
+package lark
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/yi-nology/common/biz/bot/media"
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+const (
+	defaultOpenApiBaseUrl = "https://open.feishu.cn"
+
+	// 上传接口默认超时，可通过 transport.WithTimeout 覆盖
+	mediaTimeout = 30 * time.Second
+)
+
+// MediaLimits 飞书自定义机器人仅支持通过 image_key 发送图片
+var MediaLimits = map[media.Type]media.Limit{
+	media.Image: {MaxSize: 10 << 20, Formats: []string{"jpg", "png", "webp", "gif", "bmp", "tiff", "ico", "heic"}},
+}
+
+// MediaUploader 飞书图片上传，需要开放平台应用的 app_id 与 app_secret
+type MediaUploader struct {
+	AppID     string
+	AppSecret string
+	BaseUrl   string
+	Client    *http.Client
+	Options   *transport.Options
+
+	tokens transport.TokenCache
+}
+
+type tenantTokenResp struct {
+	Code              int    `json:"code"`
+	Msg               string `json:"msg"`
+	TenantAccessToken string `json:"tenant_access_token"`
+	Expire            int64  `json:"expire"`
+}
+
+type uploadImageResp struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data struct {
+		ImageKey string `json:"image_key"`
+	} `json:"data"`
+}
+
+// NewMediaUploader 创建图片上传器，opts 可指定 HTTP 客户端、地址覆盖与请求钩子
+func NewMediaUploader(appID, appSecret string, opts ...transport.Option) *MediaUploader {
+	options := transport.NewOptions(platform, append([]transport.Option{transport.WithTimeout(mediaTimeout)}, opts...)...)
+	return &MediaUploader{
+		AppID:     appID,
+		AppSecret: appSecret,
+		BaseUrl:   options.ResolveURL(defaultOpenApiBaseUrl),
+		Client:    options.HTTPClient(),
+		Options:   options,
+	}
+}
+
+// Upload 统一媒体上传，图片校验压缩后上传并返回 image_key
+func (u *MediaUploader) Upload(ctx context.Context, mediaType media.Type, name string, r io.Reader) (*media.Handle, error) {
+	limit, ok := MediaLimits[mediaType]
+	if !ok {
+		return nil, media.ErrUnsupported
+	}
+	data, format, err := media.Prepare(mediaType, name, r, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := u.tenantAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("image_type", "message")
+	part, err := writer.CreateFormFile("image", name)
+	if err != nil {
+		return nil, err
+	}
+	part.Write(data)
+	writer.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.baseUrl()+"/open-apis/im/v1/images", body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	ret := uploadImageResp{}
+	if err := u.do(req, &ret); err != nil {
+		return nil, err
+	}
+	if ret.Code != 0 {
+		return nil, fmt.Errorf("upload image failed, code: %d, msg: %s", ret.Code, ret.Msg)
+	}
+	return &media.Handle{
+		Type:   mediaType,
+		Name:   name,
+		Format: format,
+		Size:   int64(len(data)),
+		ID:     ret.Data.ImageKey,
+	}, nil
+}
+
+// tenantAccessToken 获取 tenant_access_token，带缓存
+func (u *MediaUploader) tenantAccessToken(ctx context.Context) (string, error) {
+	return u.tokens.Get(ctx, u.fetchToken)
+}
+
+// fetchToken 换取 tenant_access_token
+func (u *MediaUploader) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	b, _ := json.Marshal(map[string]string{"app_id": u.AppID, "app_secret": u.AppSecret})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.baseUrl()+"/open-apis/auth/v3/tenant_access_token/internal", bytes.NewReader(b))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	ret := tenantTokenResp{}
+	if err := u.do(req, &ret); err != nil {
+		return "", 0, err
+	}
+	if ret.Code != 0 {
+		return "", 0, fmt.Errorf("get tenant_access_token failed, code: %d, msg: %s", ret.Code, ret.Msg)
+	}
+	return ret.TenantAccessToken, time.Duration(ret.Expire) * time.Second, nil
+}
+
+func (u *MediaUploader) baseUrl() string {
+	if u.BaseUrl == "" {
+		return defaultOpenApiBaseUrl
+	}
+	return u.BaseUrl
+}
+
+// do 通过 transport 发送请求，检查 HTTP 状态码后解析响应
+func (u *MediaUploader) do(req *http.Request, ret interface{}) error {
+	result, err := u.Options.Do(u.Client, req, decodeResp)
+	if result != nil && (result.StatusCode < 200 || result.StatusCode >= 300) {
+		return fmt.Errorf("lark api error, status: %d, body: %s", result.StatusCode, result.Body)
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(result.Body, ret)
+}
@@ -0,0 +1,20 @@
+package bot
+
+import "github.com/yi-nology/common/biz/bot/markdown"
+
+// markdownDialects 各平台 Markdown 方言
+var markdownDialects = map[BotType]markdown.Dialect{
+	WXWork:   markdown.WeCom,
+	Dingtalk: markdown.DingTalk,
+	Lark:     markdown.Lark,
+	Lanxin:   markdown.Lanxin,
+}
+
+// ConvertMarkdown 将 CommonMark 转换为平台支持的 Markdown 方言并按长度限制截断，未适配的平台原样返回
+func ConvertMarkdown(botType BotType, src string) string {
+	d, ok := markdownDialects[botType]
+	if !ok {
+		return src
+	}
+	return markdown.Convert(src, d)
+}
@@ -0,0 +1,82 @@
+package markdown
+
+import "regexp"
+
+// Dialect 平台 Markdown 方言支持的语法与长度限制
+type Dialect struct {
+	Name        string
+	MaxBytes    int            // 内容长度上限（UTF-8 字节），0 表示不限制
+	LineBreak   string         // 块内换行（表格、代码块、引用多行）
+	Headings    bool           // 支持 # 标题，否则降级为加粗
+	Italic      bool           // 支持 *斜体*
+	Strike      bool           // 支持 ~~删除线~~
+	InlineCode  bool           // 支持 `行内代码`
+	CodeBlock   bool           // 支持 ``` 代码块，否则降级为引用
+	Images      bool           // 支持 ![图片](url)，否则降级为链接
+	Tables      bool           // 支持表格，否则降级为对齐文本
+	NestedLists bool           // 支持嵌套列表，否则展开为单层
+	Tags        []string       // 允许保留的 HTML 标签，如 font、at
+	Mention     *regexp.Regexp // @提及格式，截断时保证提及不丢失
+	Escaping    EscapeStyle    // 特殊字符转义方式
+}
+
+var (
+	// DingTalk 钉钉：不支持表格、代码块与删除线，@手机号需出现在正文中
+	DingTalk = Dialect{
+		Name:      "dd",
+		MaxBytes:  20000,
+		LineBreak: "  \n",
+		Headings:  true,
+		Italic:    true,
+		Images:    true,
+		Tags:      []string{"font"},
+		Mention:   regexp.MustCompile(`@(\d{5,}|all)`),
+		Escaping:  EscapeFullWidth,
+	}
+
+	// WeCom 企业微信：仅支持标题、加粗、链接、行内代码、引用与 <font color>
+	WeCom = Dialect{
+		Name:       "wx",
+		MaxBytes:   4096,
+		LineBreak:  "\n",
+		Headings:   true,
+		InlineCode: true,
+		Tags:       []string{"font"},
+		Mention:    regexp.MustCompile(`<@[^>\s]+>`),
+		Escaping:   EscapeFullWidth,
+	}
+
+	// Lark 飞书 lark_md：不支持标题与图片，支持 <at> 与 <font>
+	Lark = Dialect{
+		Name:       "lark",
+		MaxBytes:   30000,
+		LineBreak:  "\n",
+		Italic:     true,
+		Strike:     true,
+		InlineCode: true,
+		CodeBlock:  true,
+		Tags:       []string{"at", "font"},
+		Mention:    regexp.MustCompile(`<at\s[^>]*>[^<]*</at>`),
+		Escaping:   EscapeEntity,
+	}
+
+	// Lanxin 蓝信：基础 Markdown，不支持表格与代码块
+	Lanxin = Dialect{
+		Name:      "lanxin",
+		MaxBytes:  4096,
+		LineBreak: "  \n",
+		Headings:  true,
+		Italic:    true,
+		Images:    true,
+		Escaping:  EscapeFullWidth,
+	}
+)
+
+func (d Dialect) allowTag(name string) bool {
+	for _, t := range d.Tags {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package markdown
+
+import (
+	"strconv"
+	"strings"
+)
+
+// EscapeStyle 平台对 Markdown 特殊字符的转义方式
+type EscapeStyle int
+
+const (
+	// EscapeBackslash 反斜杠转义，如 \*
+	EscapeBackslash EscapeStyle = iota
+	// EscapeEntity HTML 字符实体，如 &#42;
+	EscapeEntity
+	// EscapeFullWidth 平台不识别反斜杠转义（原样显示），替换为外观相近的全角字符
+	EscapeFullWidth
+)
+
+// markdownSpecials 可能触发 Markdown 语法或平台标签的字符
+const markdownSpecials = "\\`*_[]#~>|<"
+
+var escapers = map[EscapeStyle]*strings.Replacer{
+	EscapeBackslash: newEscaper(nil, func(c rune) string { return `\` + string(c) }),
+	EscapeEntity:    newEscaper([]string{"&", "&amp;"}, func(c rune) string { return "&#" + strconv.Itoa(int(c)) + ";" }),
+	// 全角字符与 ASCII 字符相差 0xFEE0；反斜杠本身不会被解析，保持原样
+	EscapeFullWidth: newEscaper(nil, func(c rune) string {
+		if c == '\\' {
+			return string(c)
+		}
+		return string(c + 0xFEE0)
+	}),
+}
+
+func newEscaper(pairs []string, escape func(c rune) string) *strings.Replacer {
+	for _, c := range markdownSpecials {
+		pairs = append(pairs, string(c), escape(c))
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// Escape 转义 s 中的 Markdown 特殊字符，用于在消息中安全嵌入用户输入
+func (d Dialect) Escape(s string) string {
+	if r, ok := escapers[d.Escaping]; ok {
+		return r.Replace(s)
+	}
+	return escapers[EscapeBackslash].Replace(s)
+}
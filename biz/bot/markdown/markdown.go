@@ -0,0 +1,373 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+const truncatedSuffix = "\n..."
+
+var (
+	parser = goldmark.New(goldmark.WithExtensions(extension.Table, extension.Strikethrough)).Parser()
+	tagRe  = regexp.MustCompile(`^</?([a-zA-Z][a-zA-Z0-9-]*)`)
+)
+
+// Convert 将 CommonMark（含 GFM 表格与删除线）转换为平台方言，并按长度限制安全截断
+func Convert(src string, d Dialect) string {
+	source := []byte(src)
+	c := &converter{source: source, dialect: d}
+	doc := parser.Parse(text.NewReader(source))
+	return Truncate(c.blocks(doc), d)
+}
+
+type converter struct {
+	source  []byte
+	dialect Dialect
+}
+
+// blocks 渲染子块，块之间以空行分隔
+func (c *converter) blocks(parent ast.Node) string {
+	var parts []string
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		if s := c.block(n); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func (c *converter) block(n ast.Node) string {
+	switch n := n.(type) {
+	case *ast.Heading:
+		if c.dialect.Headings {
+			return strings.Repeat("#", n.Level) + " " + c.inlines(n)
+		}
+		return "**" + c.inlines(n) + "**"
+	case *ast.Paragraph, *ast.TextBlock:
+		return c.inlines(n)
+	case *ast.Blockquote:
+		return prefixLines(c.blocks(n), "> ")
+	case *ast.List:
+		return strings.Join(c.list(n, ""), "\n")
+	case *ast.FencedCodeBlock:
+		return c.code(c.lines(n), string(n.Language(c.source)))
+	case *ast.CodeBlock:
+		return c.code(c.lines(n), "")
+	case *ast.ThematicBreak:
+		return "---"
+	case *ast.HTMLBlock:
+		return strings.TrimSpace(c.html(strings.Join(c.lines(n), "\n")))
+	case *east.Table:
+		return c.table(n)
+	default:
+		return c.blocks(n)
+	}
+}
+
+// list 渲染列表，不支持嵌套列表的方言将子列表展开为同级
+func (c *converter) list(l *ast.List, indent string) []string {
+	var lines []string
+	index := l.Start
+	for item := l.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := "- "
+		if l.IsOrdered() {
+			marker = fmt.Sprintf("%d. ", index)
+			index++
+		}
+		childIndent := indent
+		if c.dialect.NestedLists {
+			childIndent = indent + strings.Repeat(" ", len(marker))
+		}
+		first := true
+		for child := item.FirstChild(); child != nil; child = child.NextSibling() {
+			if sub, ok := child.(*ast.List); ok {
+				lines = append(lines, c.list(sub, childIndent)...)
+				continue
+			}
+			s := c.block(child)
+			if first {
+				lines = append(lines, indent+marker+s)
+				first = false
+			} else {
+				lines = append(lines, indent+strings.Repeat(" ", len(marker))+s)
+			}
+		}
+		if first {
+			lines = append(lines, indent+strings.TrimSpace(marker))
+		}
+	}
+	return lines
+}
+
+func (c *converter) lines(n ast.Node) []string {
+	var lines []string
+	segs := n.Lines()
+	for i := 0; i < segs.Len(); i++ {
+		seg := segs.At(i)
+		lines = append(lines, strings.TrimRight(string(seg.Value(c.source)), "\n"))
+	}
+	return lines
+}
+
+// code 渲染代码块，不支持代码块的方言降级为引用
+func (c *converter) code(lines []string, lang string) string {
+	if c.dialect.CodeBlock {
+		return "```" + lang + "\n" + strings.Join(lines, "\n") + "\n```"
+	}
+	for i, line := range lines {
+		lines[i] = "> " + line
+	}
+	return strings.Join(lines, c.dialect.LineBreak)
+}
+
+// table 渲染表格，不支持表格的方言降级为按列对齐的文本
+func (c *converter) table(t *east.Table) string {
+	var rows [][]string
+	for row := t.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, strings.TrimSpace(c.inlines(cell)))
+		}
+		rows = append(rows, cells)
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	if c.dialect.Tables {
+		lines := []string{"| " + strings.Join(rows[0], " | ") + " |"}
+		seps := make([]string, len(rows[0]))
+		for i := range seps {
+			seps[i] = "---"
+		}
+		lines = append(lines, "| "+strings.Join(seps, " | ")+" |")
+		for _, row := range rows[1:] {
+			lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	widths := map[int]int{}
+	for _, row := range rows {
+		for i, cell := range row {
+			if w := displayWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	lines := make([]string, 0, len(rows)+1)
+	for r, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell
+			if i < len(row)-1 {
+				cells[i] += strings.Repeat(" ", widths[i]-displayWidth(cell))
+			}
+		}
+		lines = append(lines, strings.Join(cells, "  "))
+		if r == 0 {
+			total := 0
+			for i := range row {
+				total += widths[i]
+			}
+			lines = append(lines, strings.Repeat("-", total+2*(len(row)-1)))
+		}
+	}
+	return strings.Join(lines, c.dialect.LineBreak)
+}
+
+// inlines 渲染行内元素
+func (c *converter) inlines(parent ast.Node) string {
+	b := &strings.Builder{}
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		c.inline(b, n)
+	}
+	return b.String()
+}
+
+func (c *converter) inline(b *strings.Builder, n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Text:
+		b.Write(n.Segment.Value(c.source))
+		if n.HardLineBreak() {
+			b.WriteString(c.dialect.LineBreak)
+		} else if n.SoftLineBreak() {
+			b.WriteString("\n")
+		}
+	case *ast.String:
+		b.Write(n.Value)
+	case *ast.Emphasis:
+		inner := c.inlines(n)
+		switch {
+		case n.Level >= 2:
+			b.WriteString("**" + inner + "**")
+		case c.dialect.Italic:
+			b.WriteString("*" + inner + "*")
+		default:
+			b.WriteString(inner)
+		}
+	case *east.Strikethrough:
+		if c.dialect.Strike {
+			b.WriteString("~~" + c.inlines(n) + "~~")
+		} else {
+			b.WriteString(c.inlines(n))
+		}
+	case *ast.CodeSpan:
+		if c.dialect.InlineCode {
+			b.WriteString("`" + c.inlines(n) + "`")
+		} else {
+			b.WriteString(c.inlines(n))
+		}
+	case *ast.Link:
+		b.WriteString("[" + c.inlines(n) + "](" + string(n.Destination) + ")")
+	case *ast.Image:
+		if c.dialect.Images {
+			b.WriteString("![" + c.inlines(n) + "](" + string(n.Destination) + ")")
+		} else {
+			b.WriteString("[" + c.inlines(n) + "](" + string(n.Destination) + ")")
+		}
+	case *ast.AutoLink:
+		b.Write(n.URL(c.source))
+	case *ast.RawHTML:
+		raw := &strings.Builder{}
+		for i := 0; i < n.Segments.Len(); i++ {
+			seg := n.Segments.At(i)
+			raw.Write(seg.Value(c.source))
+		}
+		b.WriteString(c.html(raw.String()))
+	default:
+		b.WriteString(c.inlines(n))
+	}
+}
+
+var htmlTagRe = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+
+// html 保留方言允许的标签（如 font、at），去除其余标签
+func (c *converter) html(raw string) string {
+	return htmlTagRe.ReplaceAllStringFunc(raw, func(tag string) string {
+		m := tagRe.FindStringSubmatch(tag)
+		if m != nil && c.dialect.allowTag(strings.ToLower(m[1])) {
+			return tag
+		}
+		return ""
+	})
+}
+
+func prefixLines(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = strings.TrimSpace(prefix)
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// displayWidth 计算显示宽度，中日韩等全角字符计为 2
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		if r >= 0x1100 && (r <= 0x115F || (r >= 0x2E80 && r <= 0xA4CF) || (r >= 0xAC00 && r <= 0xD7A3) ||
+			(r >= 0xF900 && r <= 0xFAFF) || (r >= 0xFE30 && r <= 0xFE4F) || (r >= 0xFF00 && r <= 0xFF60) ||
+			(r >= 0xFFE0 && r <= 0xFFE6)) {
+			w += 2
+		} else {
+			w++
+		}
+	}
+	return w
+}
+
+// Truncate 按方言长度限制截断：优先在换行处截断，不拆分 UTF-8 字符、@提及、链接与成对标记，被截掉的提及追加到末尾
+func Truncate(s string, d Dialect) string {
+	if d.MaxBytes <= 0 || len(s) <= d.MaxBytes {
+		return s
+	}
+
+	var mentions []string
+	if d.Mention != nil {
+		seen := map[string]bool{}
+		for _, m := range d.Mention.FindAllString(s, -1) {
+			if !seen[m] {
+				seen[m] = true
+				mentions = append(mentions, m)
+			}
+		}
+	}
+	// 为被截掉的提及预留空间，预留后截断位置前移可能丢失更多提及，直到稳定
+	var cut string
+	var missing []string
+	for {
+		budget := d.MaxBytes - len(truncatedSuffix)
+		if len(missing) > 0 {
+			budget -= len(strings.Join(missing, " ")) + 1
+		}
+		if budget <= 0 {
+			return safeCut(s, d.MaxBytes, d.Mention)
+		}
+		cut = safeCut(s, budget, d.Mention)
+		var lost []string
+		for _, m := range mentions {
+			if !strings.Contains(cut, m) {
+				lost = append(lost, m)
+			}
+		}
+		if len(lost) == len(missing) {
+			break
+		}
+		missing = lost
+	}
+
+	out := cut + truncatedSuffix
+	if len(missing) > 0 {
+		out += "\n" + strings.Join(missing, " ")
+	}
+	return out
+}
+
+func safeCut(s string, n int, mention *regexp.Regexp) string {
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	if mention != nil {
+		for _, loc := range mention.FindAllStringIndex(s, -1) {
+			if loc[0] < n && n < loc[1] {
+				n = loc[0]
+			}
+		}
+	}
+	cut := s[:n]
+	if i := strings.LastIndex(cut, "\n"); i > n/2 {
+		cut = cut[:i]
+	}
+	// 标签、链接未闭合时整体丢弃
+	if i := strings.LastIndex(cut, "<"); i > strings.LastIndex(cut, ">") {
+		cut = cut[:i]
+	}
+	if i := strings.LastIndex(cut, "["); i >= 0 && !strings.Contains(cut[i:], ")") {
+		cut = cut[:i]
+	}
+	// 成对标记数量为奇数时丢弃最后一个未闭合的标记
+	for _, marker := range []string{"```", "**", "~~"} {
+		if strings.Count(cut, marker)%2 == 1 {
+			cut = cut[:strings.LastIndex(cut, marker)]
+		}
+	}
+	if strings.Count(strings.ReplaceAll(cut, "```", ""), "`")%2 == 1 {
+		cut = cut[:strings.LastIndex(cut, "`")]
+	}
+	return strings.TrimRight(cut, " \t\n")
+}
@@ -0,0 +1,46 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertDowngrade(t *testing.T) {
+	src := "# 告警\n\n服务 **order** *异常* ~~旧~~ `code` <span>x</span>\n\n- a\n  - b\n- c\n\n```\nline1\nline2\n```"
+
+	assert.Equal(t, "# 告警\n\n服务 **order** 异常 旧 `code` x\n\n- a\n- b\n- c\n\n> line1\n> line2", Convert(src, WeCom))
+	assert.Equal(t, "**告警**\n\n服务 **order** *异常* ~~旧~~ `code` x\n\n- a\n- b\n- c\n\n```\nline1\nline2\n```", Convert(src, Lark))
+
+	nested := Dialect{NestedLists: true, LineBreak: "\n"}
+	assert.Equal(t, "- a\n  - b\n    1. c", Convert("- a\n  - b\n    1. c", nested))
+}
+
+func TestConvertTable(t *testing.T) {
+	src := "| 指标 | 值 |\n|---|---|\n| CPU | 95% |\n| 内存 | 80% |"
+	assert.Equal(t, "指标  值  \n---------  \nCPU   95%  \n内存  80%", Convert(src, DingTalk))
+	assert.Equal(t, "| 指标 | 值 |\n| --- | --- |\n| CPU | 95% |\n| 内存 | 80% |", Convert(src, Dialect{Tables: true}))
+}
+
+func TestConvertKeepTags(t *testing.T) {
+	src := `<font color="warning">高</font> <at user_id="ou_1">张三</at> <@lisi>`
+	assert.Equal(t, `<font color="warning">高</font> 张三 <@lisi>`, Convert(src, WeCom))
+	assert.Equal(t, `<font color="warning">高</font> <at user_id="ou_1">张三</at> <@lisi>`, Convert(src, Lark))
+}
+
+func TestTruncate(t *testing.T) {
+	d := Dialect{MaxBytes: 40, Mention: WeCom.Mention}
+	src := "<@zhangsan> 请处理\n" + strings.Repeat("中", 30) + " <@lisi>"
+	out := Truncate(src, d)
+	assert.LessOrEqual(t, len(out), 40)
+	assert.True(t, strings.HasPrefix(out, "<@zhangsan> 请处理\n..."))
+	assert.True(t, strings.HasSuffix(out, "<@lisi>"))
+
+	// 不拆分加粗与链接
+	d = Dialect{MaxBytes: 24}
+	assert.Equal(t, "hello\n...", Truncate("hello **world wide web** [link](http://example.com)", d))
+	assert.Equal(t, "hello\n...", Truncate("hello [link](http://example.com)", d))
+
+	assert.Equal(t, "short", Truncate("short", d))
+}
@@ -0,0 +1,13 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertMarkdown(t *testing.T) {
+	src := "## 标题\n\n| a | b |\n|---|---|\n| 1 | 2 |"
+	assert.Equal(t, "**标题**\n\na  b\n----\n1  2", ConvertMarkdown(Lark, src))
+	assert.Equal(t, src, ConvertMarkdown(Webhook, src))
+}
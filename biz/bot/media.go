@@ -0,0 +1,51 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/yi-nology/common/biz/bot/dingtalk"
+	"github.com/yi-nology/common/biz/bot/lanxin"
+	"github.com/yi-nology/common/biz/bot/lark"
+	"github.com/yi-nology/common/biz/bot/media"
+	"github.com/yi-nology/common/biz/bot/transport"
+	"github.com/yi-nology/common/biz/bot/wxwork"
+)
+
+// NewMediaUploader 根据类型创建媒体上传器，opts 与机器人相同，可指定 HTTP 客户端、地址覆盖与请求钩子
+// 企业微信使用机器人 key 上传；飞书、钉钉、蓝信需要开放平台应用凭证
+func NewMediaUploader(botType BotType, botKey string, appID string, appSecret string, opts ...transport.Option) (media.Uploader, error) {
+	switch botType {
+	case WXWork:
+		return wxwork.New(botKey, opts...), nil
+	case Lark:
+		return lark.NewMediaUploader(appID, appSecret, opts...), nil
+	case Dingtalk:
+		return dingtalk.NewMediaUploader(appID, appSecret, opts...), nil
+	case Lanxin:
+		return lanxin.NewMediaUploader(appID, appSecret, opts...), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", media.ErrUnsupported, botType)
+	}
+}
+
+// NewMediaMessage 根据上传结果构建可直接传给 BotOne.Send 的消息
+func NewMediaMessage(botType BotType, h *media.Handle) (interface{}, error) {
+	switch {
+	case botType == WXWork && h.Type == media.Image:
+		return *wxwork.NewImage().SetMedia(h), nil
+	case botType == WXWork && h.Type == media.File:
+		return *wxwork.NewFile().SetMedia(h), nil
+	case botType == WXWork && h.Type == media.Voice:
+		return *wxwork.NewVoice().SetMedia(h), nil
+	case botType == Lark && h.Type == media.Image:
+		return lark.NewImageFromMedia(h), nil
+	case botType == Dingtalk && h.Type == media.Image:
+		return dingtalk.NewImageMarkDown(h.Name, h), nil
+	case botType == Lanxin && h.Type == media.Image:
+		return lanxin.NewImage().SetMedia(h), nil
+	case botType == Lanxin && h.Type == media.File:
+		return lanxin.NewFile().SetMedia(h), nil
+	default:
+		return nil, fmt.Errorf("%w: %s %s", media.ErrUnsupported, botType, h.Type)
+	}
+}
@@ -0,0 +1,95 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+)
+
+// 压缩时依次尝试的 JPEG 质量
+var compressQualities = []int{85, 70, 55, 40}
+
+// 单次缩放比例及最小边长
+const (
+	scaleStep    = 0.75
+	minDimension = 64
+)
+
+// CompressImage 将图片压缩为不超过 maxSize 字节的 JPEG：先降低质量，仍超出时按比例缩小尺寸
+func CompressImage(data []byte, maxSize int64) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	img := flatten(src)
+
+	for {
+		for _, q := range compressQualities {
+			buf := &bytes.Buffer{}
+			if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: q}); err != nil {
+				return nil, err
+			}
+			if int64(buf.Len()) <= maxSize {
+				return buf.Bytes(), nil
+			}
+		}
+
+		b := img.Bounds()
+		w, h := int(float64(b.Dx())*scaleStep), int(float64(b.Dy())*scaleStep)
+		if w < minDimension || h < minDimension {
+			return nil, ErrTooLarge
+		}
+		img = resize(img, w, h)
+	}
+}
+
+// flatten 将图片绘制到白色背景上，去除透明通道
+func flatten(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Over)
+	return dst
+}
+
+// resize 使用区域平均法缩小图片
+func resize(src *image.RGBA, w, h int) *image.RGBA {
+	sb := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	xRatio := float64(sb.Dx()) / float64(w)
+	yRatio := float64(sb.Dy()) / float64(h)
+
+	for y := 0; y < h; y++ {
+		y0, y1 := int(float64(y)*yRatio), int(float64(y+1)*yRatio)
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < w; x++ {
+			x0, x1 := int(float64(x)*xRatio), int(float64(x+1)*xRatio)
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, bl, n uint32
+			for sy := y0; sy < y1 && sy < sb.Dy(); sy++ {
+				for sx := x0; sx < x1 && sx < sb.Dx(); sx++ {
+					off := src.PixOffset(sx, sy)
+					r += uint32(src.Pix[off])
+					g += uint32(src.Pix[off+1])
+					bl += uint32(src.Pix[off+2])
+					n++
+				}
+			}
+			off := dst.PixOffset(x, y)
+			dst.Pix[off] = uint8(r / n)
+			dst.Pix[off+1] = uint8(g / n)
+			dst.Pix[off+2] = uint8(bl / n)
+			dst.Pix[off+3] = 0xFF
+		}
+	}
+	return dst
+}
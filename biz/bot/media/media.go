@@ -0,0 +1,169 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// Type 媒体类型
+type Type string
+
+const (
+	Image Type = "image"
+	File  Type = "file"
+	Voice Type = "voice"
+)
+
+// 图片压缩前允许读取的最大原始大小
+const maxSourceImageSize = 50 << 20
+
+// Ogg 单页最大长度，编码头位于首页
+const oggPageMaxSize = 65307
+
+var (
+	ErrUnsupported   = errors.New("media: unsupported media type for this platform")
+	ErrTooLarge      = errors.New("media: file size exceeds platform limit")
+	ErrInvalidFormat = errors.New("media: file format not allowed")
+	ErrEmpty         = errors.New("media: empty file")
+)
+
+// Limit 平台对某类媒体的限制
+type Limit struct {
+	MaxSize int64    // 最大字节数
+	MinSize int64    // 最小字节数
+	Formats []string // 允许的格式（小写扩展名，不含点），为空表示不限制
+}
+
+// Handle 上传结果，可传给各平台对应的图片、文件、语音消息
+type Handle struct {
+	Type   Type
+	Name   string
+	Format string
+	Size   int64
+	ID     string // 平台返回的 media_id / image_key
+	Base64 string // 企业微信图片消息直接内联的 base64 内容
+	MD5    string // 企业微信图片消息内容的 md5
+}
+
+// Uploader 统一媒体上传接口
+type Uploader interface {
+	Upload(ctx context.Context, mediaType Type, name string, r io.Reader) (*Handle, error)
+}
+
+// Prepare 读取并校验媒体内容，图片超出限制时自动压缩，返回最终内容及格式
+func Prepare(mediaType Type, name string, r io.Reader, limit Limit) ([]byte, string, error) {
+	readLimit := limit.MaxSize
+	if mediaType == Image && readLimit < maxSourceImageSize {
+		readLimit = maxSourceImageSize
+	}
+	var data []byte
+	var err error
+	if readLimit > 0 {
+		data, err = io.ReadAll(io.LimitReader(r, readLimit+1))
+	} else {
+		data, err = io.ReadAll(r)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) == 0 {
+		return nil, "", ErrEmpty
+	}
+	if readLimit > 0 && int64(len(data)) > readLimit {
+		return nil, "", ErrTooLarge
+	}
+
+	format := DetectFormat(mediaType, name, data)
+	if !allowed(limit.Formats, format) {
+		return nil, "", fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+	}
+
+	if limit.MaxSize > 0 && int64(len(data)) > limit.MaxSize {
+		if mediaType != Image {
+			return nil, "", ErrTooLarge
+		}
+		if data, err = CompressImage(data, limit.MaxSize); err != nil {
+			return nil, "", err
+		}
+		format = "jpg"
+		if !allowed(limit.Formats, format) {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+		}
+	}
+	if int64(len(data)) < limit.MinSize {
+		return nil, "", fmt.Errorf("%w: file is smaller than %d bytes", ErrInvalidFormat, limit.MinSize)
+	}
+	return data, format, nil
+}
+
+// DetectFormat 识别媒体格式，图片和语音按文件头识别（语音文件头仅在 Voice 类型下识别），其余按扩展名
+func DetectFormat(mediaType Type, name string, data []byte) string {
+	if mediaType == Voice {
+		if format := detectAudio(data); format != "" {
+			return format
+		}
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte("BM")) && mediaType == Image:
+		return "bmp"
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "webp"
+	}
+
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		return "jpg"
+	case "image/png":
+		return "png"
+	case "image/gif":
+		return "gif"
+	case "image/bmp":
+		return "bmp"
+	case "image/webp":
+		return "webp"
+	case "application/pdf":
+		return "pdf"
+	}
+
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	if ext == "jpeg" {
+		ext = "jpg"
+	}
+	return ext
+}
+
+// detectAudio 按文件头识别语音格式，Ogg 容器需在首页中找到 OpusHead 才视为 opus
+func detectAudio(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("#!AMR")):
+		return "amr"
+	case bytes.HasPrefix(data, []byte("ID3")), len(data) > 1 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		return "mp3"
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return "wav"
+	case bytes.HasPrefix(data, []byte("OggS")):
+		if bytes.Contains(data[:min(len(data), oggPageMaxSize)], []byte("OpusHead")) {
+			return "opus"
+		}
+		return "ogg"
+	}
+	return ""
+}
+
+func allowed(formats []string, format string) bool {
+	if len(formats) == 0 {
+		return true
+	}
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
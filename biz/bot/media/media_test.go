@@ -0,0 +1,81 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func noisyPNG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	rnd := rand.New(rand.NewSource(1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), 0xFF})
+		}
+	}
+	buf := &bytes.Buffer{}
+	assert.Nil(t, png.Encode(buf, img))
+	return buf.Bytes()
+}
+
+func TestPrepareCompressesImage(t *testing.T) {
+	src := noisyPNG(t, 400, 300)
+	limit := Limit{MaxSize: 32 << 10, Formats: []string{"jpg", "png"}}
+
+	data, format, err := Prepare(Image, "chart.png", bytes.NewReader(src), limit)
+	assert.Nil(t, err)
+	assert.Equal(t, "jpg", format)
+	assert.LessOrEqual(t, int64(len(data)), limit.MaxSize)
+
+	_, err = jpeg.Decode(bytes.NewReader(data))
+	assert.Nil(t, err)
+}
+
+func TestPrepareValidation(t *testing.T) {
+	src := noisyPNG(t, 10, 10)
+
+	data, format, err := Prepare(Image, "a.png", bytes.NewReader(src), Limit{MaxSize: 1 << 20, Formats: []string{"jpg", "png"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "png", format)
+	assert.Equal(t, src, data)
+
+	_, _, err = Prepare(Image, "a.png", bytes.NewReader(src), Limit{Formats: []string{"jpg"}})
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	_, _, err = Prepare(File, "a.txt", strings.NewReader(strings.Repeat("x", 100)), Limit{MaxSize: 10})
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, _, err = Prepare(File, "a.txt", strings.NewReader(""), Limit{})
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	_, _, err = Prepare(Voice, "a.mp3", strings.NewReader("ID3........"), Limit{Formats: []string{"amr"}})
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	_, format, err = Prepare(Voice, "a.amr", strings.NewReader("#!AMR\n......"), Limit{Formats: []string{"amr"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "amr", format)
+
+	_, format, err = Prepare(File, "report.XLSX", strings.NewReader("PK\x03\x04....."), Limit{})
+	assert.Nil(t, err)
+	assert.Equal(t, "xlsx", format)
+}
+
+func TestDetectFormat(t *testing.T) {
+	id3 := []byte("ID3\x04\x00........")
+	assert.Equal(t, "mp3", DetectFormat(Voice, "a.bin", id3))
+	assert.Equal(t, "bin", DetectFormat(File, "a.bin", id3))
+	assert.Equal(t, "png", DetectFormat(Image, "a.png", id3))
+
+	opus := []byte("OggS\x00\x02........................OpusHead\x01\x02")
+	vorbis := []byte("OggS\x00\x02.........................\x01vorbis\x00")
+	assert.Equal(t, "opus", DetectFormat(Voice, "a.ogg", opus))
+	assert.Equal(t, "ogg", DetectFormat(Voice, "a.opus", vorbis))
+}
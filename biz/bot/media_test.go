@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yi-nology/common/biz/bot/media"
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+func TestNewMediaUploaderWithOptions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/open-apis/auth/v3/tenant_access_token/internal":
+			w.Write([]byte(`{"code":0,"tenant_access_token":"t","expire":7200}`))
+		case "/open-apis/im/v1/images":
+			w.Write([]byte(`{"code":0,"data":{"image_key":"img-lark"}}`))
+		case "/gettoken":
+			w.Write([]byte(`{"errcode":0,"access_token":"t","expires_in":7200}`))
+		case "/media/upload":
+			w.Write([]byte(`{"errcode":0,"media_id":"img-dd"}`))
+		case "/v1/apptoken/create":
+			w.Write([]byte(`{"errCode":0,"data":{"appToken":"t","expiresIn":7200}}`))
+		case "/v1/medias/create":
+			w.Write([]byte(`{"errCode":0,"data":{"mediaId":"img-lanxin"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 32)
+	for botType, id := range map[BotType]string{Lark: "img-lark", Dingtalk: "img-dd", Lanxin: "img-lanxin"} {
+		var platforms []string
+		uploader, err := NewMediaUploader(botType, "", "app", "secret",
+			transport.WithBaseURL(srv.URL),
+			transport.WithAfterHook(func(info *transport.ResponseInfo) { platforms = append(platforms, info.Platform) }))
+		assert.NoError(t, err)
+		h, err := uploader.Upload(context.Background(), media.Image, "a.png", strings.NewReader(png))
+		assert.NoError(t, err, botType)
+		assert.Equal(t, id, h.ID)
+		// 获取令牌与上传均经过请求钩子
+		assert.Len(t, platforms, 2, botType)
+	}
+}
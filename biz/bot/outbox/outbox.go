@@ -0,0 +1,493 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yi-nology/common/biz/bot"
+	"github.com/yi-nology/common/biz/bot/transport"
+	"github.com/yi-nology/common/utils/xlogger"
+	"github.com/yi-nology/common/utils/xuuid"
+)
+
+const (
+	OUTBOX_RETRY = "bot.outbox.%s.retry" // 等待重试的消息，score 为下次投递时间（毫秒）
+	OUTBOX_QUEUE = "bot.outbox.%s.queue" // 待投递队列
+	OUTBOX_DEAD  = "bot.outbox.%s.dead"  // 死信队列
+
+	OUTBOX_PROCESSING = "bot.outbox.%s.processing.%s.%d" // 投递中的消息，每个 worker 一个列表：出站队列名、实例标识、worker 序号
+
+	_pollBatch = 300
+)
+
+var (
+	// dueScript 将到期的重试消息原子地移入待投递队列
+	dueScript = redis.NewScript(`
+local items = redis.call('zrangebyscore', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, item in ipairs(items) do
+	redis.call('zrem', KEYS[1], item)
+	redis.call('rpush', KEYS[2], item)
+end
+return #items
+`)
+	// retryScript 写入重试队列并从处理中列表确认移除
+	retryScript = redis.NewScript(`
+redis.call('zadd', KEYS[1], ARGV[1], ARGV[2])
+redis.call('lrem', KEYS[2], 1, ARGV[3])
+return 1
+`)
+	// deadScript 写入死信队列并从处理中列表确认移除
+	deadScript = redis.NewScript(`
+redis.call('rpush', KEYS[1], ARGV[1])
+redis.call('lrem', KEYS[2], 1, ARGV[2])
+return 1
+`)
+)
+
+var (
+	ErrUnknownChannel = errors.New("outbox: unknown channel")
+	ErrInvalidPayload = errors.New("outbox: payload must be valid json")
+	ErrNotFound       = errors.New("outbox: message not found")
+)
+
+// Channel 投递通道，即一个机器人的配置；凭证仅保存在内存中，不写入 Redis
+type Channel struct {
+	Type         bot.BotType
+	Key          string
+	SecurityType bot.SecurityType
+	Secret       string
+	Keyword      string
+	Options      []transport.Option
+}
+
+// Message 出站消息
+type Message struct {
+	ID         string          `json:"id"`
+	Channel    string          `json:"channel"`
+	Payload    json.RawMessage `json:"payload"`     // SendRaw 可接受的平台原始消息
+	Attempts   int             `json:"attempts"`    // 已投递次数
+	CreateTime int64           `json:"create_time"` // 创建时间（秒）
+	LastCode   int             `json:"last_code"`   // 最近一次平台错误码或 HTTP 状态码
+	LastError  string          `json:"last_error"`  // 最近一次错误信息
+}
+
+// Outbox 基于 Redis 的机器人出站队列：失败消息按指数退避重试，永久失败进入死信队列；
+// worker 取出的消息先移入自己的处理中列表，投递结果写入后才确认移除，进程崩溃后由 Start 回收，至少投递一次
+type Outbox struct {
+	name     string
+	instance string
+	client   *redis.Client
+	log      xlogger.Logger
+	policy   Policy
+	mu       sync.RWMutex
+	channels map[string]Channel
+}
+
+// New 创建出站队列，name 用于区分 Redis key
+func New(name string, client *redis.Client, log xlogger.Logger) *Outbox {
+	instance, _ := os.Hostname()
+	if instance == "" {
+		instance = "default"
+	}
+	return &Outbox{
+		name:     name,
+		instance: instance,
+		client:   client,
+		log:      log,
+		policy:   DefaultPolicy,
+		channels: map[string]Channel{},
+	}
+}
+
+// Register 注册投递通道
+func (o *Outbox) Register(name string, channel Channel) *Outbox {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.channels[name] = channel
+	return o
+}
+
+// SetInstance 设置实例标识，默认为主机名；每个进程必须唯一且重启后保持不变，Start 只回收本实例未确认的消息
+func (o *Outbox) SetInstance(instance string) *Outbox {
+	o.instance = instance
+	return o
+}
+
+// SetPolicy 设置重试策略
+func (o *Outbox) SetPolicy(policy Policy) *Outbox {
+	o.policy = policy
+	return o
+}
+
+func (o *Outbox) retryKey() string {
+	return fmt.Sprintf(OUTBOX_RETRY, o.name)
+}
+
+func (o *Outbox) queueKey() string {
+	return fmt.Sprintf(OUTBOX_QUEUE, o.name)
+}
+
+func (o *Outbox) deadKey() string {
+	return fmt.Sprintf(OUTBOX_DEAD, o.name)
+}
+
+func (o *Outbox) processingKey(worker int) string {
+	return fmt.Sprintf(OUTBOX_PROCESSING, o.name, o.instance, worker)
+}
+
+// claim worker 处理中列表里的一条消息，写入投递结果时一并确认
+type claim struct {
+	key  string
+	item string
+}
+
+func (o *Outbox) newMessage(channel string, msgBytes []byte) (*Message, error) {
+	o.mu.RLock()
+	_, ok := o.channels[channel]
+	o.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+	if !json.Valid(msgBytes) {
+		return nil, ErrInvalidPayload
+	}
+	return &Message{
+		ID:         xuuid.ShortUuid(),
+		Channel:    channel,
+		Payload:    msgBytes,
+		CreateTime: time.Now().Unix(),
+	}, nil
+}
+
+// Enqueue 将消息放入待投递队列，由后台 worker 异步投递
+func (o *Outbox) Enqueue(ctx context.Context, channel string, msgBytes []byte) (string, error) {
+	m, err := o.newMessage(channel, msgBytes)
+	if err != nil {
+		return "", err
+	}
+	if err := o.push(ctx, m); err != nil {
+		return "", err
+	}
+	return m.ID, nil
+}
+
+// Send 立即投递一次，失败且可重试时转入重试队列
+// 返回 true 表示已送达；false 且 err 为空表示已进入重试队列；err 非空表示永久失败（已进入死信队列）或存储失败
+func (o *Outbox) Send(ctx context.Context, channel string, msgBytes []byte) (bool, error) {
+	m, err := o.newMessage(channel, msgBytes)
+	if err != nil {
+		return false, err
+	}
+	return o.Deliver(ctx, m)
+}
+
+// Deliver 投递一条消息并根据结果重试或转入死信队列
+func (o *Outbox) Deliver(ctx context.Context, m *Message) (bool, error) {
+	ok, sendErr, err := o.deliver(ctx, m, nil)
+	if err != nil {
+		return false, err
+	}
+	return ok, sendErr
+}
+
+// deliver 投递消息，返回是否送达、进入死信队列的原因与存储错误；
+// c 不为空时在写入结果的同时确认处理中列表里的消息，写入失败时消息留在处理中列表等待回收
+func (o *Outbox) deliver(ctx context.Context, m *Message, c *claim) (bool, error, error) {
+	o.mu.RLock()
+	channel, ok := o.channels[m.Channel]
+	o.mu.RUnlock()
+	if !ok {
+		m.LastError = ErrUnknownChannel.Error()
+		return false, fmt.Errorf("%w: %s", ErrUnknownChannel, m.Channel), o.dead(ctx, m, c)
+	}
+
+	var info *transport.ResponseInfo
+	opts := append(append([]transport.Option{}, channel.Options...), transport.WithAfterHook(func(i *transport.ResponseInfo) {
+		info = i
+	}))
+	b := bot.NewBot(channel.Type, channel.Key, channel.SecurityType, channel.Secret, channel.Keyword, opts...)
+
+	m.Attempts++
+	ok, sendErr := b.SendRaw(m.Payload)
+	if ok {
+		o.log.Debugf("Outbox|Deliver|ok id:%s channel:%s attempts:%d", m.ID, m.Channel, m.Attempts)
+		return true, nil, o.ack(ctx, c)
+	}
+
+	if sendErr != nil {
+		m.LastError = sendErr.Error()
+	}
+	if info != nil {
+		m.LastCode = info.ErrCode
+		if m.LastCode == 0 {
+			m.LastCode = info.StatusCode
+		}
+	}
+	if !Retryable(channel.Type, info) || m.Attempts >= o.policy.MaxAttempts {
+		o.log.Errorf("Outbox|Deliver|dead id:%s channel:%s attempts:%d code:%d error %s", m.ID, m.Channel, m.Attempts, m.LastCode, m.LastError)
+		return false, sendErr, o.dead(ctx, m, c)
+	}
+	o.log.Infof("Outbox|Deliver|retry id:%s channel:%s attempts:%d code:%d error %s", m.ID, m.Channel, m.Attempts, m.LastCode, m.LastError)
+	return false, nil, o.retry(ctx, m, time.Now().Add(o.policy.Backoff(m.Attempts)), c)
+}
+
+func (o *Outbox) push(ctx context.Context, m *Message) error {
+	info, _ := json.Marshal(m)
+	if err := o.client.RPush(ctx, o.queueKey(), string(info)).Err(); err != nil {
+		o.log.Errorf("Outbox|push|RPush %s %s error %s", o.queueKey(), info, err)
+		return err
+	}
+	return nil
+}
+
+func (o *Outbox) retry(ctx context.Context, m *Message, at time.Time, c *claim) error {
+	info, _ := json.Marshal(m)
+	var err error
+	if c == nil {
+		err = o.client.ZAdd(ctx, o.retryKey(), redis.Z{
+			Score:  float64(at.UnixMilli()),
+			Member: string(info),
+		}).Err()
+	} else {
+		err = retryScript.Run(ctx, o.client, []string{o.retryKey(), c.key}, at.UnixMilli(), string(info), c.item).Err()
+	}
+	if err != nil {
+		o.log.Errorf("Outbox|retry|ZADD %s %s error %s", o.retryKey(), info, err)
+	}
+	return err
+}
+
+func (o *Outbox) dead(ctx context.Context, m *Message, c *claim) error {
+	info, _ := json.Marshal(m)
+	var err error
+	if c == nil {
+		err = o.client.RPush(ctx, o.deadKey(), string(info)).Err()
+	} else {
+		err = deadScript.Run(ctx, o.client, []string{o.deadKey(), c.key}, string(info), c.item).Err()
+	}
+	if err != nil {
+		o.log.Errorf("Outbox|dead|RPush %s %s error %s", o.deadKey(), info, err)
+	}
+	return err
+}
+
+// ack 投递成功后从处理中列表移除，失败时消息会在回收后重复投递
+func (o *Outbox) ack(ctx context.Context, c *claim) error {
+	if c == nil {
+		return nil
+	}
+	if err := o.client.LRem(ctx, c.key, 1, c.item).Err(); err != nil {
+		o.log.Errorf("Outbox|ack|LRem %s error %s", c.key, err)
+		return err
+	}
+	return nil
+}
+
+// PollDue 将到期的重试消息移入待投递队列，返回移动的数量；ZREM 与 RPUSH 在同一个脚本中执行，多实例不会重复移动
+func (o *Outbox) PollDue(ctx context.Context) (int, error) {
+	key := o.retryKey()
+	moved, err := dueScript.Run(ctx, o.client, []string{key, o.queueKey()}, strconv.FormatInt(time.Now().UnixMilli(), 10), _pollBatch).Int()
+	if err != nil {
+		o.log.Errorf("Outbox|PollDue|%s error %s", key, err)
+		return 0, err
+	}
+	return moved, nil
+}
+
+// Reclaim 将本实例处理中列表里未确认的消息放回待投递队列头部，返回回收的数量
+func (o *Outbox) Reclaim(ctx context.Context) (int, error) {
+	// worker 数量可能与上次不同，按前缀查找全部处理中列表；前缀后只能是 worker 序号，避免匹配到 web1.prod 等其他实例
+	prefix := strings.TrimSuffix(o.processingKey(0), "0")
+	var keys []string
+	iter := o.client.Scan(ctx, 0, escapeGlob(prefix)+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		if isWorkerSuffix(strings.TrimPrefix(iter.Val(), prefix)) {
+			keys = append(keys, iter.Val())
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, key := range keys {
+		for {
+			// 从尾部取出、放到队列头部，保持原有顺序
+			err := o.client.LMove(ctx, key, o.queueKey(), "RIGHT", "LEFT").Err()
+			if err == redis.Nil {
+				break
+			}
+			if err != nil {
+				o.log.Errorf("Outbox|Reclaim|LMove %s error %s", key, err)
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// escapeGlob 转义 SCAN MATCH 中的通配符，队列名与实例标识按字面匹配
+func escapeGlob(s string) string {
+	return globEscaper.Replace(s)
+}
+
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// isWorkerSuffix 判断是否为 worker 序号
+func isWorkerSuffix(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Start 回收本实例上次未确认的消息后启动后台任务：每秒检查到期的重试消息，并由 workers 个协程消费待投递队列，ctx 取消后退出
+func (o *Outbox) Start(ctx context.Context, workers int) error {
+	if workers <= 0 {
+		return errors.New("workers is >=1")
+	}
+	n, err := o.Reclaim(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		o.log.Infof("Outbox|Start|reclaimed %d messages", n)
+	}
+	go o.check(ctx)
+	for i := 0; i < workers; i++ {
+		go o.work(ctx, i)
+	}
+	return nil
+}
+
+func (o *Outbox) check(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.PollDue(ctx)
+		}
+	}
+}
+
+func (o *Outbox) work(ctx context.Context, worker int) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := o.process(ctx, worker, time.Second); err != nil && ctx.Err() == nil {
+			o.log.Errorf("Outbox|work error %s", err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+// ProcessOne 从待投递队列取出一条消息并投递，队列为空时最多等待 timeout，返回是否处理了消息
+func (o *Outbox) ProcessOne(ctx context.Context, timeout time.Duration) (bool, error) {
+	return o.process(ctx, 0, timeout)
+}
+
+// process 将一条消息原子地移入 worker 的处理中列表后投递，结果写入重试或死信队列时一并确认
+func (o *Outbox) process(ctx context.Context, worker int, timeout time.Duration) (bool, error) {
+	key := o.processingKey(worker)
+	item, err := o.client.BLMove(ctx, o.queueKey(), key, "LEFT", "RIGHT", timeout).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	c := &claim{key: key, item: item}
+	m := new(Message)
+	if err := json.Unmarshal([]byte(item), m); err != nil {
+		o.log.Errorf("Outbox|ProcessOne|Unmarshal %s error %s", item, err)
+		return true, o.ack(ctx, c)
+	}
+	// 投递失败已在 deliver 中转入重试或死信队列，只返回存储错误
+	_, _, err = o.deliver(ctx, m, c)
+	return true, err
+}
+
+// DeadLetters 查看死信队列
+func (o *Outbox) DeadLetters(ctx context.Context, offset, limit int64) ([]*Message, error) {
+	items, err := o.client.LRange(ctx, o.deadKey(), offset, offset+limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]*Message, 0, len(items))
+	for _, item := range items {
+		m := new(Message)
+		if err := json.Unmarshal([]byte(item), m); err != nil {
+			o.log.Errorf("Outbox|DeadLetters|Unmarshal %s error %s", item, err)
+			continue
+		}
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
+
+// Replay 将死信消息重新放入待投递队列，投递次数清零
+func (o *Outbox) Replay(ctx context.Context, id string) error {
+	items, err := o.client.LRange(ctx, o.deadKey(), 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		m := new(Message)
+		if err := json.Unmarshal([]byte(item), m); err != nil || m.ID != id {
+			continue
+		}
+		return o.replay(ctx, item, m)
+	}
+	return ErrNotFound
+}
+
+// ReplayAll 重放全部死信消息，返回重放数量
+func (o *Outbox) ReplayAll(ctx context.Context) (int, error) {
+	items, err := o.client.LRange(ctx, o.deadKey(), 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, item := range items {
+		m := new(Message)
+		if err := json.Unmarshal([]byte(item), m); err != nil {
+			continue
+		}
+		if err := o.replay(ctx, item, m); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (o *Outbox) replay(ctx context.Context, item string, m *Message) error {
+	n, err := o.client.LRem(ctx, o.deadKey(), 1, item).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	m.Attempts = 0
+	m.LastCode = 0
+	m.LastError = ""
+	return o.push(ctx, m)
+}
@@ -0,0 +1,202 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/yi-nology/common/biz/bot"
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Errorf(string, ...interface{}) {}
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Infof(string, ...interface{})  {}
+
+func newTestOutbox(t *testing.T, handler http.HandlerFunc) (*Outbox, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	o := New("test", client, nopLogger{}).
+		SetPolicy(Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}).
+		Register("ops", Channel{Type: bot.Dingtalk, Key: "k", Options: []transport.Option{transport.WithBaseURL(srv.URL)}})
+	return o, mr
+}
+
+const textMsg = `{"msgtype":"text","text":{"content":"hi"}}`
+
+func TestSendRetryThenDelivered(t *testing.T) {
+	var calls int32
+	o, mr := newTestOutbox(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	})
+	ctx := context.Background()
+
+	ok, err := o.Send(ctx, "ops", []byte(textMsg))
+	assert.False(t, ok)
+	assert.NoError(t, err)
+	members, _ := mr.ZMembers(o.retryKey())
+	assert.Len(t, members, 1)
+
+	time.Sleep(5 * time.Millisecond)
+	moved, err := o.PollDue(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, moved)
+
+	processed, err := o.ProcessOne(ctx, 100*time.Millisecond)
+	assert.True(t, processed)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.False(t, mr.Exists(o.queueKey()))
+	assert.False(t, mr.Exists(o.deadKey()))
+	assert.False(t, mr.Exists(o.processingKey(0)))
+}
+
+func TestReclaimAfterCrash(t *testing.T) {
+	var mr *miniredis.Miniredis
+	var calls int32
+	o, mr := newTestOutbox(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		// 投递失败后 Redis 不可用，重试记录无法写入
+		mr.Close()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	ctx := context.Background()
+
+	first, _ := o.Enqueue(ctx, "ops", []byte(textMsg))
+	second, _ := o.Enqueue(ctx, "ops", []byte(textMsg))
+	processed, err := o.ProcessOne(ctx, 100*time.Millisecond)
+	assert.True(t, processed)
+	assert.Error(t, err)
+	assert.NoError(t, mr.Restart())
+	items, _ := mr.List(o.processingKey(0))
+	assert.Len(t, items, 1)
+
+	// 模拟另一个 worker 取出消息后进程退出
+	assert.NoError(t, o.client.LMove(ctx, o.queueKey(), o.processingKey(3), "LEFT", "RIGHT").Err())
+	assert.False(t, mr.Exists(o.queueKey()))
+
+	// 其他实例的处理中列表不受影响
+	other := New("test", o.client, nopLogger{}).SetInstance("other")
+	n, err := other.Reclaim(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	// 实例名以本实例为前缀或含通配符的其他实例同样不受影响
+	prod := New("test", o.client, nopLogger{}).SetInstance(o.instance + ".prod")
+	assert.NoError(t, o.client.RPush(ctx, prod.processingKey(0), "in-flight").Err())
+	glob := New("test", o.client, nopLogger{}).SetInstance("*")
+	n, err = glob.Reclaim(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	n, err = o.Reclaim(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.True(t, mr.Exists(prod.processingKey(0)))
+	assert.False(t, mr.Exists(o.processingKey(0)))
+	assert.False(t, mr.Exists(o.processingKey(3)))
+	items, _ = mr.List(o.queueKey())
+	var ids []string
+	for _, item := range items {
+		m := new(Message)
+		assert.NoError(t, json.Unmarshal([]byte(item), m))
+		ids = append(ids, m.ID)
+	}
+	assert.ElementsMatch(t, []string{first, second}, ids)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestPermanentErrorDeadLetterAndReplay(t *testing.T) {
+	fail := int32(1)
+	o, _ := newTestOutbox(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			w.Write([]byte(`{"errcode":310000,"errmsg":"keywords not in content"}`))
+			return
+		}
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	})
+	ctx := context.Background()
+
+	id, err := o.Enqueue(ctx, "ops", []byte(textMsg))
+	assert.NoError(t, err)
+	processed, err := o.ProcessOne(ctx, 100*time.Millisecond)
+	assert.True(t, processed)
+	assert.NoError(t, err)
+
+	dead, err := o.DeadLetters(ctx, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, dead, 1)
+	assert.Equal(t, id, dead[0].ID)
+	assert.Equal(t, 310000, dead[0].LastCode)
+	assert.Equal(t, 1, dead[0].Attempts)
+	assert.JSONEq(t, textMsg, string(dead[0].Payload))
+
+	atomic.StoreInt32(&fail, 0)
+	assert.NoError(t, o.Replay(ctx, id))
+	assert.ErrorIs(t, o.Replay(ctx, id), ErrNotFound)
+	processed, err = o.ProcessOne(ctx, 100*time.Millisecond)
+	assert.True(t, processed)
+	assert.NoError(t, err)
+	dead, _ = o.DeadLetters(ctx, 0, 10)
+	assert.Empty(t, dead)
+}
+
+func TestMaxAttempts(t *testing.T) {
+	o, _ := newTestOutbox(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	ctx := context.Background()
+
+	m, err := o.newMessage("ops", []byte(textMsg))
+	assert.NoError(t, err)
+	m.Attempts = 2
+	ok, err := o.Deliver(ctx, m)
+	assert.False(t, ok)
+	assert.Error(t, err)
+	dead, _ := o.DeadLetters(ctx, 0, 10)
+	assert.Len(t, dead, 1)
+	assert.Equal(t, http.StatusBadGateway, dead[0].LastCode)
+
+	_, err = o.Enqueue(ctx, "unknown", []byte(textMsg))
+	assert.ErrorIs(t, err, ErrUnknownChannel)
+	_, err = o.Enqueue(ctx, "ops", []byte("not json"))
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+}
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, Retryable(bot.Lark, &transport.ResponseInfo{StatusCode: 200, ErrCode: 11232}))
+	assert.False(t, Retryable(bot.Lark, &transport.ResponseInfo{StatusCode: 200, ErrCode: 19024}))
+	assert.True(t, Retryable(bot.Slack, &transport.ResponseInfo{StatusCode: 429}))
+	assert.False(t, Retryable(bot.Slack, &transport.ResponseInfo{StatusCode: 404}))
+	assert.False(t, Retryable(bot.WXWork, &transport.ResponseInfo{Err: context.Canceled}))
+	assert.True(t, Retryable(bot.WXWork, &transport.ResponseInfo{Err: errors.New("connection refused")}))
+	assert.True(t, Retryable(bot.WXWork, &transport.ResponseInfo{StatusCode: 200, Err: io.ErrUnexpectedEOF}))
+	// 已删除的 webhook 返回 HTML 错误页，解析失败但不应重试
+	htmlErr := json.Unmarshal([]byte("<html>"), &struct{}{})
+	assert.False(t, Retryable(bot.Dingtalk, &transport.ResponseInfo{StatusCode: 404, Err: htmlErr}))
+	assert.False(t, Retryable(bot.Dingtalk, &transport.ResponseInfo{StatusCode: 200, Err: htmlErr}))
+	assert.True(t, Retryable(bot.Dingtalk, &transport.ResponseInfo{StatusCode: 502, Err: htmlErr}))
+
+	p := Policy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	assert.Equal(t, time.Second, p.Backoff(1))
+	assert.Equal(t, 4*time.Second, p.Backoff(3))
+	assert.Equal(t, 5*time.Second, p.Backoff(10))
+}
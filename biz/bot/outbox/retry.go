@@ -0,0 +1,86 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/yi-nology/common/biz/bot"
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+// Policy 重试策略
+type Policy struct {
+	MaxAttempts int           // 最大投递次数（含首次）
+	BaseDelay   time.Duration // 首次重试间隔，之后按指数增长
+	MaxDelay    time.Duration // 重试间隔上限
+}
+
+// DefaultPolicy 默认重试策略：最多投递 6 次，间隔 2s、4s、8s... 上限 5 分钟
+var DefaultPolicy = Policy{
+	MaxAttempts: 6,
+	BaseDelay:   2 * time.Second,
+	MaxDelay:    5 * time.Minute,
+}
+
+// Backoff 返回第 attempt 次失败后的重试间隔
+func (p Policy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// PermanentCodes 各平台重试无意义的错误码，如关键字校验失败、签名错误、机器人已失效
+var PermanentCodes = map[bot.BotType][]int{
+	bot.WXWork:   {93000, 93004, 40008, 40058},
+	bot.Dingtalk: {300001, 310000, 400013},
+	bot.Lark:     {9499, 19001, 19021, 19022, 19024},
+	bot.Telegram: {400, 401, 403, 404},
+}
+
+// Retryable 根据请求结果判断是否值得重试：先看 HTTP 状态码，错误只重试网络层错误，
+// 响应体无法解析（如 webhook 已删除返回的 HTML 页面）时重试也不会成功
+func Retryable(botType bot.BotType, info *transport.ResponseInfo) bool {
+	if info == nil {
+		return true
+	}
+	switch {
+	case info.StatusCode == http.StatusTooManyRequests, info.StatusCode == http.StatusRequestTimeout:
+		return true
+	case info.StatusCode >= 400 && info.StatusCode < 500:
+		return false
+	case info.StatusCode >= 500:
+		return true
+	}
+	if info.Err != nil {
+		if errors.Is(info.Err, context.Canceled) {
+			return false
+		}
+		if info.StatusCode == 0 {
+			return true
+		}
+		// 已收到响应，只有读取响应体时连接中断才重试
+		var netErr net.Error
+		return errors.As(info.Err, &netErr) || errors.Is(info.Err, io.ErrUnexpectedEOF)
+	}
+	for _, code := range PermanentCodes[botType] {
+		if info.ErrCode == code {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package slack
+
+// 文本对象类型
+const (
+	TextPlain    = "plain_text"
+	TextMarkdown = "mrkdwn"
+)
+
+// Message Slack 消息，text 作为通知摘要，blocks 为 Block Kit 布局
+type Message struct {
+	Text   string   `json:"text,omitempty"`
+	Blocks []*Block `json:"blocks,omitempty"`
+}
+
+// TextObject 文本对象
+type TextObject struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+// Element Block 中的元素（按钮、图片、文本）
+type Element struct {
+	Type     string      `json:"type"`
+	Text     *TextObject `json:"text,omitempty"`
+	URL      string      `json:"url,omitempty"`
+	Value    string      `json:"value,omitempty"`
+	ActionID string      `json:"action_id,omitempty"`
+	Style    string      `json:"style,omitempty"`
+	ImageURL string      `json:"image_url,omitempty"`
+	AltText  string      `json:"alt_text,omitempty"`
+}
+
+// Block Block Kit 布局块
+type Block struct {
+	Type      string        `json:"type"`
+	Text      *TextObject   `json:"text,omitempty"`
+	Fields    []*TextObject `json:"fields,omitempty"`
+	Elements  []interface{} `json:"elements,omitempty"` // *Element 或 *TextObject
+	ImageURL  string        `json:"image_url,omitempty"`
+	AltText   string        `json:"alt_text,omitempty"`
+	Accessory *Element      `json:"accessory,omitempty"`
+}
+
+// NewText 创建纯文本消息
+func NewText(text string) *Message {
+	return &Message{Text: text}
+}
+
+// NewMessage 创建 Block Kit 消息
+func NewMessage() *Message {
+	return &Message{}
+}
+
+// SetText 设置通知摘要文本
+func (m *Message) SetText(text string) *Message {
+	m.Text = text
+	return m
+}
+
+// AddHeader 添加标题块
+func (m *Message) AddHeader(text string) *Message {
+	m.Blocks = append(m.Blocks, &Block{Type: "header", Text: &TextObject{Type: TextPlain, Text: text, Emoji: true}})
+	return m
+}
+
+// AddSection 添加 mrkdwn 文本块
+func (m *Message) AddSection(markdown string) *Message {
+	m.Blocks = append(m.Blocks, &Block{Type: "section", Text: &TextObject{Type: TextMarkdown, Text: markdown}})
+	return m
+}
+
+// AddFields 添加双列字段块
+func (m *Message) AddFields(fields ...string) *Message {
+	block := &Block{Type: "section"}
+	for _, f := range fields {
+		block.Fields = append(block.Fields, &TextObject{Type: TextMarkdown, Text: f})
+	}
+	m.Blocks = append(m.Blocks, block)
+	return m
+}
+
+// AddDivider 添加分割线
+func (m *Message) AddDivider() *Message {
+	m.Blocks = append(m.Blocks, &Block{Type: "divider"})
+	return m
+}
+
+// AddContext 添加上下文说明
+func (m *Message) AddContext(markdown string) *Message {
+	m.Blocks = append(m.Blocks, &Block{Type: "context", Elements: []interface{}{&TextObject{Type: TextMarkdown, Text: markdown}}})
+	return m
+}
+
+// AddImage 添加图片块
+func (m *Message) AddImage(imageURL, altText string) *Message {
+	m.Blocks = append(m.Blocks, &Block{Type: "image", ImageURL: imageURL, AltText: altText})
+	return m
+}
+
+// AddLinkButton 添加跳转按钮，style 可选 primary / danger
+func (m *Message) AddLinkButton(text, url, style string) *Message {
+	button := &Element{Type: "button", Text: &TextObject{Type: TextPlain, Text: text}, URL: url, Style: style}
+	if n := len(m.Blocks); n > 0 && m.Blocks[n-1].Type == "actions" {
+		m.Blocks[n-1].Elements = append(m.Blocks[n-1].Elements, button)
+		return m
+	}
+	m.Blocks = append(m.Blocks, &Block{Type: "actions", Elements: []interface{}{button}})
+	return m
+}
@@ -0,0 +1,136 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+const (
+	defaultWebHookUrlTemplate = "https://hooks.slack.com/services/%s"
+
+	platform = "slack"
+
+	SecurityNone    = "none"
+	SecurityKeyword = "keyword"
+)
+
+// Bot Slack Incoming Webhook 机器人
+type Bot struct {
+	Key          string
+	WebHookUrl   string
+	Client       *http.Client
+	SecurityType string
+	Keywords     string
+	Options      *transport.Options
+}
+
+// New 创建 Slack 机器人实例，botKey 为 webhook 地址 services/ 之后的部分（T000/B000/XXXX），也可直接传入完整地址
+func New(botKey string, opts ...transport.Option) *Bot {
+	options := transport.NewOptions(platform, opts...)
+	webHookUrl := botKey
+	if !strings.HasPrefix(botKey, "http://") && !strings.HasPrefix(botKey, "https://") {
+		webHookUrl = fmt.Sprintf(defaultWebHookUrlTemplate, botKey)
+	}
+	return &Bot{
+		Key:          botKey,
+		WebHookUrl:   options.ResolveURL(webHookUrl),
+		Client:       options.HTTPClient(),
+		SecurityType: SecurityNone,
+		Options:      options,
+	}
+}
+
+// AddKeyword 设置关键字模式，发送时在 text 前注入关键字
+func (b *Bot) AddKeyword(keyword string) *Bot {
+	if keyword != "" {
+		b.SecurityType = SecurityKeyword
+		b.Keywords = keyword
+	}
+	return b
+}
+
+// Send 发送消息
+func (b *Bot) Send(msg interface{}) (bool, error) {
+	var msgBytes []byte
+	var err error
+	switch m := msg.(type) {
+	case string:
+		msgBytes, err = json.Marshal(NewText(m))
+	default:
+		msgBytes, err = json.Marshal(msg)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if b.SecurityType == SecurityKeyword && b.Keywords != "" {
+		msgBytes = b.injectKeyword(msgBytes)
+	}
+	return b.SendRaw(msgBytes)
+}
+
+// SendRaw 发送原始JSON消息
+func (b *Bot) SendRaw(msgBytes []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, b.WebHookUrl, bytes.NewReader(msgBytes))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	result, err := b.Options.Do(b.Client, req, decodeResp)
+	if err != nil {
+		return false, err
+	}
+	if result.StatusCode != http.StatusOK || result.ErrCode != 0 {
+		return false, fmt.Errorf("send message failed, status: %d, msg: %s", result.StatusCode, result.ErrMsg)
+	}
+	return true, nil
+}
+
+// decodeResp Slack 成功时返回纯文本 ok，失败时返回错误标识如 invalid_payload
+func decodeResp(body []byte) (int, string, error) {
+	text := strings.TrimSpace(string(body))
+	if text == "ok" {
+		return 0, "", nil
+	}
+	return -1, text, nil
+}
+
+// injectKeyword 在消息 text 中注入关键字
+func (b *Bot) injectKeyword(msgBytes []byte) []byte {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(msgBytes, &raw); err != nil {
+		return msgBytes
+	}
+	if text, ok := raw["text"].(string); ok && text != "" {
+		raw["text"] = b.Keywords + "\n" + text
+	} else {
+		raw["text"] = b.Keywords
+	}
+	newBytes, err := json.Marshal(raw)
+	if err != nil {
+		return msgBytes
+	}
+	return newBytes
+}
+
+// CheckMessage 检查消息是否为合法的 Slack 消息格式
+func (b *Bot) CheckMessage(msg string) bool {
+	if len(msg) == 0 {
+		return false
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(msg), &raw); err != nil {
+		return false
+	}
+	if text, ok := raw["text"].(string); ok && text != "" {
+		return true
+	}
+	blocks, ok := raw["blocks"].([]interface{})
+	return ok && len(blocks) > 0
+}
@@ -0,0 +1,51 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+func TestSend(t *testing.T) {
+	var path string
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		b, _ := io.ReadAll(r.Body)
+		body = nil
+		json.Unmarshal(b, &body)
+		if body["text"] == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid_payload"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	bot := New("T000/B000/XXXX", transport.WithBaseURL(srv.URL)).AddKeyword("[alert]")
+	ok, err := bot.Send(NewMessage().SetText("fallback").AddHeader("部署完成").AddSection("*service* ok").AddDivider())
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, "/services/T000/B000/XXXX", path)
+	assert.Equal(t, "[alert]\nfallback", body["text"])
+	assert.Len(t, body["blocks"], 3)
+
+	ok, err = bot.SendRaw([]byte(`{"blocks":[]}`))
+	assert.False(t, ok)
+	assert.ErrorContains(t, err, "invalid_payload")
+}
+
+func TestCheckMessage(t *testing.T) {
+	bot := New("https://hooks.slack.com/services/T/B/X")
+	assert.Equal(t, "https://hooks.slack.com/services/T/B/X", bot.WebHookUrl)
+	assert.True(t, bot.CheckMessage(`{"text":"hi"}`))
+	assert.True(t, bot.CheckMessage(`{"blocks":[{"type":"divider"}]}`))
+	assert.False(t, bot.CheckMessage(`{"blocks":[]}`))
+	assert.False(t, bot.CheckMessage(""))
+}
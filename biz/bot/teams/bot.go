@@ -0,0 +1,96 @@
+package teams
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+const platform = "teams"
+
+// Bot Microsoft Teams 机器人（Incoming Webhook / Workflows，发送 Adaptive Card）
+type Bot struct {
+	WebHookUrl string
+	Client     *http.Client
+	Options    *transport.Options
+}
+
+// New 创建 Teams 机器人实例（传入完整的webhook URL）
+func New(webhookUrl string, opts ...transport.Option) *Bot {
+	options := transport.NewOptions(platform, opts...)
+	return &Bot{
+		WebHookUrl: options.ResolveURL(webhookUrl),
+		Client:     options.HTTPClient(),
+		Options:    options,
+	}
+}
+
+// Send 发送消息，支持 string、*AdaptiveCard 与 *Message
+func (b *Bot) Send(msg interface{}) (bool, error) {
+	var payload interface{}
+	switch m := msg.(type) {
+	case string:
+		payload = NewAdaptiveCard().AddText(m).Message()
+	case *AdaptiveCard:
+		payload = m.Message()
+	case AdaptiveCard:
+		payload = m.Message()
+	default:
+		payload = msg
+	}
+	msgBytes, err := json.Marshal(payload)
+	if err != nil {
+		return false, err
+	}
+	return b.SendRaw(msgBytes)
+}
+
+// SendRaw 发送原始JSON消息
+func (b *Bot) SendRaw(msgBytes []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, b.WebHookUrl, bytes.NewReader(msgBytes))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	result, err := b.Options.Do(b.Client, req, decodeResp)
+	if err != nil {
+		return false, err
+	}
+	if result.StatusCode < 200 || result.StatusCode >= 300 || result.ErrCode != 0 {
+		return false, fmt.Errorf("send message failed, status: %d, msg: %s", result.StatusCode, result.ErrMsg)
+	}
+	return true, nil
+}
+
+// decodeResp 旧版 connector 成功返回 "1"，Workflows 返回空响应体
+func decodeResp(body []byte) (int, string, error) {
+	text := strings.TrimSpace(string(body))
+	if text == "" || text == "1" {
+		return 0, "", nil
+	}
+	return -1, text, nil
+}
+
+// CheckMessage 检查消息是否为合法的 Teams 消息格式
+func (b *Bot) CheckMessage(msg string) bool {
+	if len(msg) == 0 {
+		return false
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(msg), &raw); err != nil {
+		return false
+	}
+	if t, ok := raw["@type"].(string); ok && t == "MessageCard" {
+		return true
+	}
+	if t, ok := raw["type"].(string); !ok || t != "message" {
+		return false
+	}
+	attachments, ok := raw["attachments"].([]interface{})
+	return ok && len(attachments) > 0
+}
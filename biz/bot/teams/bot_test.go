@@ -0,0 +1,46 @@
+package teams
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendAdaptiveCard(t *testing.T) {
+	var msg Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &msg)
+		w.Write([]byte("1"))
+	}))
+	defer srv.Close()
+
+	bot := New(srv.URL)
+	card := NewAdaptiveCard().AddTitle("发布通知").AddFacts(&Fact{Title: "版本", Value: "v1.2.0"}).AddOpenUrl("查看", "https://example.com")
+	ok, err := bot.Send(card)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, "message", msg.Type)
+	assert.Len(t, msg.Attachments, 1)
+	assert.Equal(t, "application/vnd.microsoft.card.adaptive", msg.Attachments[0].ContentType)
+
+	ok, err = bot.Send("plain text")
+	assert.True(t, ok)
+	assert.NoError(t, err)
+}
+
+func TestSendFailed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Bad payload"))
+	}))
+	defer srv.Close()
+
+	ok, err := New(srv.URL).SendRaw([]byte(`{}`))
+	assert.False(t, ok)
+	assert.Error(t, err)
+}
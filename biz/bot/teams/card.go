@@ -0,0 +1,148 @@
+package teams
+
+const (
+	adaptiveCardSchema      = "http://adaptivecards.io/schemas/adaptive-card.json"
+	adaptiveCardVersion     = "1.4"
+	adaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+)
+
+// Message Teams 消息
+type Message struct {
+	Type        string        `json:"type"`
+	Attachments []*Attachment `json:"attachments"`
+}
+
+// Attachment 消息附件
+type Attachment struct {
+	ContentType string        `json:"contentType"`
+	ContentURL  *string       `json:"contentUrl"`
+	Content     *AdaptiveCard `json:"content"`
+}
+
+// AdaptiveCard 自适应卡片
+type AdaptiveCard struct {
+	Schema  string     `json:"$schema"`
+	Type    string     `json:"type"`
+	Version string     `json:"version"`
+	Body    []*Element `json:"body"`
+	Actions []*Action  `json:"actions,omitempty"`
+	MSTeams *MSTeams   `json:"msteams,omitempty"`
+}
+
+// MSTeams Teams 扩展属性
+type MSTeams struct {
+	Width    string     `json:"width,omitempty"`
+	Entities []*Mention `json:"entities,omitempty"`
+}
+
+// Mention @ 提及
+type Mention struct {
+	Type      string `json:"type"`
+	Text      string `json:"text"`
+	Mentioned struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"mentioned"`
+}
+
+// Fact 键值对
+type Fact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// Element 卡片元素
+type Element struct {
+	Type    string  `json:"type"`
+	Text    string  `json:"text,omitempty"`
+	Size    string  `json:"size,omitempty"`
+	Weight  string  `json:"weight,omitempty"`
+	Color   string  `json:"color,omitempty"`
+	Wrap    bool    `json:"wrap,omitempty"`
+	URL     string  `json:"url,omitempty"`
+	AltText string  `json:"altText,omitempty"`
+	Facts   []*Fact `json:"facts,omitempty"`
+}
+
+// Action 卡片按钮
+type Action struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url,omitempty"`
+}
+
+// NewAdaptiveCard 创建自适应卡片
+func NewAdaptiveCard() *AdaptiveCard {
+	return &AdaptiveCard{
+		Schema:  adaptiveCardSchema,
+		Type:    "AdaptiveCard",
+		Version: adaptiveCardVersion,
+	}
+}
+
+// AddTitle 添加标题
+func (c *AdaptiveCard) AddTitle(text string) *AdaptiveCard {
+	c.Body = append(c.Body, &Element{Type: "TextBlock", Text: text, Size: "Large", Weight: "Bolder", Wrap: true})
+	return c
+}
+
+// AddText 添加文本（支持 Teams 的 Markdown 子集）
+func (c *AdaptiveCard) AddText(text string) *AdaptiveCard {
+	c.Body = append(c.Body, &Element{Type: "TextBlock", Text: text, Wrap: true})
+	return c
+}
+
+// AddColorText 添加带颜色的文本，color 可选 Default / Good / Warning / Attention / Accent
+func (c *AdaptiveCard) AddColorText(text, color string) *AdaptiveCard {
+	c.Body = append(c.Body, &Element{Type: "TextBlock", Text: text, Color: color, Wrap: true})
+	return c
+}
+
+// AddFacts 添加键值对列表
+func (c *AdaptiveCard) AddFacts(facts ...*Fact) *AdaptiveCard {
+	c.Body = append(c.Body, &Element{Type: "FactSet", Facts: facts})
+	return c
+}
+
+// AddImage 添加图片
+func (c *AdaptiveCard) AddImage(url, altText string) *AdaptiveCard {
+	c.Body = append(c.Body, &Element{Type: "Image", URL: url, AltText: altText})
+	return c
+}
+
+// AddOpenUrl 添加跳转按钮
+func (c *AdaptiveCard) AddOpenUrl(title, url string) *AdaptiveCard {
+	c.Actions = append(c.Actions, &Action{Type: "Action.OpenUrl", Title: title, URL: url})
+	return c
+}
+
+// AddMention @ 用户，text 中需包含 <at>name</at>
+func (c *AdaptiveCard) AddMention(id, name string) *AdaptiveCard {
+	if c.MSTeams == nil {
+		c.MSTeams = &MSTeams{}
+	}
+	m := &Mention{Type: "mention", Text: "<at>" + name + "</at>"}
+	m.Mentioned.ID = id
+	m.Mentioned.Name = name
+	c.MSTeams.Entities = append(c.MSTeams.Entities, m)
+	return c
+}
+
+// FullWidth 卡片使用全宽显示
+func (c *AdaptiveCard) FullWidth() *AdaptiveCard {
+	if c.MSTeams == nil {
+		c.MSTeams = &MSTeams{}
+	}
+	c.MSTeams.Width = "Full"
+	return c
+}
+
+// Message 包装为 Teams 消息
+func (c *AdaptiveCard) Message() *Message {
+	return &Message{
+		Type: "message",
+		Attachments: []*Attachment{
+			{ContentType: adaptiveCardContentType, Content: c},
+		},
+	}
+}
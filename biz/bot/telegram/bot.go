@@ -0,0 +1,145 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+const (
+	defaultSendMessageUrlTemplate = "https://api.telegram.org/bot%s/sendMessage"
+
+	platform = "telegram"
+
+	SecurityNone    = "none"
+	SecurityKeyword = "keyword"
+)
+
+var ErrInvalidKey = errors.New("telegram: bot key must be <bot_token>/<chat_id>")
+
+// Bot Telegram Bot API 机器人
+type Bot struct {
+	Token        string
+	ChatID       string
+	RequestUrl   string
+	Client       *http.Client
+	SecurityType string
+	Keywords     string
+	Options      *transport.Options
+}
+
+// Resp Telegram API响应
+type Resp struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+}
+
+// New 创建 Telegram 机器人实例，chatID 为默认的会话（用户 id、群 id 或 @channel）
+func New(token, chatID string, opts ...transport.Option) *Bot {
+	options := transport.NewOptions(platform, opts...)
+	return &Bot{
+		Token:        token,
+		ChatID:       chatID,
+		RequestUrl:   options.ResolveURL(fmt.Sprintf(defaultSendMessageUrlTemplate, token)),
+		Client:       options.HTTPClient(),
+		SecurityType: SecurityNone,
+		Options:      options,
+	}
+}
+
+// ParseKey 解析 "<bot_token>/<chat_id>" 格式的机器人 key
+func ParseKey(botKey string) (token, chatID string, err error) {
+	idx := strings.LastIndex(botKey, "/")
+	if idx <= 0 || idx == len(botKey)-1 {
+		return "", "", ErrInvalidKey
+	}
+	return botKey[:idx], botKey[idx+1:], nil
+}
+
+// AddKeyword 设置关键字模式，发送时在 text 前注入关键字
+func (b *Bot) AddKeyword(keyword string) *Bot {
+	if keyword != "" {
+		b.SecurityType = SecurityKeyword
+		b.Keywords = keyword
+	}
+	return b
+}
+
+// Send 发送消息，支持 string 与 *Message，未指定 chat_id 时使用默认会话
+func (b *Bot) Send(msg interface{}) (bool, error) {
+	var m Message
+	switch v := msg.(type) {
+	case string:
+		m = *NewText(v)
+	case *Message:
+		m = *v
+	case Message:
+		m = v
+	default:
+		msgBytes, err := json.Marshal(msg)
+		if err != nil {
+			return false, err
+		}
+		return b.SendRaw(msgBytes)
+	}
+	if m.ChatID == "" {
+		m.ChatID = b.ChatID
+	}
+	if b.SecurityType == SecurityKeyword && b.Keywords != "" {
+		m.Text = b.Keywords + "\n" + m.Text
+	}
+	msgBytes, err := json.Marshal(m)
+	if err != nil {
+		return false, err
+	}
+	return b.SendRaw(msgBytes)
+}
+
+// SendRaw 发送原始JSON消息（sendMessage 请求体）
+func (b *Bot) SendRaw(msgBytes []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, b.RequestUrl, bytes.NewReader(msgBytes))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	result, err := b.Options.Do(b.Client, req, decodeResp)
+	if err != nil {
+		return false, err
+	}
+	if result.ErrCode != 0 {
+		return false, fmt.Errorf("send message failed, code: %d, msg: %s", result.ErrCode, result.ErrMsg)
+	}
+	return true, nil
+}
+
+// decodeResp 解析 Telegram 响应中的错误码
+func decodeResp(body []byte) (int, string, error) {
+	r := Resp{}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return 0, "", err
+	}
+	if !r.OK && r.ErrorCode == 0 {
+		return -1, r.Description, nil
+	}
+	return r.ErrorCode, r.Description, nil
+}
+
+// CheckMessage 检查消息是否为合法的 sendMessage 请求体
+func (b *Bot) CheckMessage(msg string) bool {
+	if len(msg) == 0 {
+		return false
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(msg), &raw); err != nil {
+		return false
+	}
+	text, ok := raw["text"].(string)
+	return ok && text != ""
+}
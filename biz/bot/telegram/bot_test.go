@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+func TestSend(t *testing.T) {
+	var path string
+	var msg Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		b, _ := io.ReadAll(r.Body)
+		msg = Message{}
+		json.Unmarshal(b, &msg)
+		if msg.ChatID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	defer srv.Close()
+
+	bot := New("123:abc", "-100200", transport.WithBaseURL(srv.URL)).AddKeyword("#ops")
+	ok, err := bot.Send(NewMarkdown("*deploy* done"))
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, "/bot123:abc/sendMessage", path)
+	assert.Equal(t, "-100200", msg.ChatID)
+	assert.Equal(t, "#ops\n*deploy* done", msg.Text)
+	assert.Equal(t, ParseModeMarkdown, msg.ParseMode)
+
+	ok, err = bot.SendRaw([]byte(`{"text":"hi"}`))
+	assert.False(t, ok)
+	assert.ErrorContains(t, err, "chat not found")
+}
+
+func TestParseKey(t *testing.T) {
+	token, chatID, err := ParseKey("123:abc/@channel")
+	assert.NoError(t, err)
+	assert.Equal(t, "123:abc", token)
+	assert.Equal(t, "@channel", chatID)
+
+	_, _, err = ParseKey("123:abc")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, _, err = ParseKey("123:abc/")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
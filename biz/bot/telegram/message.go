@@ -0,0 +1,50 @@
+package telegram
+
+// 文本解析模式
+const (
+	ParseModeMarkdown = "MarkdownV2"
+	ParseModeHTML     = "HTML"
+)
+
+// Message sendMessage 请求体
+type Message struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode,omitempty"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview,omitempty"`
+	DisableNotification   bool   `json:"disable_notification,omitempty"`
+	MessageThreadID       int64  `json:"message_thread_id,omitempty"`
+}
+
+// NewText 创建纯文本消息
+func NewText(text string) *Message {
+	return &Message{Text: text}
+}
+
+// NewMarkdown 创建 MarkdownV2 消息，特殊字符需自行转义
+func NewMarkdown(text string) *Message {
+	return &Message{Text: text, ParseMode: ParseModeMarkdown}
+}
+
+// NewHTML 创建 HTML 消息
+func NewHTML(text string) *Message {
+	return &Message{Text: text, ParseMode: ParseModeHTML}
+}
+
+// SetChatID 指定会话，覆盖机器人默认会话
+func (m *Message) SetChatID(chatID string) *Message {
+	m.ChatID = chatID
+	return m
+}
+
+// Silent 静默发送
+func (m *Message) Silent() *Message {
+	m.DisableNotification = true
+	return m
+}
+
+// DisablePreview 关闭链接预览
+func (m *Message) DisablePreview() *Message {
+	m.DisableWebPagePreview = true
+	return m
+}
@@ -0,0 +1,317 @@
+package bot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/yi-nology/common/biz/bot/dingtalk"
+	"github.com/yi-nology/common/biz/bot/lanxin"
+	"github.com/yi-nology/common/biz/bot/lark"
+	"github.com/yi-nology/common/biz/bot/slack"
+	"github.com/yi-nology/common/biz/bot/teams"
+	"github.com/yi-nology/common/biz/bot/telegram"
+	"github.com/yi-nology/common/biz/bot/webhook"
+	"github.com/yi-nology/common/biz/bot/wxwork"
+	"github.com/yi-nology/common/utils/xnow"
+	"github.com/yi-nology/common/utils/xstrings"
+)
+
+const (
+	// TemplateExt 模板文件后缀
+	TemplateExt = ".tmpl"
+
+	// 模板覆盖使用的 Markdown 方言
+	DialectMarkdown   = "md"     // 通用 Markdown：企业微信、钉钉、蓝信、飞书卡片、Teams
+	DialectSlack      = "mrkdwn" // Slack mrkdwn
+	DialectTelegramV2 = "mdv2"   // Telegram MarkdownV2
+
+	defaultTimeLayout = "2006-01-02 15:04:05"
+)
+
+var ErrTemplateNotFound = errors.New("bot: template not found")
+
+// TemplateRegistry 消息模板注册表
+// 文件 alert.tmpl 注册为 alert；alert.lark.tmpl、alert.md.tmpl 分别为飞书与 Markdown 方言的覆盖版本
+// 渲染时按 名称.平台 > 名称.方言 > 名称 的顺序查找，被引用的子模板（partial）同样按此规则替换
+type TemplateRegistry struct {
+	mu    sync.RWMutex
+	root  *template.Template
+	funcs template.FuncMap
+	cache map[BotType]*template.Template // 各平台解析后的模板集合，注册模板或函数时清空
+}
+
+// NewTemplateRegistry 创建模板注册表
+func NewTemplateRegistry() *TemplateRegistry {
+	funcs := template.FuncMap{
+		"now":        time.Now,
+		"parseTime":  xnow.Parse,
+		"formatTime": formatTime,
+		"truncate":   truncate,
+		// escape 在渲染时按平台替换
+		"escape": func(s string) string { return s },
+	}
+	return &TemplateRegistry{
+		root:  template.New("").Funcs(funcs),
+		funcs: funcs,
+	}
+}
+
+// Funcs 添加自定义模板函数，需在加载模板前调用
+func (r *TemplateRegistry) Funcs(funcs template.FuncMap) *TemplateRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, v := range funcs {
+		r.funcs[k] = v
+	}
+	r.root.Funcs(funcs)
+	r.cache = nil
+	return r
+}
+
+// Add 注册模板，name 可带平台或方言后缀，如 alert.lark
+func (r *TemplateRegistry) Add(name, text string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = nil
+	_, err := r.root.New(name).Parse(text)
+	return err
+}
+
+// LoadFS 加载 fsys 中所有 .tmpl 文件（含子目录），模板名为去掉后缀的文件名
+func (r *TemplateRegistry) LoadFS(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != TemplateExt {
+			return nil
+		}
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		if err := r.Add(strings.TrimSuffix(path.Base(p), TemplateExt), string(b)); err != nil {
+			return fmt.Errorf("parse template %s: %w", p, err)
+		}
+		return nil
+	})
+}
+
+// LoadDir 加载目录中的模板文件
+func (r *TemplateRegistry) LoadDir(dir string) error {
+	return r.LoadFS(os.DirFS(dir))
+}
+
+// Render 按平台渲染模板为文本
+func (r *TemplateRegistry) Render(botType BotType, name string, data interface{}) (string, error) {
+	t, err := r.resolve(botType, name)
+	if err != nil {
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	if err := t.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Message 渲染模板并构建可直接传给 BotOne.Send 的消息，title 用于需要标题的消息类型
+func (r *TemplateRegistry) Message(botType BotType, name string, title string, data interface{}) (interface{}, error) {
+	content, err := r.Render(botType, name, data)
+	if err != nil {
+		return nil, err
+	}
+	switch botType {
+	case WXWork:
+		return *wxwork.NewMarkdown().SetMarkdown(content), nil
+	case Dingtalk:
+		return dingtalk.NewMarkDown().SetContent(title, content), nil
+	case Lanxin:
+		return lanxin.NewMarkDown().SetContent(title, content), nil
+	case Lark:
+		if title == "" {
+			return lark.Text{Text: content}, nil
+		}
+		return lark.NewMarkdownCard(title, content), nil
+	case Slack:
+		return slack.NewText(content), nil
+	case Teams:
+		card := teams.NewAdaptiveCard()
+		if title != "" {
+			card.AddTitle(title)
+		}
+		return card.AddText(content), nil
+	case Telegram:
+		return telegram.NewMarkdown(content), nil
+	case Webhook:
+		return webhook.Data{Title: title, Text: content}, nil
+	default:
+		return content, nil
+	}
+}
+
+// resolve 查找平台解析后的模板集合中 name 的最终版本
+func (r *TemplateRegistry) resolve(botType BotType, name string) (*template.Template, error) {
+	t, err := r.platform(botType)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range append(suffixesOf(name, platformSuffixes(botType)), name) {
+		if found := t.Lookup(n); found != nil && found.Tree != nil {
+			return found, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+}
+
+// platform 返回平台的模板集合，首次使用时构建并缓存
+func (r *TemplateRegistry) platform(botType BotType) (*template.Template, error) {
+	r.mu.RLock()
+	t := r.cache[botType]
+	r.mu.RUnlock()
+	if t != nil {
+		return t, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t := r.cache[botType]; t != nil {
+		return t, nil
+	}
+	t, err := r.build(botType)
+	if err != nil {
+		return nil, err
+	}
+	if r.cache == nil {
+		r.cache = make(map[BotType]*template.Template)
+	}
+	r.cache[botType] = t
+	return t, nil
+}
+
+// build 克隆模板集合，将所有存在覆盖版本的模板替换为当前平台版本，并绑定平台转义函数
+func (r *TemplateRegistry) build(botType BotType) (*template.Template, error) {
+	t, err := r.root.Clone()
+	if err != nil {
+		return nil, err
+	}
+	suffixes := platformSuffixes(botType)
+	for _, base := range r.root.Templates() {
+		if base.Tree == nil || strings.Contains(base.Name(), ".") {
+			continue
+		}
+		for _, suffix := range suffixes {
+			if override := r.root.Lookup(base.Name() + suffix); override != nil && override.Tree != nil {
+				if _, err := t.AddParseTree(base.Name(), override.Tree); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+	t.Funcs(template.FuncMap{"escape": Escaper(botType)})
+	return t, nil
+}
+
+// platformSuffixes 平台模板覆盖后缀，按优先级排列
+func platformSuffixes(botType BotType) []string {
+	suffixes := []string{"." + string(botType)}
+	if dialect := MarkdownDialect(botType); dialect != "" {
+		suffixes = append(suffixes, "."+dialect)
+	}
+	return suffixes
+}
+
+func suffixesOf(name string, suffixes []string) []string {
+	names := make([]string, 0, len(suffixes))
+	for _, suffix := range suffixes {
+		names = append(names, name+suffix)
+	}
+	return names
+}
+
+// MarkdownDialect 返回平台使用的 Markdown 方言，通用 Webhook 返回空
+func MarkdownDialect(botType BotType) string {
+	switch botType {
+	case WXWork, Dingtalk, Lanxin, Lark, Teams:
+		return DialectMarkdown
+	case Slack:
+		return DialectSlack
+	case Telegram:
+		return DialectTelegramV2
+	default:
+		return ""
+	}
+}
+
+var (
+	// markdownEscaper Teams 等支持反斜杠转义的通用 Markdown
+	markdownEscaper   = strings.NewReplacer(`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "#", `\#`, "~", `\~`, ">", `\>`, "|", `\|`)
+	slackEscaper      = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	telegramV2Escaper = newTelegramV2Escaper()
+)
+
+func newTelegramV2Escaper() *strings.Replacer {
+	var pairs []string
+	for _, c := range "\\_*[]()~`>#+-=|{}.!" {
+		pairs = append(pairs, string(c), `\`+string(c))
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// Escaper 返回平台 Markdown 方言的转义函数，用于在模板中安全嵌入用户输入
+// 企业微信、钉钉、蓝信原样显示反斜杠，飞书使用字符实体，均按 markdown 包中的平台方言转义
+func Escaper(botType BotType) func(string) string {
+	if d, ok := markdownDialects[botType]; ok {
+		return d.Escape
+	}
+	switch MarkdownDialect(botType) {
+	case DialectMarkdown:
+		return markdownEscaper.Replace
+	case DialectSlack:
+		return slackEscaper.Replace
+	case DialectTelegramV2:
+		return telegramV2Escaper.Replace
+	default:
+		return func(s string) string { return s }
+	}
+}
+
+// formatTime 格式化时间，支持 time.Time、Unix 秒以及 xnow 可解析的时间字符串
+func formatTime(v interface{}, layout ...string) (string, error) {
+	l := defaultTimeLayout
+	if len(layout) > 0 {
+		l = layout[0]
+	}
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(l), nil
+	case *time.Time:
+		return t.Format(l), nil
+	case int64:
+		return time.Unix(t, 0).Format(l), nil
+	case int:
+		return time.Unix(int64(t), 0).Format(l), nil
+	case string:
+		parsed, err := xnow.Parse(t)
+		if err != nil {
+			return "", err
+		}
+		return parsed.Format(l), nil
+	default:
+		return "", fmt.Errorf("formatTime: unsupported type %T", v)
+	}
+}
+
+// truncate 截取前 n 个字符，超出部分以 ... 结尾，参数顺序便于管道使用：{{.Detail | truncate 100}}
+func truncate(n int, s string) string {
+	return xstrings.FirstN(s, n, "...")
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"embed"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yi-nology/common/biz/bot/dingtalk"
+	"github.com/yi-nology/common/biz/bot/slack"
+	"github.com/yi-nology/common/biz/bot/telegram"
+	"github.com/yi-nology/common/biz/bot/webhook"
+)
+
+//go:embed testdata/templates
+var testTemplates embed.FS
+
+func newTestRegistry(t *testing.T) *TemplateRegistry {
+	sub, err := fs.Sub(testTemplates, "testdata/templates")
+	assert.NoError(t, err)
+	r := NewTemplateRegistry()
+	assert.NoError(t, r.LoadFS(sub))
+	return r
+}
+
+func TestTemplateRender(t *testing.T) {
+	r := newTestRegistry(t)
+	data := map[string]interface{}{
+		"Level":   "P1",
+		"Service": "order_api",
+		"Detail":  "connection refused by upstream",
+		"At":      time.Date(2024, 5, 1, 8, 30, 0, 0, time.Local),
+	}
+
+	// 通用 Markdown：使用 header.md 覆盖，钉钉不识别反斜杠，下划线替换为全角字符
+	out, err := r.Render(Dingtalk, "alert", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "**[P1]**\n服务 order＿api 异常：connection...\n时间：05-01 08:30", out)
+
+	out, err = r.Render(Teams, "alert", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "**[P1]**\n服务 order\\_api 异常：connection...\n时间：05-01 08:30", out)
+
+	// 无覆盖版本的平台使用默认模板
+	out, err = r.Render(Webhook, "alert", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "[P1]\n服务 order_api 异常：connection...\n时间：05-01 08:30", out)
+
+	// 平台专属模板
+	out, err = r.Render(Slack, "alert", map[string]string{"Level": "P2", "Service": "a<b>"})
+	assert.NoError(t, err)
+	assert.Equal(t, "<!here> *P2* a&lt;b&gt;", out)
+
+	_, err = r.Render(Lark, "missing", data)
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
+
+func TestTemplateMessage(t *testing.T) {
+	r := NewTemplateRegistry()
+	assert.NoError(t, r.Add("deploy", "{{escape .}} 已发布"))
+
+	msg, err := r.Message(Dingtalk, "deploy", "发布", "v1.0")
+	assert.NoError(t, err)
+	dd, ok := msg.(*dingtalk.MarkDownMessage)
+	assert.True(t, ok)
+	assert.Equal(t, "发布", dd.MarkDown.Title)
+
+	msg, err = r.Message(Telegram, "deploy", "", "v1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, telegram.NewMarkdown(`v1\.0 已发布`), msg)
+
+	msg, err = r.Message(Slack, "deploy", "", "v1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, slack.NewText("v1.0 已发布"), msg)
+
+	msg, err = r.Message(Webhook, "deploy", "t", "v1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, webhook.Data{Title: "t", Text: "v1.0 已发布"}, msg)
+}
+
+func TestEscaper(t *testing.T) {
+	in := `a_b *c* <font>`
+	assert.Equal(t, `a＿b ＊c＊ ＜font＞`, Escaper(WXWork)(in))
+	assert.Equal(t, `a＿b ＊c＊ ＜font＞`, Escaper(Dingtalk)(in))
+	assert.Equal(t, `a&#95;b &#42;c&#42; &#60;font&#62;`, Escaper(Lark)(in))
+	assert.Equal(t, `a\_b \*c\* <font\>`, Escaper(Teams)(in))
+	assert.Equal(t, in, Escaper(Webhook)(in))
+}
+
+func TestTemplateCache(t *testing.T) {
+	r := NewTemplateRegistry()
+	assert.NoError(t, r.Add("a", "v1"))
+	out, err := r.Render(Slack, "a", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", out)
+
+	first, _ := r.platform(Slack)
+	second, _ := r.platform(Slack)
+	assert.Same(t, first, second)
+
+	// 注册新模板后重新构建
+	assert.NoError(t, r.Add("a.mrkdwn", "v2"))
+	out, err = r.Render(Slack, "a", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", out)
+}
+
+func TestFormatTime(t *testing.T) {
+	s, err := formatTime("2024-05-01 08:30:00", "2006/01/02")
+	assert.NoError(t, err)
+	assert.Equal(t, "2024/05/01", s)
+
+	_, err = formatTime(1.5)
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 提前刷新访问令牌，避免临界点过期
+const tokenRefreshAhead = 5 * time.Minute
+
+// TokenFetcher 向开放平台换取访问令牌，返回令牌及其有效期
+type TokenFetcher func(ctx context.Context) (token string, expiresIn time.Duration, err error)
+
+// TokenCache 开放平台访问令牌缓存（access_token、tenant_access_token 等），零值可用
+type TokenCache struct {
+	mu       sync.Mutex
+	token    string
+	expireAt time.Time
+}
+
+// Get 返回缓存的令牌，未获取或即将过期时调用 fetch 刷新；并发调用只会刷新一次
+func (c *TokenCache) Get(ctx context.Context, fetch TokenFetcher) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.expireAt) {
+		return c.token, nil
+	}
+
+	token, expiresIn, err := fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	ahead := tokenRefreshAhead
+	if ahead > expiresIn/2 {
+		ahead = expiresIn / 2
+	}
+	c.token = token
+	c.expireAt = time.Now().Add(expiresIn - ahead)
+	return c.token, nil
+}
+
+// Invalidate 清除缓存的令牌，如平台返回令牌失效时，下次 Get 重新获取
+func (c *TokenCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+}
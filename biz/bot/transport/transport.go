@@ -0,0 +1,201 @@
+package transport
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 5 * time.Second
+
+// RequestHook 请求发送前调用，可用于注入追踪头、鉴权等；返回错误将中止发送
+type RequestHook func(req *http.Request) error
+
+// ResponseHook 请求完成后调用，可用于上报指标与日志
+type ResponseHook func(info *ResponseInfo)
+
+// ResponseInfo 一次机器人请求的结果
+type ResponseInfo struct {
+	Platform   string
+	Request    *http.Request
+	StatusCode int
+	Latency    time.Duration
+	ErrCode    int    // 平台返回的错误码
+	ErrMsg     string // 平台返回的错误信息
+	Err        error  // 网络或解析错误
+}
+
+// Decoder 从响应体中解析平台错误码
+type Decoder func(body []byte) (code int, msg string, err error)
+
+// Result 请求结果
+type Result struct {
+	StatusCode int
+	Body       []byte
+	ErrCode    int
+	ErrMsg     string
+	Latency    time.Duration
+}
+
+// Options 机器人公共配置
+type Options struct {
+	Platform  string
+	Client    *http.Client
+	Transport http.RoundTripper
+	Timeout   time.Duration
+	BaseURL   string
+	Before    []RequestHook
+	After     []ResponseHook
+}
+
+// Option 配置项
+type Option func(*Options)
+
+// WithClient 使用自定义 http.Client
+func WithClient(client *http.Client) Option {
+	return func(o *Options) {
+		o.Client = client
+	}
+}
+
+// WithTransport 使用自定义 RoundTripper，如出口代理或追踪
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *Options) {
+		o.Transport = rt
+	}
+}
+
+// WithTimeout 设置请求超时，仅在未指定 Client 时生效
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+// WithBaseURL 替换平台默认地址的协议与主机（可带路径前缀），用于代理或 mock 服务
+func WithBaseURL(baseURL string) Option {
+	return func(o *Options) {
+		o.BaseURL = baseURL
+	}
+}
+
+// WithBeforeHook 添加请求前钩子
+func WithBeforeHook(hook RequestHook) Option {
+	return func(o *Options) {
+		o.Before = append(o.Before, hook)
+	}
+}
+
+// WithAfterHook 添加请求后钩子
+func WithAfterHook(hook ResponseHook) Option {
+	return func(o *Options) {
+		o.After = append(o.After, hook)
+	}
+}
+
+// NewOptions 创建配置
+func NewOptions(platform string, opts ...Option) *Options {
+	o := &Options{Platform: platform, Timeout: defaultTimeout}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// HTTPClient 根据配置返回 http.Client
+func (o *Options) HTTPClient() *http.Client {
+	if o == nil {
+		return &http.Client{Timeout: defaultTimeout}
+	}
+	if o.Client != nil {
+		if o.Transport == nil {
+			return o.Client
+		}
+		client := *o.Client
+		client.Transport = o.Transport
+		return &client
+	}
+	timeout := o.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &http.Client{Timeout: timeout, Transport: o.Transport}
+}
+
+// ResolveURL 用 BaseURL 替换默认地址的协议与主机，保留原路径与查询参数
+func (o *Options) ResolveURL(defaultURL string) string {
+	if o == nil || o.BaseURL == "" {
+		return defaultURL
+	}
+	base, err := url.Parse(o.BaseURL)
+	if err != nil || base.Host == "" {
+		return defaultURL
+	}
+	u, err := url.Parse(defaultURL)
+	if err != nil {
+		return defaultURL
+	}
+	u.Scheme = base.Scheme
+	u.Host = base.Host
+	u.User = base.User
+	u.Path = strings.TrimSuffix(base.Path, "/") + u.Path
+	u.RawPath = ""
+	return u.String()
+}
+
+// Do 发送请求，依次调用请求前钩子、发送、读取响应、解析错误码并调用请求后钩子
+func (o *Options) Do(client *http.Client, req *http.Request, decode Decoder) (*Result, error) {
+	info := &ResponseInfo{Request: req}
+	if o != nil {
+		info.Platform = o.Platform
+		for _, hook := range o.Before {
+			if err := hook(req); err != nil {
+				info.Err = err
+				o.after(info)
+				return nil, err
+			}
+		}
+	}
+
+	start := time.Now()
+	result, err := do(client, req, decode)
+	info.Latency = time.Since(start)
+	info.Err = err
+	if result != nil {
+		result.Latency = info.Latency
+		info.StatusCode = result.StatusCode
+		info.ErrCode = result.ErrCode
+		info.ErrMsg = result.ErrMsg
+	}
+	o.after(info)
+	return result, err
+}
+
+func (o *Options) after(info *ResponseInfo) {
+	if o == nil {
+		return
+	}
+	for _, hook := range o.After {
+		hook(info)
+	}
+}
+
+func do(client *http.Client, req *http.Request, decode Decoder) (*Result, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	result := &Result{StatusCode: resp.StatusCode, Body: body}
+	if err != nil {
+		return result, err
+	}
+	if decode != nil {
+		result.ErrCode, result.ErrMsg, err = decode(body)
+	}
+	return result, err
+}
@@ -0,0 +1,111 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestResolveURL(t *testing.T) {
+	o := NewOptions("wx", WithBaseURL("http://127.0.0.1:8080/proxy/"))
+	assert.Equal(t, "http://127.0.0.1:8080/proxy/cgi-bin/webhook/send?key=abc",
+		o.ResolveURL("https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=abc"))
+
+	assert.Equal(t, "https://open.feishu.cn/open-apis/bot/v2/hook/k",
+		NewOptions("lark").ResolveURL("https://open.feishu.cn/open-apis/bot/v2/hook/k"))
+
+	var nilOptions *Options
+	assert.Equal(t, "https://a.com/x", nilOptions.ResolveURL("https://a.com/x"))
+}
+
+func TestHTTPClient(t *testing.T) {
+	assert.Equal(t, defaultTimeout, NewOptions("wx").HTTPClient().Timeout)
+	assert.Equal(t, time.Second, NewOptions("wx", WithTimeout(time.Second)).HTTPClient().Timeout)
+
+	client := &http.Client{Timeout: time.Minute}
+	assert.Same(t, client, NewOptions("wx", WithClient(client)).HTTPClient())
+
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) { return nil, errors.New("x") })
+	c := NewOptions("wx", WithClient(client), WithTransport(rt)).HTTPClient()
+	assert.Equal(t, time.Minute, c.Timeout)
+	assert.NotNil(t, c.Transport)
+	assert.Nil(t, client.Transport)
+}
+
+func TestDoHooks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "trace-1", r.Header.Get("X-Trace-Id"))
+		w.Write([]byte(`{"errcode":310000,"errmsg":"keywords not in content"}`))
+	}))
+	defer srv.Close()
+
+	var info *ResponseInfo
+	o := NewOptions("dd",
+		WithBeforeHook(func(req *http.Request) error {
+			req.Header.Set("X-Trace-Id", "trace-1")
+			return nil
+		}),
+		WithAfterHook(func(i *ResponseInfo) { info = i }),
+	)
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, nil)
+	result, err := o.Do(o.HTTPClient(), req, func(body []byte) (int, string, error) {
+		return 310000, "keywords not in content", nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 310000, result.ErrCode)
+	assert.Equal(t, "dd", info.Platform)
+	assert.Equal(t, http.StatusOK, info.StatusCode)
+	assert.Equal(t, 310000, info.ErrCode)
+	assert.Greater(t, info.Latency, time.Duration(0))
+
+	abort := errors.New("abort")
+	o = NewOptions("dd", WithBeforeHook(func(req *http.Request) error { return abort }), WithAfterHook(func(i *ResponseInfo) { info = i }))
+	_, err = o.Do(o.HTTPClient(), req, nil)
+	assert.ErrorIs(t, err, abort)
+	assert.ErrorIs(t, info.Err, abort)
+}
+
+func TestTokenCache(t *testing.T) {
+	var cache TokenCache
+	fetches := 0
+	fetch := func(ctx context.Context) (string, time.Duration, error) {
+		fetches++
+		return fmt.Sprintf("token-%d", fetches), time.Hour, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		token, err := cache.Get(context.Background(), fetch)
+		assert.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+	}
+	assert.Equal(t, 1, fetches)
+
+	cache.Invalidate()
+	token, _ := cache.Get(context.Background(), fetch)
+	assert.Equal(t, "token-2", token)
+
+	// 有效期短于提前刷新时间时按一半有效期缓存
+	var short TokenCache
+	token, _ = short.Get(context.Background(), func(ctx context.Context) (string, time.Duration, error) {
+		return "short", time.Minute, nil
+	})
+	assert.Equal(t, "short", token)
+	assert.True(t, short.expireAt.After(time.Now().Add(29*time.Second)))
+
+	_, err := short.Get(context.Background(), func(ctx context.Context) (string, time.Duration, error) {
+		return "", 0, errors.New("unreachable")
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+const (
+	// DefaultTemplate 默认将消息数据直接序列化为JSON
+	DefaultTemplate = "{{json .}}"
+
+	// HeaderTimestamp 签名模式下携带的时间戳请求头（毫秒）
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature 签名模式下携带的签名请求头
+	HeaderSignature = "X-Webhook-Signature"
+
+	platform = "webhook"
+
+	SecurityNone    = "none"
+	SecuritySign    = "sign"
+	SecurityKeyword = "keyword"
+)
+
+// Data 模板渲染数据，字符串消息会转换为 Data{Text: msg}
+type Data struct {
+	Text  string                 `json:"text"`
+	Title string                 `json:"title,omitempty"`
+	Extra map[string]interface{} `json:"extra,omitempty"`
+}
+
+// Bot 通用 JSON Webhook 机器人，通过模板将消息渲染为任意平台的请求体
+type Bot struct {
+	WebHookUrl   string
+	Template     *template.Template
+	Client       *http.Client
+	SecurityType string
+	Secret       string
+	Keywords     string
+	Headers      map[string]string
+	Options      *transport.Options
+}
+
+// templateFuncs 模板可用函数
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// quote 输出JSON字符串字面量（含引号），用于在模板中安全嵌入文本
+	"quote": func(s string) (string, error) {
+		b, err := json.Marshal(s)
+		return string(b), err
+	},
+}
+
+// New 创建通用 Webhook 机器人，tmpl 为请求体模板，为空时使用 DefaultTemplate
+func New(webhookUrl string, tmpl string, opts ...transport.Option) (*Bot, error) {
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+	t, err := template.New(platform).Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	options := transport.NewOptions(platform, opts...)
+	return &Bot{
+		WebHookUrl:   options.ResolveURL(webhookUrl),
+		Template:     t,
+		Client:       options.HTTPClient(),
+		SecurityType: SecurityNone,
+		Headers:      map[string]string{},
+		Options:      options,
+	}, nil
+}
+
+// AddSign 设置签名模式
+func (b *Bot) AddSign(secret string) *Bot {
+	if secret != "" {
+		b.SecurityType = SecuritySign
+		b.Secret = secret
+	}
+	return b
+}
+
+// AddKeyword 设置关键字模式，渲染前在 Text 前注入关键字
+func (b *Bot) AddKeyword(keyword string) *Bot {
+	if keyword != "" {
+		b.SecurityType = SecurityKeyword
+		b.Keywords = keyword
+	}
+	return b
+}
+
+// AddHeader 添加自定义请求头
+func (b *Bot) AddHeader(key, value string) *Bot {
+	b.Headers[key] = value
+	return b
+}
+
+// Send 渲染模板后发送，支持 string、Data、*Data 及任意模板数据
+func (b *Bot) Send(msg interface{}) (bool, error) {
+	var data interface{}
+	switch m := msg.(type) {
+	case string:
+		data = b.withKeyword(Data{Text: m})
+	case Data:
+		data = b.withKeyword(m)
+	case *Data:
+		data = b.withKeyword(*m)
+	default:
+		data = msg
+	}
+
+	buf := &bytes.Buffer{}
+	if err := b.Template.Execute(buf, data); err != nil {
+		return false, err
+	}
+	return b.SendRaw(buf.Bytes())
+}
+
+// SendRaw 发送原始JSON消息
+func (b *Bot) SendRaw(msgBytes []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, b.WebHookUrl, bytes.NewReader(msgBytes))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range b.Headers {
+		req.Header.Set(k, v)
+	}
+	if b.SecurityType == SecuritySign && b.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Signature(timestamp, msgBytes, b.Secret))
+	}
+
+	result, err := b.Options.Do(b.Client, req, nil)
+	if err != nil {
+		return false, err
+	}
+	if result.StatusCode < 200 || result.StatusCode >= 300 {
+		return false, fmt.Errorf("send message failed, status: %d, body: %s", result.StatusCode, result.Body)
+	}
+	return true, nil
+}
+
+// Signature 计算签名：HMAC-SHA256(secret, timestamp + "\n" + body) 的十六进制编码
+func Signature(timestamp string, body []byte, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CheckMessage 检查消息是否为合法JSON
+func (b *Bot) CheckMessage(msg string) bool {
+	return len(msg) > 0 && json.Valid([]byte(msg))
+}
+
+func (b *Bot) withKeyword(d Data) Data {
+	if b.SecurityType == SecurityKeyword && b.Keywords != "" {
+		d.Text = b.Keywords + "\n" + d.Text
+	}
+	return d
+}
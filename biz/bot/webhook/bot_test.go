@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendTemplate(t *testing.T) {
+	var body []byte
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	bot, err := New(srv.URL, `{"content":{{quote .Text}}}`)
+	assert.NoError(t, err)
+	bot.AddSign("secret").AddHeader("X-Source", "common")
+
+	ok, err := bot.Send(`line "1"`)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"content":"line \"1\""}`, string(body))
+	assert.Equal(t, "common", header.Get("X-Source"))
+	ts := header.Get(HeaderTimestamp)
+	assert.NotEmpty(t, ts)
+	assert.Equal(t, Signature(ts, body, "secret"), header.Get(HeaderSignature))
+}
+
+func TestSendDefaultTemplate(t *testing.T) {
+	var body []byte
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	bot, err := New(srv.URL, "")
+	assert.NoError(t, err)
+	bot.AddKeyword("[kw]")
+
+	ok, err := bot.Send(Data{Text: "hi", Title: "t"})
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"text":"[kw]\nhi","title":"t"}`, string(body))
+	assert.True(t, bot.CheckMessage(string(body)))
+
+	status = http.StatusInternalServerError
+	ok, err = bot.Send("hi")
+	assert.False(t, ok)
+	assert.Error(t, err)
+}
+
+func TestNewInvalidTemplate(t *testing.T) {
+	_, err := New("http://localhost", "{{.Text")
+	assert.Error(t, err)
+}
@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"time"
+
+	"github.com/yi-nology/common/biz/bot/transport"
 )
 
 func init() {
@@ -15,6 +15,8 @@ func init() {
 
 const (
 	defaultWebHookUrlTemplate = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=%s"
+
+	platform = "wx"
 )
 
 var (
@@ -26,6 +28,7 @@ type WxWorkBot struct {
 	Key        string
 	WebHookUrl string
 	Client     *http.Client
+	Options    *transport.Options
 }
 
 type message struct {
@@ -33,11 +36,13 @@ type message struct {
 }
 
 // New 创建企业微信机器人实例
-func New(botKey string) *WxWorkBot {
+func New(botKey string, opts ...transport.Option) *WxWorkBot {
+	options := transport.NewOptions(platform, opts...)
 	bot := WxWorkBot{
 		Key:        botKey,
-		WebHookUrl: fmt.Sprintf(defaultWebHookUrlTemplate, botKey),
-		Client:     &http.Client{Timeout: 5 * time.Second},
+		WebHookUrl: options.ResolveURL(fmt.Sprintf(defaultWebHookUrlTemplate, botKey)),
+		Client:     options.HTTPClient(),
+		Options:    options,
 	}
 	return &bot
 }
@@ -62,23 +67,25 @@ func (bot *WxWorkBot) SendRaw(msgBytes []byte) (bool, error) {
 		return false, err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := bot.Client.Do(req)
-	if err != nil {
-		return false, err
-	}
-	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-	var wxWorkResp wxWorkResponse
-	err = json.Unmarshal(body, &wxWorkResp)
+	result, err := bot.Options.Do(bot.Client, req, decodeResp)
 	if err != nil {
 		return false, err
 	}
-	if wxWorkResp.ErrorCode != 0 && wxWorkResp.ErrorMessage != "" {
-		return false, errors.New(string(body))
+	if result.ErrCode != 0 && result.ErrMsg != "" {
+		return false, errors.New(string(result.Body))
 	}
 	return true, nil
 }
 
+// decodeResp 解析企业微信响应中的错误码
+func decodeResp(body []byte) (int, string, error) {
+	var wxWorkResp wxWorkResponse
+	if err := json.Unmarshal(body, &wxWorkResp); err != nil {
+		return 0, "", err
+	}
+	return wxWorkResp.ErrorCode, wxWorkResp.ErrorMessage, nil
+}
+
 // CheckMessage 检查消息是否为合法的企业微信消息格式
 func (bot *WxWorkBot) CheckMessage(msg string) bool {
 	if len(msg) == 0 {
@@ -93,7 +100,7 @@ func (bot *WxWorkBot) CheckMessage(msg string) bool {
 		return false
 	}
 	switch msgType {
-	case "text", "markdown", "image", "news", "template_card":
+	case "text", "markdown", "image", "news", "template_card", "file", "voice":
 		return true
 	default:
 		return false
@@ -155,5 +162,13 @@ func marshalMessage(msg interface{}) ([]byte, error) {
 		templateCardMsg.MsgType = "template_card"
 		return marshal(templateCardMsg)
 	}
+	if file, ok := msg.(File); ok {
+		fileMsg := fileMessage{message: message{MsgType: "file"}, File: file}
+		return marshal(fileMsg)
+	}
+	if voice, ok := msg.(Voice); ok {
+		voiceMsg := voiceMessage{message: message{MsgType: "voice"}, Voice: voice}
+		return marshal(voiceMsg)
+	}
 	return nil, ErrUnsupportedMessage
 }
@@ -0,0 +1,187 @@
+package wxwork
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// EventTemplateCard 模板卡片交互事件
+	EventTemplateCard = "template_card_event"
+
+	maxCallbackBodySize = 1 << 20
+
+	// 回调时间戳与当前时间的最大偏差，超出视为重放
+	maxTimestampSkew = 5 * time.Minute
+)
+
+// SelectedItem 投票/多项选择卡片的选择结果
+type SelectedItem struct {
+	QuestionKey string   `xml:"QuestionKey"`
+	OptionIDs   []string `xml:"OptionIds>OptionId"`
+}
+
+// Event 企业微信回调消息（解密后的 XML）
+type Event struct {
+	XMLName       xml.Name       `xml:"xml"`
+	ToUserName    string         `xml:"ToUserName"`
+	FromUserName  string         `xml:"FromUserName"`
+	CreateTime    int64          `xml:"CreateTime"`
+	MsgType       string         `xml:"MsgType"`
+	Event         string         `xml:"Event"`
+	EventKey      string         `xml:"EventKey"`
+	TaskID        string         `xml:"TaskId"`
+	CardType      string         `xml:"CardType"`
+	ResponseCode  string         `xml:"ResponseCode"`
+	AgentID       int64          `xml:"AgentID"`
+	SelectedItems []SelectedItem `xml:"SelectedItems>SelectedItem"`
+	Content       string         `xml:"Content"`
+	MsgID         string         `xml:"MsgId"`
+}
+
+// EventHandler 回调事件处理函数
+type EventHandler func(ctx context.Context, event *Event) error
+
+// CallbackHandler 企业微信回调处理器，实现 http.Handler
+// GET 请求用于回调 URL 校验，POST 请求为加密的消息与事件
+type CallbackHandler struct {
+	crypt *MsgCrypt
+
+	mu            sync.RWMutex
+	cardHandler   EventHandler
+	msgHandler    EventHandler
+	eventHandlers map[string]EventHandler
+}
+
+// NewCallbackHandler 创建回调处理器，token 与 encodingAESKey 为回调配置，receiverID 为企业ID（可为空）
+func NewCallbackHandler(token, encodingAESKey, receiverID string) (*CallbackHandler, error) {
+	crypt, err := NewMsgCrypt(token, encodingAESKey, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	return &CallbackHandler{
+		crypt:         crypt,
+		eventHandlers: make(map[string]EventHandler),
+	}, nil
+}
+
+// Crypt 返回加解密实例
+func (h *CallbackHandler) Crypt() *MsgCrypt {
+	return h.crypt
+}
+
+// OnTemplateCardEvent 注册模板卡片交互处理函数（按钮点击、投票、多项选择等）
+func (h *CallbackHandler) OnTemplateCardEvent(handler EventHandler) *CallbackHandler {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cardHandler = handler
+	return h
+}
+
+// OnMessage 注册普通消息处理函数
+func (h *CallbackHandler) OnMessage(handler EventHandler) *CallbackHandler {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.msgHandler = handler
+	return h
+}
+
+// OnEvent 注册指定事件的处理函数
+func (h *CallbackHandler) OnEvent(event string, handler EventHandler) *CallbackHandler {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.eventHandlers[event] = handler
+	return h
+}
+
+// ServeHTTP 处理企业微信回调请求
+func (h *CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	msgSignature := query.Get("msg_signature")
+	timestamp := query.Get("timestamp")
+	nonce := query.Get("nonce")
+	if err := checkTimestamp(timestamp); err != nil {
+		writeCallbackError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		echo, err := h.crypt.VerifyURL(msgSignature, timestamp, nonce, query.Get("echostr"))
+		if err != nil {
+			writeCallbackError(w, err)
+			return
+		}
+		w.Write(echo)
+	case http.MethodPost:
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBodySize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		plain, err := h.crypt.DecryptMsg(msgSignature, timestamp, nonce, body)
+		if err != nil {
+			writeCallbackError(w, err)
+			return
+		}
+		event := &Event{}
+		if err := xml.Unmarshal(plain, event); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.dispatch(r.Context(), event); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// dispatch 分发回调消息
+func (h *CallbackHandler) dispatch(ctx context.Context, event *Event) error {
+	h.mu.RLock()
+	var handler EventHandler
+	switch {
+	case event.MsgType != "event":
+		handler = h.msgHandler
+	case event.Event == EventTemplateCard && h.cardHandler != nil:
+		handler = h.cardHandler
+	default:
+		handler = h.eventHandlers[event.Event]
+	}
+	h.mu.RUnlock()
+
+	if handler == nil {
+		return nil
+	}
+	return handler(ctx, event)
+}
+
+// checkTimestamp 校验回调时间戳（秒）与当前时间的偏差，签名本身不含有效期，需据此拒绝重放的请求
+func checkTimestamp(timestamp string) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	skew := time.Since(time.Unix(ts, 0))
+	if skew > maxTimestampSkew || skew < -maxTimestampSkew {
+		return ErrTimestampExpired
+	}
+	return nil
+}
+
+func writeCallbackError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, ErrInvalidSignature) || errors.Is(err, ErrReceiverIDInvalid) || errors.Is(err, ErrTimestampExpired) {
+		status = http.StatusUnauthorized
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,176 @@
+package wxwork
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testToken      = "QDG6eK"
+	testAESKey     = "jWmYm7qr5nMoAUwZRjGtBxmz3KA1tkAj3ykkR6q2B2C"
+	testReceiverID = "wx5823bf96d3bd56c7"
+)
+
+func callbackURL(base string, c *MsgCrypt, timestamp, nonce, encrypt string, extra url.Values) string {
+	q := url.Values{}
+	q.Set("msg_signature", c.Signature(timestamp, nonce, encrypt))
+	q.Set("timestamp", timestamp)
+	q.Set("nonce", nonce)
+	for k, v := range extra {
+		q[k] = v
+	}
+	return base + "?" + q.Encode()
+}
+
+func TestMsgCryptRoundTrip(t *testing.T) {
+	c, err := NewMsgCrypt(testToken, testAESKey, testReceiverID)
+	assert.Nil(t, err)
+
+	enc, err := c.Encrypt([]byte("<xml><Content>hello</Content></xml>"))
+	assert.Nil(t, err)
+	plain, err := c.Decrypt(enc)
+	assert.Nil(t, err)
+	assert.Equal(t, "<xml><Content>hello</Content></xml>", string(plain))
+
+	other, _ := NewMsgCrypt(testToken, testAESKey, "another-corp")
+	_, err = other.Decrypt(enc)
+	assert.ErrorIs(t, err, ErrReceiverIDInvalid)
+
+	reply, err := c.EncryptMsg([]byte("<xml/>"), "1409659813", "1372623149")
+	assert.Nil(t, err)
+	plain, err = c.DecryptMsg(c.Signature("1409659813", "1372623149", extractEncrypt(t, reply)), "1409659813", "1372623149", reply)
+	assert.Nil(t, err)
+	assert.Equal(t, "<xml/>", string(plain))
+
+	_, err = NewMsgCrypt(testToken, "short", testReceiverID)
+	assert.ErrorIs(t, err, ErrInvalidAESKey)
+
+	// 只有最后一个字节正确的填充
+	data := make([]byte, 64)
+	binary.BigEndian.PutUint32(data[16:20], 4)
+	data[len(data)-1] = 3
+	block, _ := aes.NewCipher(c.key)
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, c.key[:aes.BlockSize]).CryptBlocks(out, data)
+	_, err = c.Decrypt(base64.StdEncoding.EncodeToString(out))
+	assert.ErrorIs(t, err, ErrDecryptFailed)
+}
+
+func extractEncrypt(t *testing.T, body []byte) string {
+	var v struct {
+		Encrypt string `xml:"Encrypt"`
+	}
+	assert.Nil(t, xml.Unmarshal(body, &v))
+	return v.Encrypt
+}
+
+func TestCallbackVerifyURL(t *testing.T) {
+	handler, err := NewCallbackHandler(testToken, testAESKey, testReceiverID)
+	assert.Nil(t, err)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	echo, _ := handler.Crypt().Encrypt([]byte("echo-123"))
+	resp, err := http.Get(callbackURL(srv.URL, handler.Crypt(), now, "263014780", echo, url.Values{"echostr": {echo}}))
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "echo-123", string(body))
+
+	q := url.Values{"msg_signature": {"bad"}, "timestamp": {now}, "nonce": {"2"}, "echostr": {echo}}
+	resp, err = http.Get(srv.URL + "?" + q.Encode())
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// 签名正确但时间戳过期，视为重放
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	resp, err = http.Get(callbackURL(srv.URL, handler.Crypt(), stale, "263014780", echo, url.Values{"echostr": {echo}}))
+	assert.Nil(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, string(body), ErrTimestampExpired.Error())
+}
+
+func TestCallbackTemplateCardEvent(t *testing.T) {
+	var updated TemplateCardUpdate
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cgi-bin/gettoken":
+			w.Write([]byte(`{"errcode":0,"errmsg":"ok","access_token":"token-1","expires_in":7200}`))
+		case "/cgi-bin/message/update_template_card":
+			assert.Equal(t, "token-1", r.URL.Query().Get("access_token"))
+			b, _ := io.ReadAll(r.Body)
+			json.Unmarshal(b, &updated)
+			w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+		}
+	}))
+	defer api.Close()
+
+	app := NewAppClient("corp", "secret", 1000002)
+	app.BaseUrl = api.URL
+
+	var got *Event
+	handler, err := NewCallbackHandler(testToken, testAESKey, testReceiverID)
+	assert.Nil(t, err)
+	handler.OnTemplateCardEvent(func(ctx context.Context, event *Event) error {
+		got = event
+		return app.UpdateTemplateCardButton(ctx, event.ResponseCode, []string{event.FromUserName}, "Approved by "+event.FromUserName)
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	plain := `<xml><ToUserName><![CDATA[wx5823bf96d3bd56c7]]></ToUserName><FromUserName><![CDATA[zhangsan]]></FromUserName><CreateTime>1409659813</CreateTime><MsgType><![CDATA[event]]></MsgType><Event><![CDATA[template_card_event]]></Event><EventKey><![CDATA[approve]]></EventKey><TaskId><![CDATA[task-1]]></TaskId><CardType><![CDATA[button_interaction]]></CardType><ResponseCode><![CDATA[code-1]]></ResponseCode><AgentID>1000002</AgentID><SelectedItems><SelectedItem><QuestionKey><![CDATA[q1]]></QuestionKey><OptionIds><OptionId><![CDATA[o1]]></OptionId><OptionId><![CDATA[o2]]></OptionId></OptionIds></SelectedItem></SelectedItems></xml>`
+	enc, _ := handler.Crypt().Encrypt([]byte(plain))
+	body := "<xml><ToUserName><![CDATA[wx5823bf96d3bd56c7]]></ToUserName><Encrypt><![CDATA[" + enc + "]]></Encrypt><AgentID><![CDATA[1000002]]></AgentID></xml>"
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	resp, err := http.Post(callbackURL(srv.URL, handler.Crypt(), now, "1372623149", enc, nil), "text/xml", strings.NewReader(body))
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, "approve", got.EventKey)
+	assert.Equal(t, "task-1", got.TaskID)
+	assert.Equal(t, []string{"o1", "o2"}, got.SelectedItems[0].OptionIDs)
+
+	assert.Equal(t, int64(1000002), updated.AgentID)
+	assert.Equal(t, "code-1", updated.ResponseCode)
+	assert.Equal(t, []string{"zhangsan"}, updated.UserIDs)
+	assert.Equal(t, "Approved by zhangsan", updated.Button.ReplaceName)
+}
+
+func TestButtonTemplateCardMarshal(t *testing.T) {
+	card := NewTemplateCard().
+		SetCardType(TemplateCardTypeButton).
+		SetTaskID("task-1").
+		AddButton(&TemplateCardButton{Text: "Approve", Style: TemplateCardButtonStyleGreen, Key: "approve"})
+	b, err := marshalMessage(*card)
+	assert.Nil(t, err)
+
+	var raw struct {
+		TemplateCard map[string]interface{} `json:"template_card"`
+	}
+	assert.Nil(t, json.Unmarshal(b, &raw))
+	assert.Equal(t, "button_interaction", raw.TemplateCard["card_type"])
+	assert.Equal(t, "task-1", raw.TemplateCard["task_id"])
+	assert.Len(t, raw.TemplateCard["button_list"], 1)
+}
@@ -0,0 +1,181 @@
+package wxwork
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 企业微信回调加解密使用 32 字节块做 PKCS#7 填充
+const cryptoBlockSize = 32
+
+var (
+	ErrInvalidSignature  = errors.New("wxwork: invalid msg_signature")
+	ErrInvalidAESKey     = errors.New("wxwork: invalid EncodingAESKey")
+	ErrDecryptFailed     = errors.New("wxwork: decrypt callback message failed")
+	ErrReceiverIDInvalid = errors.New("wxwork: receiver id mismatch")
+	ErrTimestampExpired  = errors.New("wxwork: callback timestamp expired")
+)
+
+// MsgCrypt 企业微信回调消息加解密
+type MsgCrypt struct {
+	token      string
+	receiverID string
+	key        []byte
+}
+
+// encryptedXML 加密的回调消息体
+type encryptedXML struct {
+	XMLName    xml.Name `xml:"xml"`
+	ToUserName string   `xml:"ToUserName"`
+	AgentID    string   `xml:"AgentID"`
+	Encrypt    string   `xml:"Encrypt"`
+}
+
+// encryptedReplyXML 加密的被动回复消息体
+type encryptedReplyXML struct {
+	XMLName      xml.Name `xml:"xml"`
+	Encrypt      cdata    `xml:"Encrypt"`
+	MsgSignature cdata    `xml:"MsgSignature"`
+	TimeStamp    string   `xml:"TimeStamp"`
+	Nonce        cdata    `xml:"Nonce"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+// NewMsgCrypt 创建加解密实例，receiverID 为企业ID或机器人ID，为空时不校验
+func NewMsgCrypt(token, encodingAESKey, receiverID string) (*MsgCrypt, error) {
+	if len(encodingAESKey) != 43 {
+		return nil, ErrInvalidAESKey
+	}
+	key, err := base64.StdEncoding.DecodeString(encodingAESKey + "=")
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidAESKey
+	}
+	return &MsgCrypt{token: token, receiverID: receiverID, key: key}, nil
+}
+
+// Signature 计算 msg_signature：sha1(sort(token, timestamp, nonce, encrypt))
+func (c *MsgCrypt) Signature(timestamp, nonce, encrypt string) string {
+	parts := []string{c.token, timestamp, nonce, encrypt}
+	sort.Strings(parts)
+	h := sha1.Sum([]byte(strings.Join(parts, "")))
+	return hex.EncodeToString(h[:])
+}
+
+// VerifyURL 校验回调 URL，返回解密后的 echostr
+func (c *MsgCrypt) VerifyURL(msgSignature, timestamp, nonce, echoStr string) ([]byte, error) {
+	if !c.checkSignature(msgSignature, timestamp, nonce, echoStr) {
+		return nil, ErrInvalidSignature
+	}
+	return c.Decrypt(echoStr)
+}
+
+// DecryptMsg 校验签名并解密 POST 回调的 XML 消息体，返回明文 XML
+func (c *MsgCrypt) DecryptMsg(msgSignature, timestamp, nonce string, body []byte) ([]byte, error) {
+	var enc encryptedXML
+	if err := xml.Unmarshal(body, &enc); err != nil {
+		return nil, err
+	}
+	if !c.checkSignature(msgSignature, timestamp, nonce, enc.Encrypt) {
+		return nil, ErrInvalidSignature
+	}
+	return c.Decrypt(enc.Encrypt)
+}
+
+// EncryptMsg 加密被动回复消息，返回可直接写回的 XML
+func (c *MsgCrypt) EncryptMsg(plain []byte, timestamp, nonce string) ([]byte, error) {
+	encrypt, err := c.Encrypt(plain)
+	if err != nil {
+		return nil, err
+	}
+	return xml.Marshal(encryptedReplyXML{
+		Encrypt:      cdata{encrypt},
+		MsgSignature: cdata{c.Signature(timestamp, nonce, encrypt)},
+		TimeStamp:    timestamp,
+		Nonce:        cdata{nonce},
+	})
+}
+
+// Decrypt 解密：AES-256-CBC(random(16) + msg_len(4) + msg + receiveid)
+func (c *MsgCrypt) Decrypt(encrypted string) ([]byte, error) {
+	buf, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptFailed, err)
+	}
+	if len(buf) == 0 || len(buf)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("%w: invalid ciphertext length", ErrDecryptFailed)
+	}
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(buf))
+	cipher.NewCBCDecrypter(block, c.key[:aes.BlockSize]).CryptBlocks(plain, buf)
+
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > cryptoBlockSize || pad > len(plain) {
+		return nil, fmt.Errorf("%w: invalid padding", ErrDecryptFailed)
+	}
+	for _, b := range plain[len(plain)-pad:] {
+		if int(b) != pad {
+			return nil, fmt.Errorf("%w: invalid padding", ErrDecryptFailed)
+		}
+	}
+	plain = plain[:len(plain)-pad]
+	if len(plain) < 20 {
+		return nil, fmt.Errorf("%w: message too short", ErrDecryptFailed)
+	}
+
+	msgLen := int(binary.BigEndian.Uint32(plain[16:20]))
+	if 20+msgLen > len(plain) {
+		return nil, fmt.Errorf("%w: invalid message length", ErrDecryptFailed)
+	}
+	msg := plain[20 : 20+msgLen]
+	receiverID := string(plain[20+msgLen:])
+	if c.receiverID != "" && receiverID != c.receiverID {
+		return nil, ErrReceiverIDInvalid
+	}
+	return msg, nil
+}
+
+// Encrypt 加密，格式与 Decrypt 对应
+func (c *MsgCrypt) Encrypt(plain []byte) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(plain)))
+
+	data := bytes.Join([][]byte{random, length, plain, []byte(c.receiverID)}, nil)
+	pad := cryptoBlockSize - len(data)%cryptoBlockSize
+	data = append(data, bytes.Repeat([]byte{byte(pad)}, pad)...)
+
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return "", err
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, c.key[:aes.BlockSize]).CryptBlocks(out, data)
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+// checkSignature 常量时间比较签名
+func (c *MsgCrypt) checkSignature(msgSignature, timestamp, nonce, encrypt string) bool {
+	expected := c.Signature(timestamp, nonce, encrypt)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(msgSignature)) == 1
+}
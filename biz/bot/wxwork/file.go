@@ -0,0 +1,51 @@
+package wxwork
+
+import "github.com/yi-nology/common/biz/bot/media"
+
+type fileMessage struct {
+	message
+	File File `json:"file"`
+}
+
+type File struct {
+	MediaID string `json:"media_id"`
+}
+
+func NewFile() *File {
+	return &File{}
+}
+
+func (f *File) SetMediaID(s string) *File {
+	f.MediaID = s
+	return f
+}
+
+// SetMedia 使用统一上传接口返回的文件
+func (f *File) SetMedia(h *media.Handle) *File {
+	f.MediaID = h.ID
+	return f
+}
+
+type voiceMessage struct {
+	message
+	Voice Voice `json:"voice"`
+}
+
+type Voice struct {
+	MediaID string `json:"media_id"`
+}
+
+func NewVoice() *Voice {
+	return &Voice{}
+}
+
+func (v *Voice) SetMediaID(s string) *Voice {
+	v.MediaID = s
+	return v
+}
+
+// SetMedia 使用统一上传接口返回的语音
+func (v *Voice) SetMedia(h *media.Handle) *Voice {
+	v.MediaID = h.ID
+	return v
+}
@@ -3,6 +3,8 @@ package wxwork
 import (
 	"crypto/md5"
 	"encoding/hex"
+
+	"github.com/yi-nology/common/biz/bot/media"
 )
 
 type imageMessage struct {
@@ -25,3 +27,10 @@ func (i *Image) SetBase64(s string) *Image {
 	i.MD5 = hex.EncodeToString(h[:])
 	return i
 }
+
+// SetMedia 使用统一上传接口返回的图片
+func (i *Image) SetMedia(h *media.Handle) *Image {
+	i.Base64 = h.Base64
+	i.MD5 = h.MD5
+	return i
+}
@@ -2,6 +2,10 @@ package wxwork
 
 import (
 	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,9 +13,21 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
+	"net/url"
 	"strings"
+
+	"github.com/yi-nology/common/biz/bot/media"
 )
 
+// MediaLimits 企业微信群机器人媒体限制：图片以 base64 内联发送，文件与语音需先上传
+var MediaLimits = map[media.Type]media.Limit{
+	media.Image: {MaxSize: 2 << 20, Formats: []string{"jpg", "png"}},
+	media.File:  {MaxSize: 20 << 20, MinSize: 5},
+	media.Voice: {MaxSize: 2 << 20, MinSize: 5, Formats: []string{"amr"}},
+}
+
+const defaultUploadUrlTemplate = "https://qyapi.weixin.qq.com/cgi-bin/webhook/upload_media?key=%s&type=%s"
+
 type uploadedMediaResponse struct {
 	wxWorkResponse
 	UploadedMedia
@@ -23,11 +39,20 @@ type UploadedMedia struct {
 	CreatedAt string `json:"created_at"`
 }
 
-func uploadApiUrl(key *string) string {
-	return fmt.Sprintf(
-		"https://qyapi.weixin.qq.com/cgi-bin/webhook/upload_media?key=%s&type=file",
-		*key,
-	)
+// uploadApiUrl 上传地址与 webhook 地址同源，便于替换为代理或测试服务
+func uploadApiUrl(webHookUrl string, key string, mediaType media.Type) string {
+	u, err := url.Parse(webHookUrl)
+	if err != nil || u.Host == "" {
+		return fmt.Sprintf(defaultUploadUrlTemplate, key, mediaType)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/send") + "/upload_media"
+	q := u.Query()
+	if q.Get("key") == "" {
+		q.Set("key", key)
+	}
+	q.Set("type", string(mediaType))
+	u.RawQuery = q.Encode()
+	return u.String()
 }
 
 var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
@@ -36,42 +61,72 @@ func escapeQuotes(s string) string {
 	return quoteEscaper.Replace(s)
 }
 
+// UploadMedia 上传文件素材
 func (bot *WxWorkBot) UploadMedia(fileName string, fileBytes *[]byte) (*UploadedMedia, error) {
+	return bot.uploadMedia(context.Background(), media.File, fileName, *fileBytes)
+}
+
+// Upload 统一媒体上传：图片校验压缩后转为 base64 内联，文件与语音上传后返回 media_id
+func (bot *WxWorkBot) Upload(ctx context.Context, mediaType media.Type, name string, r io.Reader) (*media.Handle, error) {
+	limit, ok := MediaLimits[mediaType]
+	if !ok {
+		return nil, media.ErrUnsupported
+	}
+	data, format, err := media.Prepare(mediaType, name, r, limit)
+	if err != nil {
+		return nil, err
+	}
+	handle := &media.Handle{Type: mediaType, Name: name, Format: format, Size: int64(len(data))}
+
+	if mediaType == media.Image {
+		h := md5.Sum(data)
+		handle.Base64 = base64.StdEncoding.EncodeToString(data)
+		handle.MD5 = hex.EncodeToString(h[:])
+		return handle, nil
+	}
+
+	uploaded, err := bot.uploadMedia(ctx, mediaType, name, data)
+	if err != nil {
+		return nil, err
+	}
+	handle.ID = uploaded.MediaID
+	return handle, nil
+}
+
+// uploadMedia 调用 upload_media 接口
+func (bot *WxWorkBot) uploadMedia(ctx context.Context, mediaType media.Type, fileName string, fileBytes []byte) (*UploadedMedia, error) {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
 	h := make(textproto.MIMEHeader)
 	h.Set("Content-Disposition",
 		fmt.Sprintf(`form-data; name="media"; filename="%s"; filelength=%d`,
-			escapeQuotes(fileName), len(*fileBytes)))
+			escapeQuotes(fileName), len(fileBytes)))
 	h.Set("Content-Type", "application/octet-stream")
 
 	part, err := writer.CreatePart(h)
 	if err != nil {
 		return nil, err
 	}
-	io.Copy(part, bytes.NewReader(*fileBytes))
+	io.Copy(part, bytes.NewReader(fileBytes))
 	writer.Close()
 
-	req, err := http.NewRequest(http.MethodPost, uploadApiUrl(&bot.Key), body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadApiUrl(bot.WebHookUrl, bot.Key, mediaType), body)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Content-Type", writer.FormDataContentType())
 
-	resp, err := bot.Client.Do(req)
+	result, err := bot.Options.Do(bot.Client, req, decodeResp)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
+	if result.ErrCode != 0 && result.ErrMsg != "" {
+		return nil, errors.New(string(result.Body))
+	}
 	var wxWorkResp uploadedMediaResponse
-	err = json.Unmarshal(respBody, &wxWorkResp)
-	if err != nil {
+	if err := json.Unmarshal(result.Body, &wxWorkResp); err != nil {
 		return nil, err
 	}
-	if wxWorkResp.ErrorCode != 0 && wxWorkResp.ErrorMessage != "" {
-		return nil, errors.New(string(respBody))
-	}
 	return &wxWorkResp.UploadedMedia, nil
 }
@@ -0,0 +1,58 @@
+package wxwork
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yi-nology/common/biz/bot/media"
+)
+
+func TestUploadFileAndSend(t *testing.T) {
+	var sent map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cgi-bin/webhook/upload_media":
+			assert.Equal(t, "k1", r.URL.Query().Get("key"))
+			assert.Equal(t, "file", r.URL.Query().Get("type"))
+			file, header, err := r.FormFile("media")
+			assert.Nil(t, err)
+			b, _ := io.ReadAll(file)
+			assert.Equal(t, "report.csv", header.Filename)
+			assert.Equal(t, "a,b,c\n1,2,3\n", string(b))
+			w.Write([]byte(`{"errcode":0,"errmsg":"ok","type":"file","media_id":"m-1","created_at":"1380000000"}`))
+		case "/cgi-bin/webhook/send":
+			b, _ := io.ReadAll(r.Body)
+			json.Unmarshal(b, &sent)
+			w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+		}
+	}))
+	defer srv.Close()
+
+	bot := New("k1")
+	bot.WebHookUrl = srv.URL + "/cgi-bin/webhook/send?key=k1"
+
+	h, err := bot.Upload(context.Background(), media.File, "report.csv", strings.NewReader("a,b,c\n1,2,3\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, "m-1", h.ID)
+
+	ok, err := bot.Send(*NewFile().SetMedia(h))
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, "file", sent["msgtype"])
+	assert.Equal(t, "m-1", sent["file"].(map[string]interface{})["media_id"])
+}
+
+func TestUploadImageInline(t *testing.T) {
+	bot := New("k1")
+	_, err := bot.Upload(context.Background(), media.Image, "a.gif", strings.NewReader("GIF89a......"))
+	assert.ErrorIs(t, err, media.ErrInvalidFormat)
+
+	_, err = bot.Upload(context.Background(), "video", "a.mp4", strings.NewReader("...."))
+	assert.ErrorIs(t, err, media.ErrUnsupported)
+}
@@ -5,6 +5,12 @@ const (
 	TemplateCardTypeText = "text_notice"
 	// TemplateCardTypeNews 图文展示类型的模板卡片
 	TemplateCardTypeNews = "news_notice"
+	// TemplateCardTypeButton 按钮交互型的模板卡片
+	TemplateCardTypeButton = "button_interaction"
+	// TemplateCardTypeVote 投票选择型的模板卡片
+	TemplateCardTypeVote = "vote_interaction"
+	// TemplateCardTypeMultiple 多项选择型的模板卡片
+	TemplateCardTypeMultiple = "multiple_interaction"
 )
 
 type templateCardMessage struct {
@@ -24,6 +30,11 @@ type TemplateCard struct {
 	HorizontalContentList []*TemplateCardHorizontalContent `json:"horizontal_content_list"`
 	JumpList              []*TemplateCardJump              `json:"jump_list"`
 	CardAction            TemplateCardAction               `json:"card_action"`
+	TaskID                string                           `json:"task_id,omitempty"`
+	ButtonList            []*TemplateCardButton            `json:"button_list,omitempty"`
+	Checkbox              *TemplateCardCheckbox            `json:"checkbox,omitempty"`
+	SelectList            []*TemplateCardSelect            `json:"select_list,omitempty"`
+	SubmitButton          *TemplateCardSubmitButton        `json:"submit_button,omitempty"`
 }
 
 func NewTemplateCard() *TemplateCard {
@@ -36,6 +47,12 @@ func (t *TemplateCard) SetCardType(s string) *TemplateCard {
 		t.CardType = TemplateCardTypeText
 	case TemplateCardTypeNews:
 		t.CardType = TemplateCardTypeNews
+	case TemplateCardTypeButton:
+		t.CardType = TemplateCardTypeButton
+	case TemplateCardTypeVote:
+		t.CardType = TemplateCardTypeVote
+	case TemplateCardTypeMultiple:
+		t.CardType = TemplateCardTypeMultiple
 	}
 	return t
 }
@@ -90,6 +107,32 @@ func (t *TemplateCard) SetCardAction(s TemplateCardAction) *TemplateCard {
 	return t
 }
 
+// SetTaskID 设置任务id，交互类卡片必填，回调事件中会原样带回
+func (t *TemplateCard) SetTaskID(s string) *TemplateCard {
+	t.TaskID = s
+	return t
+}
+
+func (t *TemplateCard) AddButton(s *TemplateCardButton) *TemplateCard {
+	t.ButtonList = append(t.ButtonList, s)
+	return t
+}
+
+func (t *TemplateCard) SetCheckbox(s *TemplateCardCheckbox) *TemplateCard {
+	t.Checkbox = s
+	return t
+}
+
+func (t *TemplateCard) AddSelect(s *TemplateCardSelect) *TemplateCard {
+	t.SelectList = append(t.SelectList, s)
+	return t
+}
+
+func (t *TemplateCard) SetSubmitButton(s *TemplateCardSubmitButton) *TemplateCard {
+	t.SubmitButton = s
+	return t
+}
+
 type TemplateCardSourceDescColor int
 
 // 来源文字的颜色，目前支持：0(默认) 灰色，1 黑色，2 红色，3 绿色
@@ -207,3 +250,53 @@ type TemplateCardImageTextArea struct {
 	Desc     *string                       `json:"desc"`
 	ImageUrl string                        `json:"image_url"`
 }
+
+type TemplateCardButtonStyle int
+
+// 按钮样式，目前可填1~4，不填或错填默认1
+const (
+	TemplateCardButtonStyleBlue  TemplateCardButtonStyle = 1
+	TemplateCardButtonStyleRed   TemplateCardButtonStyle = 2
+	TemplateCardButtonStyleGrey  TemplateCardButtonStyle = 3
+	TemplateCardButtonStyleGreen TemplateCardButtonStyle = 4
+)
+
+type TemplateCardButton struct {
+	Text  string                  `json:"text"`
+	Style TemplateCardButtonStyle `json:"style,omitempty"`
+	Key   string                  `json:"key"`
+}
+
+type TemplateCardOption struct {
+	ID        string `json:"id"`
+	Text      string `json:"text"`
+	IsChecked bool   `json:"is_checked,omitempty"`
+}
+
+type TemplateCardCheckboxMode int
+
+// 选择题模式，单选：0，多选：1，不填默认0
+const (
+	TemplateCardCheckboxModeSingle   TemplateCardCheckboxMode = 0
+	TemplateCardCheckboxModeMultiple TemplateCardCheckboxMode = 1
+)
+
+type TemplateCardCheckbox struct {
+	QuestionKey string                   `json:"question_key"`
+	OptionList  []*TemplateCardOption    `json:"option_list"`
+	Disable     bool                     `json:"disable,omitempty"`
+	Mode        TemplateCardCheckboxMode `json:"mode"`
+}
+
+type TemplateCardSelect struct {
+	QuestionKey string                `json:"question_key"`
+	Title       string                `json:"title,omitempty"`
+	Disable     bool                  `json:"disable,omitempty"`
+	SelectedID  string                `json:"selected_id,omitempty"`
+	OptionList  []*TemplateCardOption `json:"option_list"`
+}
+
+type TemplateCardSubmitButton struct {
+	Text string `json:"text"`
+	Key  string `json:"key"`
+}
@@ -0,0 +1,152 @@
+package wxwork
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/yi-nology/common/biz/bot/transport"
+)
+
+const (
+	defaultAppBaseUrl = "https://qyapi.weixin.qq.com"
+)
+
+// AppClient 企业微信自建应用客户端，用于通过 response_code 更新已发送的模板卡片
+type AppClient struct {
+	CorpID     string
+	CorpSecret string
+	AgentID    int64
+	BaseUrl    string
+	Client     *http.Client
+	Options    *transport.Options
+
+	tokens transport.TokenCache
+}
+
+type accessTokenResponse struct {
+	wxWorkResponse
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// TemplateCardButtonUpdate 将卡片按钮更新为不可点击状态并替换文案
+type TemplateCardButtonUpdate struct {
+	ReplaceName string `json:"replace_name"`
+}
+
+// TemplateCardUpdate update_template_card 接口请求
+type TemplateCardUpdate struct {
+	UserIDs      []string                  `json:"userids,omitempty"`
+	PartyIDs     []int64                   `json:"partyids,omitempty"`
+	TagIDs       []int64                   `json:"tagids,omitempty"`
+	AtAll        int                       `json:"atall,omitempty"`
+	AgentID      int64                     `json:"agentid"`
+	ResponseCode string                    `json:"response_code"`
+	Button       *TemplateCardButtonUpdate `json:"button,omitempty"`
+	TemplateCard *TemplateCard             `json:"template_card,omitempty"`
+}
+
+// NewAppClient 创建自建应用客户端，opts 可指定 HTTP 客户端、地址覆盖与请求钩子
+func NewAppClient(corpID, corpSecret string, agentID int64, opts ...transport.Option) *AppClient {
+	options := transport.NewOptions(platform, opts...)
+	return &AppClient{
+		CorpID:     corpID,
+		CorpSecret: corpSecret,
+		AgentID:    agentID,
+		BaseUrl:    options.ResolveURL(defaultAppBaseUrl),
+		Client:     options.HTTPClient(),
+		Options:    options,
+	}
+}
+
+// AccessToken 获取 access_token，带缓存
+func (a *AppClient) AccessToken(ctx context.Context) (string, error) {
+	return a.tokens.Get(ctx, a.fetchToken)
+}
+
+// fetchToken 换取 access_token
+func (a *AppClient) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	query := url.Values{}
+	query.Set("corpid", a.CorpID)
+	query.Set("corpsecret", a.CorpSecret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseUrl()+"/cgi-bin/gettoken?"+query.Encode(), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	var ret accessTokenResponse
+	if err := a.do(req, &ret); err != nil {
+		return "", 0, err
+	}
+	return ret.AccessToken, time.Duration(ret.ExpiresIn) * time.Second, nil
+}
+
+// UpdateTemplateCardButton 将按钮更新为不可点击并替换文案，如 "已审批"
+func (a *AppClient) UpdateTemplateCardButton(ctx context.Context, responseCode string, userIDs []string, replaceName string) error {
+	return a.UpdateTemplateCard(ctx, &TemplateCardUpdate{
+		UserIDs:      userIDs,
+		ResponseCode: responseCode,
+		Button:       &TemplateCardButtonUpdate{ReplaceName: replaceName},
+	})
+}
+
+// ReplaceTemplateCard 用新的模板卡片替换原卡片
+func (a *AppClient) ReplaceTemplateCard(ctx context.Context, responseCode string, userIDs []string, card *TemplateCard) error {
+	return a.UpdateTemplateCard(ctx, &TemplateCardUpdate{
+		UserIDs:      userIDs,
+		ResponseCode: responseCode,
+		TemplateCard: card,
+	})
+}
+
+// UpdateTemplateCard 调用 update_template_card 接口，response_code 来自卡片回调事件且只能使用一次
+func (a *AppClient) UpdateTemplateCard(ctx context.Context, update *TemplateCardUpdate) error {
+	if update.AgentID == 0 {
+		update.AgentID = a.AgentID
+	}
+	token, err := a.AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	body, err := marshal(update)
+	if err != nil {
+		return err
+	}
+	apiUrl := fmt.Sprintf("%s/cgi-bin/message/update_template_card?access_token=%s", a.baseUrl(), url.QueryEscape(token))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var ret wxWorkResponse
+	return a.do(req, &ret)
+}
+
+func (a *AppClient) baseUrl() string {
+	if a.BaseUrl == "" {
+		return defaultAppBaseUrl
+	}
+	return a.BaseUrl
+}
+
+// do 通过 transport 发送请求，检查 HTTP 状态码与 errcode
+func (a *AppClient) do(req *http.Request, ret interface{ code() (int, string) }) error {
+	result, err := a.Options.Do(a.Client, req, decodeResp)
+	if result != nil && (result.StatusCode < 200 || result.StatusCode >= 300) {
+		return fmt.Errorf("wxwork api error, status: %d, body: %s", result.StatusCode, result.Body)
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(result.Body, ret); err != nil {
+		return err
+	}
+	if code, msg := ret.code(); code != 0 {
+		return fmt.Errorf("wxwork api error, code: %d, msg: %s", code, msg)
+	}
+	return nil
+}
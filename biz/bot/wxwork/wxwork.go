@@ -4,3 +4,7 @@ type wxWorkResponse struct {
 	ErrorCode    int    `json:"errcode"`
 	ErrorMessage string `json:"errmsg"`
 }
+
+func (r *wxWorkResponse) code() (int, string) {
+	return r.ErrorCode, r.ErrorMessage
+}
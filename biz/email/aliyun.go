@@ -0,0 +1,171 @@
+package email
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yi-nology/common/utils/xuuid"
+)
+
+const (
+	aliyunDefaultRegion = "cn-hangzhou"
+	aliyunAPIVersion    = "2015-11-23"
+)
+
+// AliyunSender 通过阿里云邮件推送（DirectMail）SingleSendMail 接口发送邮件
+// 该接口不支持抄送、密送与附件，包含这些内容的邮件返回 ErrUnsupportedFeature，需要时改用阿里云 SMTP 服务（smtpdm.aliyun.com）
+type AliyunSender struct {
+	config  *Config
+	baseURL string
+	client  *http.Client
+}
+
+// NewAliyunSender 创建阿里云邮件推送发送器，APIKey、APISecret 分别为 AccessKey ID 与 Secret
+func NewAliyunSender(config *Config) (*AliyunSender, error) {
+	if config == nil || config.APIKey == "" || config.APISecret == "" {
+		return nil, fmt.Errorf("%w: aliyun access key is required", ErrInvalidConfig)
+	}
+	if len(config.Protectors) > 0 {
+		return nil, fmt.Errorf("%w: aliyun cannot sign or encrypt message content", ErrUnsupportedFeature)
+	}
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = "https://dm.aliyuncs.com"
+		if config.APIRegion != "" && config.APIRegion != aliyunDefaultRegion {
+			baseURL = fmt.Sprintf("https://dm.%s.aliyuncs.com", config.APIRegion)
+		}
+	}
+	return &AliyunSender{
+		config:  config,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: httpTimeout(config)},
+	}, nil
+}
+
+// SetHTTPClient 设置 HTTP 客户端
+func (s *AliyunSender) SetHTTPClient(client *http.Client) *AliyunSender {
+	s.client = client
+	return s
+}
+
+// Send 发送邮件
+func (s *AliyunSender) Send(ctx context.Context, message *Message) error {
+	if err := prepareMessage(s.config, message); err != nil {
+		return err
+	}
+	params, err := s.buildParams(message)
+	if err != nil {
+		return err
+	}
+	params.Set("Signature", aliyunSignature(http.MethodPost, params, s.config.APISecret))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/", strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err = doRequest(s.client, req, ProviderAliyun)
+	return err
+}
+
+func (s *AliyunSender) buildParams(message *Message) (url.Values, error) {
+	if len(message.Cc) > 0 || len(message.Bcc) > 0 {
+		return nil, fmt.Errorf("%w: aliyun does not support cc or bcc", ErrUnsupportedFeature)
+	}
+	if message.HasAttachment() {
+		return nil, fmt.Errorf("%w: aliyun does not support attachments", ErrUnsupportedFeature)
+	}
+
+	region := s.config.APIRegion
+	if region == "" {
+		region = aliyunDefaultRegion
+	}
+	from := parseAddress(message.From)
+	to := make([]string, 0, len(message.To))
+	for _, addr := range message.To {
+		to = append(to, parseAddress(addr).Address)
+	}
+
+	params := url.Values{}
+	params.Set("Format", "JSON")
+	params.Set("Version", aliyunAPIVersion)
+	params.Set("AccessKeyId", s.config.APIKey)
+	params.Set("SignatureMethod", "HMAC-SHA1")
+	params.Set("SignatureVersion", "1.0")
+	params.Set("SignatureNonce", xuuid.Uuid())
+	params.Set("Timestamp", time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	params.Set("RegionId", region)
+
+	params.Set("Action", "SingleSendMail")
+	params.Set("AccountName", from.Address)
+	params.Set("AddressType", "1")
+	params.Set("ReplyToAddress", "false")
+	params.Set("ToAddress", strings.Join(to, ","))
+	params.Set("Subject", message.Subject)
+	if from.Name != "" {
+		params.Set("FromAlias", from.Name)
+	}
+	if message.ReplyTo != "" {
+		reply := parseAddress(message.ReplyTo)
+		params.Set("ReplyAddress", reply.Address)
+		if reply.Name != "" {
+			params.Set("ReplyAddressAlias", reply.Name)
+		}
+	}
+	if message.HTML != "" {
+		params.Set("HtmlBody", message.HTML)
+	}
+	if message.Text != "" {
+		params.Set("TextBody", message.Text)
+	}
+	if headers := messageHeaders(message); len(headers) > 0 {
+		b, _ := json.Marshal(headers)
+		params.Set("Headers", string(b))
+	}
+	return params, nil
+}
+
+// SendBatch 批量发送邮件
+func (s *AliyunSender) SendBatch(ctx context.Context, messages []*Message) error {
+	return sendEach(ctx, s, messages)
+}
+
+// Close 无需关闭
+func (s *AliyunSender) Close() error {
+	return nil
+}
+
+// aliyunSignature 阿里云 RPC 接口签名（HMAC-SHA1）
+func aliyunSignature(method string, params url.Values, secret string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != "Signature" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, aliyunEscape(k)+"="+aliyunEscape(params.Get(k)))
+	}
+	stringToSign := method + "&" + aliyunEscape("/") + "&" + aliyunEscape(strings.Join(pairs, "&"))
+
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// aliyunEscape 按 RFC 3986 编码
+func aliyunEscape(s string) string {
+	s = url.QueryEscape(s)
+	return strings.NewReplacer("+", "%20", "*", "%2A", "%7E", "~").Replace(s)
+}
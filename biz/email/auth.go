@@ -0,0 +1,118 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// AuthMechanism SMTP 认证方式
+type AuthMechanism string
+
+const (
+	AuthPlain   AuthMechanism = "PLAIN"
+	AuthLogin   AuthMechanism = "LOGIN"
+	AuthCRAMMD5 AuthMechanism = "CRAM-MD5"
+	AuthXOAUTH2 AuthMechanism = "XOAUTH2" // Gmail、Office 365 的 OAuth2 认证，Password 或 TokenSource 提供访问令牌
+)
+
+// autoMechanisms 自动选择时的优先顺序
+var autoMechanisms = []AuthMechanism{AuthCRAMMD5, AuthPlain, AuthLogin}
+
+var errUnencryptedAuth = errors.New("unencrypted connection")
+
+// selectMechanism 按服务器在 EHLO 中通告的认证方式自动选择
+func selectMechanism(advertised string) (AuthMechanism, bool) {
+	supported := map[AuthMechanism]bool{}
+	for _, m := range strings.Fields(strings.ToUpper(advertised)) {
+		supported[AuthMechanism(m)] = true
+	}
+	for _, m := range autoMechanisms {
+		if supported[m] {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+// newAuth 创建认证器，每个连接单独创建
+func newAuth(mechanism AuthMechanism, username, secret, host string) (smtp.Auth, error) {
+	switch mechanism {
+	case AuthPlain:
+		return smtp.PlainAuth("", username, secret, host), nil
+	case AuthLogin:
+		return &loginAuth{username: username, password: secret, host: host}, nil
+	case AuthCRAMMD5:
+		return smtp.CRAMMD5Auth(username, secret), nil
+	case AuthXOAUTH2:
+		return &xoauth2Auth{username: username, token: secret, host: host}, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported auth mechanism %q", ErrInvalidConfig, mechanism)
+	}
+}
+
+// checkServer 明文凭据仅允许在 TLS 或本机连接上发送，与 smtp.PlainAuth 一致
+func checkServer(server *smtp.ServerInfo, host string) error {
+	if server.Name != host {
+		return errors.New("wrong host name")
+	}
+	if !server.TLS && !isLocalhost(server.Name) {
+		return errUnencryptedAuth
+	}
+	return nil
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
+
+// loginAuth LOGIN 认证，依次应答用户名与密码
+type loginAuth struct {
+	username, password, host string
+	step                     int
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if err := checkServer(server, a.host); err != nil {
+		return "", nil, err
+	}
+	a.step = 0
+	return string(AuthLogin), nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	a.step++
+	switch a.step {
+	case 1:
+		return []byte(a.username), nil
+	case 2:
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+	}
+}
+
+// xoauth2Auth XOAUTH2 认证
+type xoauth2Auth struct {
+	username, token, host string
+}
+
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if err := checkServer(server, a.host); err != nil {
+		return "", nil, err
+	}
+	resp := "user=" + a.username + "\x01auth=Bearer " + a.token + "\x01\x01"
+	return string(AuthXOAUTH2), []byte(resp), nil
+}
+
+// Next 认证失败时服务器返回 JSON 错误详情，需应答空行以获取最终错误码
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		return []byte{}, nil
+	}
+	return nil, nil
+}
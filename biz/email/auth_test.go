@@ -0,0 +1,123 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sendTestMail(sender *SMTPSender) error {
+	return sender.Send(context.Background(), &Message{
+		From: "sender@example.com", To: []string{"a@example.com"}, Subject: "hi", Text: "body",
+	})
+}
+
+func TestSMTPAuthMechanisms(t *testing.T) {
+	for _, mechanism := range []AuthMechanism{AuthPlain, AuthLogin, AuthCRAMMD5, AuthXOAUTH2} {
+		t.Run(string(mechanism), func(t *testing.T) {
+			server := newFakeSMTPServer(t, func(s *fakeSMTPServer) {
+				s.mechanisms = []string{"PLAIN", "LOGIN", "CRAM-MD5", "XOAUTH2"}
+			})
+			config := server.config()
+			config.AuthMechanism = mechanism
+			sender, err := NewSMTPSender(config)
+			assert.NoError(t, err)
+
+			assert.NoError(t, sendTestMail(sender))
+			used, _ := server.auths()
+			assert.Equal(t, []string{string(mechanism)}, used)
+
+			config.Password = "wrong"
+			sender, _ = NewSMTPSender(config)
+			assert.ErrorIs(t, sendTestMail(sender), ErrAuthFailed)
+		})
+	}
+}
+
+func TestSMTPAuthAutoSelect(t *testing.T) {
+	server := newFakeSMTPServer(t, func(s *fakeSMTPServer) {
+		s.mechanisms = []string{"LOGIN", "CRAM-MD5"}
+	})
+	sender, err := NewSMTPSender(server.config())
+	assert.NoError(t, err)
+	assert.NoError(t, sendTestMail(sender))
+	used, _ := server.auths()
+	assert.Equal(t, []string{"CRAM-MD5"}, used)
+}
+
+func TestSMTPAuthTokenSource(t *testing.T) {
+	server := newFakeSMTPServer(t, func(s *fakeSMTPServer) {
+		s.mechanisms = []string{"XOAUTH2"}
+		s.password = "access-token"
+	})
+	config := server.config()
+	config.Password = ""
+	config.AuthMechanism = AuthXOAUTH2
+	config.TokenSource = func(ctx context.Context) (string, error) { return "access-token", nil }
+	sender, err := NewSMTPSender(config)
+	assert.NoError(t, err)
+	assert.NoError(t, sendTestMail(sender))
+
+	config.TokenSource = func(ctx context.Context) (string, error) { return "", errors.New("token expired") }
+	sender, _ = NewSMTPSender(config)
+	assert.ErrorIs(t, sendTestMail(sender), ErrAuthFailed)
+}
+
+func TestSMTPTLSModes(t *testing.T) {
+	t.Run("starttls required", func(t *testing.T) {
+		server := newFakeSMTPServer(t, withTLS(t))
+		config := server.config()
+		config.TLSMode = TLSStartTLS
+		sender, _ := NewSMTPSender(config)
+		assert.NoError(t, sendTestMail(sender))
+		_, secure := server.auths()
+		assert.Equal(t, []bool{true}, secure)
+	})
+
+	t.Run("starttls required but not offered", func(t *testing.T) {
+		server := newFakeSMTPServer(t, withTLS(t), func(s *fakeSMTPServer) { s.noStartTLS = true })
+		config := server.config()
+		config.TLSMode = TLSStartTLS
+		sender, _ := NewSMTPSender(config)
+		assert.ErrorIs(t, sendTestMail(sender), ErrConnectFailed)
+		assert.Len(t, server.received(), 0)
+	})
+
+	t.Run("starttls opportunistic", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		config := server.config()
+		config.TLSMode = TLSStartTLSOpportunistic
+		sender, _ := NewSMTPSender(config)
+		assert.NoError(t, sendTestMail(sender))
+		_, secure := server.auths()
+		assert.Equal(t, []bool{false}, secure)
+	})
+
+	t.Run("implicit", func(t *testing.T) {
+		server := newFakeSMTPServer(t, withTLS(t), func(s *fakeSMTPServer) { s.implicitTLS = true })
+		config := server.config()
+		config.TLSMode = TLSImplicit
+		sender, _ := NewSMTPSender(config)
+		assert.NoError(t, sendTestMail(sender))
+		_, secure := server.auths()
+		assert.Equal(t, []bool{true}, secure)
+		assert.Equal(t, 0, server.commandCount("STARTTLS"))
+	})
+}
+
+func TestSMTPTLSModeDefault(t *testing.T) {
+	sender := &SMTPSender{config: &Config{Port: 465}}
+	assert.Equal(t, TLSImplicit, sender.tlsMode())
+	sender.config = &Config{Port: 587, UseTLS: true}
+	assert.Equal(t, TLSStartTLSOpportunistic, sender.tlsMode())
+	sender.config = &Config{Port: 25}
+	assert.Equal(t, TLSNone, sender.tlsMode())
+
+	config := DefaultConfig()
+	config.Host = "smtp.example.com"
+	config.TLSMode = "ssl"
+	_, err := NewSMTPSender(config)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
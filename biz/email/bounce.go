@@ -0,0 +1,85 @@
+package email
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strings"
+)
+
+// BounceType 退信类型
+type BounceType string
+
+const (
+	BounceHard BounceType = "hard" // 永久失败（5.x.x），应停止向该地址发信
+	BounceSoft BounceType = "soft" // 暂时失败（4.x.x）或延迟投递，可稍后重试
+)
+
+// Bounce 单个收件人的退信信息，来自 DSN（RFC 3464）的收件人字段组
+type Bounce struct {
+	Recipient         string     // 失败的收件人邮箱
+	Action            string     // failed、delayed
+	Status            string     // 增强状态码，如 5.1.1
+	Type              BounceType // 根据 Status 与 Action 判定
+	DiagnosticCode    string     // 远端服务器返回的诊断信息，如 550 5.1.1 User unknown
+	RemoteMTA         string     // 远端服务器
+	ReportingMTA      string     // 生成退信的服务器
+	OriginalMessageID string     // 原始邮件的 Message-ID，不含尖括号
+}
+
+// ParseDeliveryStatus 解析 message/delivery-status 内容，只返回 failed 与 delayed 的收件人
+func ParseDeliveryStatus(r io.Reader) ([]*Bounce, error) {
+	tr := textproto.NewReader(bufio.NewReader(r))
+
+	// 第一组为邮件级字段，其后每组对应一个收件人
+	perMessage, err := tr.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse delivery status: %w", err)
+	}
+	reportingMTA := dsnValue(perMessage.Get("Reporting-Mta"))
+
+	var bounces []*Bounce
+	for err != io.EOF {
+		var fields textproto.MIMEHeader
+		fields, err = tr.ReadMIMEHeader()
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to parse delivery status: %w", err)
+		}
+		action := strings.ToLower(strings.TrimSpace(fields.Get("Action")))
+		if action != "failed" && action != "delayed" {
+			continue
+		}
+		recipient := dsnValue(fields.Get("Final-Recipient"))
+		if recipient == "" {
+			recipient = dsnValue(fields.Get("Original-Recipient"))
+		}
+		status := strings.TrimSpace(fields.Get("Status"))
+		bounces = append(bounces, &Bounce{
+			Recipient:      recipient,
+			Action:         action,
+			Status:         status,
+			Type:           classifyBounce(action, status),
+			DiagnosticCode: dsnValue(fields.Get("Diagnostic-Code")),
+			RemoteMTA:      dsnValue(fields.Get("Remote-Mta")),
+			ReportingMTA:   reportingMTA,
+		})
+	}
+	return bounces, nil
+}
+
+// classifyBounce 5.x.x 的失败为硬退信，其余为软退信
+func classifyBounce(action, status string) BounceType {
+	if action == "failed" && strings.HasPrefix(status, "5") {
+		return BounceHard
+	}
+	return BounceSoft
+}
+
+// dsnValue 去掉 "rfc822; user@example.com" 中的类型前缀
+func dsnValue(value string) string {
+	if _, v, ok := strings.Cut(value, ";"); ok {
+		return strings.TrimSpace(v)
+	}
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,279 @@
+package email
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var cssCommentRe = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+// cssRule 样式规则
+type cssRule struct {
+	selector    []cssCompound // 后代选择器链，最后一个为目标元素
+	specificity int
+	order       int
+	decls       string
+}
+
+// cssCompound 复合选择器，如 td.total#sum
+type cssCompound struct {
+	tag     string
+	id      string
+	classes []string
+}
+
+// InlineCSS 将 <style> 中的规则内联到元素的 style 属性，提升邮件客户端兼容性
+// 支持标签、类、ID 及其组合与后代选择器；@media 与伪类等无法内联的规则保留在 <style> 中
+func InlineCSS(htmlContent string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return "", err
+	}
+
+	var rules []cssRule
+	var residual []string
+	var styles []*html.Node
+	walk(doc, func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Style {
+			styles = append(styles, n)
+		}
+	})
+	for _, style := range styles {
+		var css strings.Builder
+		for c := style.FirstChild; c != nil; c = c.NextSibling {
+			css.WriteString(c.Data)
+		}
+		r, rest := parseCSS(css.String(), len(rules))
+		rules = append(rules, r...)
+		residual = append(residual, rest...)
+		style.Parent.RemoveChild(style)
+	}
+	if len(rules) == 0 && len(residual) == 0 {
+		return htmlContent, nil
+	}
+
+	// 优先级低的先应用，元素原有的 style 最后追加，保证其优先级最高
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].specificity != rules[j].specificity {
+			return rules[i].specificity < rules[j].specificity
+		}
+		return rules[i].order < rules[j].order
+	})
+	walk(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		var decls []string
+		for _, rule := range rules {
+			if matchSelector(n, rule.selector) {
+				decls = append(decls, rule.decls)
+			}
+		}
+		if len(decls) == 0 {
+			return
+		}
+		if inline := getAttr(n, "style"); inline != "" {
+			decls = append(decls, strings.TrimSuffix(strings.TrimSpace(inline), ";"))
+		}
+		setAttr(n, "style", strings.Join(decls, "; "))
+	})
+
+	if len(residual) > 0 {
+		if head := findElement(doc, atom.Head); head != nil {
+			style := &html.Node{Type: html.ElementNode, Data: "style", DataAtom: atom.Style}
+			style.AppendChild(&html.Node{Type: html.TextNode, Data: strings.Join(residual, "\n")})
+			head.AppendChild(style)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := html.Render(buf, doc); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// parseCSS 解析样式表，返回可内联的规则与需保留的规则
+func parseCSS(css string, order int) ([]cssRule, []string) {
+	css = cssCommentRe.ReplaceAllString(css, "")
+	var rules []cssRule
+	var residual []string
+	for {
+		open := strings.Index(css, "{")
+		if open < 0 {
+			break
+		}
+		prelude := strings.TrimSpace(css[:open])
+		// 查找匹配的右括号，兼容 @media 嵌套
+		depth, end := 0, -1
+		for i := open; i < len(css); i++ {
+			if css[i] == '{' {
+				depth++
+			} else if css[i] == '}' {
+				depth--
+				if depth == 0 {
+					end = i
+					break
+				}
+			}
+		}
+		if end < 0 {
+			break
+		}
+		block := css[open+1 : end]
+		css = css[end+1:]
+
+		if strings.HasPrefix(prelude, "@") {
+			residual = append(residual, prelude+" {"+block+"}")
+			continue
+		}
+		decls := normalizeDecls(block)
+		if decls == "" {
+			continue
+		}
+		for _, sel := range strings.Split(prelude, ",") {
+			sel = strings.TrimSpace(sel)
+			compounds, specificity, ok := parseSelector(sel)
+			if !ok {
+				residual = append(residual, sel+" {"+decls+"}")
+				continue
+			}
+			rules = append(rules, cssRule{selector: compounds, specificity: specificity, order: order, decls: decls})
+			order++
+		}
+	}
+	return rules, residual
+}
+
+func normalizeDecls(block string) string {
+	var decls []string
+	for _, d := range strings.Split(block, ";") {
+		if d = strings.TrimSpace(d); d != "" {
+			decls = append(decls, d)
+		}
+	}
+	return strings.Join(decls, "; ")
+}
+
+// parseSelector 解析选择器，包含伪类、属性选择器、子代/相邻组合器时返回 false
+func parseSelector(sel string) ([]cssCompound, int, bool) {
+	if sel == "" || strings.ContainsAny(sel, ":[>+~*") {
+		return nil, 0, false
+	}
+	var compounds []cssCompound
+	specificity := 0
+	for _, field := range strings.Fields(sel) {
+		c := cssCompound{}
+		token := ""
+		kind := byte(0)
+		flush := func() {
+			switch kind {
+			case 0:
+				c.tag = strings.ToLower(token)
+				if token != "" {
+					specificity++
+				}
+			case '.':
+				c.classes = append(c.classes, token)
+				specificity += 10
+			case '#':
+				c.id = token
+				specificity += 100
+			}
+		}
+		for i := 0; i < len(field); i++ {
+			if field[i] == '.' || field[i] == '#' {
+				flush()
+				kind, token = field[i], ""
+				continue
+			}
+			token += string(field[i])
+		}
+		flush()
+		compounds = append(compounds, c)
+	}
+	return compounds, specificity, true
+}
+
+// matchSelector 判断元素是否匹配后代选择器链
+func matchSelector(n *html.Node, selector []cssCompound) bool {
+	last := len(selector) - 1
+	if !matchCompound(n, selector[last]) {
+		return false
+	}
+	i := last - 1
+	for p := n.Parent; p != nil && i >= 0; p = p.Parent {
+		if p.Type == html.ElementNode && matchCompound(p, selector[i]) {
+			i--
+		}
+	}
+	return i < 0
+}
+
+func matchCompound(n *html.Node, c cssCompound) bool {
+	if c.tag != "" && n.Data != c.tag {
+		return false
+	}
+	if c.id != "" && getAttr(n, "id") != c.id {
+		return false
+	}
+	if len(c.classes) > 0 {
+		classes := strings.Fields(getAttr(n, "class"))
+		for _, want := range c.classes {
+			found := false
+			for _, have := range classes {
+				if have == want {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func walk(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		walk(c, fn)
+		c = next
+	}
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(n, func(node *html.Node) {
+		if found == nil && node.Type == html.ElementNode && node.DataAtom == a {
+			found = node
+		}
+	})
+	return found
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i, attr := range n.Attr {
+		if attr.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
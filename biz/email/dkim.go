@@ -0,0 +1,248 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDKIMHeaders 默认签名的邮件头，邮件中不存在的头会被跳过
+var DefaultDKIMHeaders = []string{
+	"From", "To", "Cc", "Reply-To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type",
+}
+
+var ErrInvalidDKIMKey = errors.New("invalid DKIM private key")
+
+// DKIMSigner DKIM 签名器，使用 relaxed/relaxed 规范化（RFC 6376），支持 RSA-SHA256 与 Ed25519-SHA256（RFC 8463）
+type DKIMSigner struct {
+	Domain   string        // 签名域名（d=）
+	Selector string        // 选择器（s=），公钥发布在 <selector>._domainkey.<domain>
+	Headers  []string      // 签名的邮件头，为空时使用 DefaultDKIMHeaders
+	Expire   time.Duration // 签名有效期（x=），为 0 时不过期
+
+	key crypto.Signer
+}
+
+// NewDKIMSigner 创建 DKIM 签名器，key 为 *rsa.PrivateKey 或 ed25519.PrivateKey
+func NewDKIMSigner(domain, selector string, key crypto.Signer) (*DKIMSigner, error) {
+	switch key.(type) {
+	case *rsa.PrivateKey, ed25519.PrivateKey:
+	default:
+		return nil, fmt.Errorf("%w: unsupported key type %T", ErrInvalidDKIMKey, key)
+	}
+	if domain == "" || selector == "" {
+		return nil, fmt.Errorf("%w: domain and selector are required", ErrInvalidConfig)
+	}
+	return &DKIMSigner{Domain: domain, Selector: selector, key: key}, nil
+}
+
+// ParseDKIMPrivateKey 解析 PEM 格式私钥，支持 PKCS#1 与 PKCS#8
+func ParseDKIMPrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", ErrInvalidDKIMKey)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDKIMKey, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported key type %T", ErrInvalidDKIMKey, key)
+	}
+	return signer, nil
+}
+
+// SetHeaders 设置签名的邮件头
+func (d *DKIMSigner) SetHeaders(headers ...string) *DKIMSigner {
+	d.Headers = headers
+	return d
+}
+
+// Sign 对完整邮件签名，返回添加了 DKIM-Signature 头的邮件，行尾统一为 CRLF
+func (d *DKIMSigner) Sign(message []byte) ([]byte, error) {
+	message = normalizeCRLF(message)
+	header, body := message, []byte(nil)
+	if i := bytes.Index(message, []byte("\r\n\r\n")); i >= 0 {
+		header, body = message[:i+2], message[i+4:]
+	}
+	fields := splitHeaderFields(header)
+
+	bodyHash := sha256.Sum256(relaxedBody(body))
+
+	// 按签名头列表选取邮件头，同名头从下往上依次取
+	names := d.Headers
+	if len(names) == 0 {
+		names = DefaultDKIMHeaders
+	}
+	used := map[string]int{}
+	var signed []string
+	var hashed bytes.Buffer
+	for _, name := range names {
+		key := strings.ToLower(name)
+		field, ok := lastHeaderField(fields, key, used[key])
+		if !ok {
+			continue
+		}
+		used[key]++
+		signed = append(signed, key)
+		hashed.WriteString(relaxedHeader(field))
+	}
+	if used["from"] == 0 {
+		return nil, fmt.Errorf("%w: From header must be signed", ErrInvalidFrom)
+	}
+
+	algorithm := "rsa-sha256"
+	if _, ok := d.key.(ed25519.PrivateKey); ok {
+		algorithm = "ed25519-sha256"
+	}
+	now := time.Now()
+	tags := []string{
+		"v=1", "a=" + algorithm, "c=relaxed/relaxed", "d=" + d.Domain, "s=" + d.Selector,
+		"t=" + strconv.FormatInt(now.Unix(), 10),
+	}
+	if d.Expire > 0 {
+		tags = append(tags, "x="+strconv.FormatInt(now.Add(d.Expire).Unix(), 10))
+	}
+	sigHeader := "DKIM-Signature: " + strings.Join(tags, "; ") + ";\r\n" +
+		"\th=" + strings.Join(signed, ":") + ";\r\n" +
+		"\tbh=" + base64.StdEncoding.EncodeToString(bodyHash[:]) + ";\r\n" +
+		"\tb="
+	// 签名头本身以 b= 为空参与计算，且不带结尾 CRLF
+	hashed.WriteString(strings.TrimSuffix(relaxedHeader(sigHeader+"\r\n"), "\r\n"))
+
+	digest := sha256.Sum256(hashed.Bytes())
+	var sig []byte
+	var err error
+	switch key := d.key.(type) {
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(key, digest[:])
+	default:
+		sig, err = d.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := bytes.NewBufferString(sigHeader)
+	out.WriteString(foldBase64(base64.StdEncoding.EncodeToString(sig), 72))
+	out.WriteString("\r\n")
+	out.Write(message)
+	return out.Bytes(), nil
+}
+
+// normalizeCRLF 将单独的 LF 与 CR 转换为 CRLF，与 SMTP DATA 实际发送的内容保持一致
+func normalizeCRLF(b []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(b) + len(b)/32)
+	for i := 0; i < len(b); i++ {
+		switch {
+		case b[i] == '\r' && i+1 < len(b) && b[i+1] == '\n':
+			out.WriteString("\r\n")
+			i++
+		case b[i] == '\r' || b[i] == '\n':
+			out.WriteString("\r\n")
+		default:
+			out.WriteByte(b[i])
+		}
+	}
+	return out.Bytes()
+}
+
+// splitHeaderFields 拆分邮件头，折行的续行归入上一个头
+func splitHeaderFields(header []byte) []string {
+	var fields []string
+	for _, line := range strings.SplitAfter(string(header), "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1] += line
+			continue
+		}
+		fields = append(fields, line)
+	}
+	return fields
+}
+
+// lastHeaderField 返回名为 key 的倒数第 skip+1 个头
+func lastHeaderField(fields []string, key string, skip int) (string, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		name, _, ok := strings.Cut(fields[i], ":")
+		if !ok || strings.ToLower(strings.TrimSpace(name)) != key {
+			continue
+		}
+		if skip == 0 {
+			return fields[i], true
+		}
+		skip--
+	}
+	return "", false
+}
+
+// relaxedHeader relaxed 头规范化：名称小写，展开折行，连续空白压缩为一个空格，去除冒号两侧与末尾空白
+func relaxedHeader(field string) string {
+	name, value, _ := strings.Cut(field, ":")
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = strings.Join(strings.FieldsFunc(value, isWSP), " ")
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + value + "\r\n"
+}
+
+// relaxedBody relaxed 正文规范化：行内连续空白压缩为一个空格，去除行尾空白与结尾空行
+func relaxedBody(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		line = strings.TrimRightFunc(line, isWSP)
+		var b strings.Builder
+		space := false
+		for _, r := range line {
+			if isWSP(r) {
+				space = true
+				continue
+			}
+			if space {
+				b.WriteByte(' ')
+				space = false
+			}
+			b.WriteRune(r)
+		}
+		lines[i] = b.String()
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+func isWSP(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+// foldBase64 按 width 折行，续行以制表符开头
+func foldBase64(s string, width int) string {
+	var b strings.Builder
+	for len(s) > width {
+		b.WriteString(s[:width])
+		b.WriteString("\r\n\t")
+		s = s[width:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
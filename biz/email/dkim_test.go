@@ -0,0 +1,177 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	wspRe        = regexp.MustCompile(`[ \t]+`)
+	trailingWSP  = regexp.MustCompile(`[ \t]+\r\n`)
+	trailingCRLF = regexp.MustCompile(`(\r\n)+$`)
+	sigValueRe   = regexp.MustCompile(`(^|;)(\s*b=)[^;]*`)
+)
+
+// verifyDKIM 独立实现的 relaxed/relaxed 校验，仅用于测试
+func verifyDKIM(message string, pub crypto.PublicKey) error {
+	message = strings.ReplaceAll(strings.ReplaceAll(message, "\r\n", "\n"), "\n", "\r\n")
+	i := strings.Index(message, "\r\n\r\n")
+	header, body := message[:i+2], message[i+4:]
+
+	var fields []string
+	for _, line := range strings.SplitAfter(header, "\r\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			fields[len(fields)-1] += line
+		} else if line != "" {
+			fields = append(fields, line)
+		}
+	}
+	canonHeader := func(f string) string {
+		name, value, _ := strings.Cut(f, ":")
+		value = strings.TrimSpace(wspRe.ReplaceAllString(strings.ReplaceAll(value, "\r\n", ""), " "))
+		return strings.ToLower(strings.TrimSpace(name)) + ":" + value + "\r\n"
+	}
+
+	var sigField string
+	tags := map[string]string{}
+	for _, f := range fields {
+		if strings.HasPrefix(strings.ToLower(f), "dkim-signature:") {
+			sigField = f
+			_, value, _ := strings.Cut(f, ":")
+			for _, tag := range strings.Split(value, ";") {
+				k, v, _ := strings.Cut(tag, "=")
+				tags[strings.TrimSpace(k)] = regexp.MustCompile(`\s+`).ReplaceAllString(v, "")
+			}
+			break
+		}
+	}
+	if sigField == "" {
+		return errors.New("no signature")
+	}
+
+	canonBody := trailingWSP.ReplaceAllString(body, "\r\n")
+	canonBody = wspRe.ReplaceAllString(canonBody, " ")
+	canonBody = trailingCRLF.ReplaceAllString(canonBody, "")
+	if canonBody != "" {
+		canonBody += "\r\n"
+	}
+	bh := sha256.Sum256([]byte(canonBody))
+	if base64.StdEncoding.EncodeToString(bh[:]) != tags["bh"] {
+		return errors.New("body hash mismatch")
+	}
+
+	var data strings.Builder
+	seen := map[string]int{}
+	for _, name := range strings.Split(tags["h"], ":") {
+		skip := seen[name]
+		seen[name]++
+		for j := len(fields) - 1; j >= 0; j-- {
+			n, _, _ := strings.Cut(fields[j], ":")
+			if strings.ToLower(strings.TrimSpace(n)) != name {
+				continue
+			}
+			if skip == 0 {
+				data.WriteString(canonHeader(fields[j]))
+				break
+			}
+			skip--
+		}
+	}
+	data.WriteString(strings.TrimSuffix(canonHeader(sigValueRe.ReplaceAllString(sigField, "$1$2")), "\r\n"))
+	digest := sha256.Sum256([]byte(data.String()))
+
+	sig, err := base64.StdEncoding.DecodeString(tags["b"])
+	if err != nil {
+		return err
+	}
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, digest[:], sig) {
+			return errors.New("ed25519 verification failed")
+		}
+	}
+	return nil
+}
+
+func TestDKIMSignRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	signer, err := NewDKIMSigner("example.com", "mail", key)
+	assert.NoError(t, err)
+
+	msg := "From: a@example.com\r\nTo: b@example.com\r\nSubject:  Hello\r\n\tWorld\r\n\r\nline one  \nline\ttwo\r\n\r\n\r\n"
+	signed, err := signer.Sign([]byte(msg))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(signed), "DKIM-Signature: v=1; a=rsa-sha256; c=relaxed/relaxed; d=example.com; s=mail;"))
+	assert.Contains(t, string(signed), "h=from:to:subject;")
+	assert.NoError(t, verifyDKIM(string(signed), &key.PublicKey))
+
+	// relaxed 规范化忽略空白差异，内容变化则校验失败
+	relaxed := strings.Replace(string(signed), "line one", "line   one", 1)
+	assert.NoError(t, verifyDKIM(relaxed, &key.PublicKey))
+	tampered := strings.Replace(string(signed), "World", "Word", 1)
+	assert.Error(t, verifyDKIM(tampered, &key.PublicKey))
+	tampered = strings.Replace(string(signed), "line\ttwo", "line three", 1)
+	assert.Error(t, verifyDKIM(tampered, &key.PublicKey))
+
+	_, err = signer.Sign([]byte("To: b@example.com\r\n\r\nbody"))
+	assert.ErrorIs(t, err, ErrInvalidFrom)
+}
+
+func TestDKIMSignEd25519ViaSMTP(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	signer, err := NewDKIMSigner("example.com", "ed", priv)
+	assert.NoError(t, err)
+	signer.SetHeaders("From", "To", "Subject", "Date", "Content-Type")
+
+	server := newFakeSMTPServer(t)
+	config := server.config()
+	config.DKIM = signer
+	sender, err := NewSMTPSender(config)
+	assert.NoError(t, err)
+	assert.NoError(t, sender.Send(context.Background(), &Message{
+		From: "sender@example.com", To: []string{"a@example.com"}, Subject: "签名测试",
+		Text: "plain", HTML: "<p>html</p>",
+		Attachments: []*Attachment{{Filename: "a.txt", ContentType: "text/plain", Data: strings.NewReader("attachment")}},
+	}))
+
+	mails := server.received()
+	assert.Len(t, mails, 1)
+	assert.Contains(t, mails[0].Data, "a=ed25519-sha256")
+	assert.Contains(t, mails[0].Data, "h=from:to:subject:date:content-type;")
+	assert.NoError(t, verifyDKIM(mails[0].Data, pub))
+}
+
+func TestParseDKIMPrivateKey(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	key, err := ParseDKIMPrivateKey(pkcs1)
+	assert.NoError(t, err)
+	assert.IsType(t, &rsa.PrivateKey{}, key)
+
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	key, err = ParseDKIMPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(edKey, key.(ed25519.PrivateKey)))
+
+	_, err = ParseDKIMPrivateKey([]byte("not a key"))
+	assert.ErrorIs(t, err, ErrInvalidDKIMKey)
+}
@@ -1,6 +1,9 @@
 package email
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// 邮件验证错误
@@ -17,7 +20,58 @@ var (
 	ErrTimeout        = errors.New("email sending timeout")
 	ErrTooManyRetries = errors.New("exceeded maximum retry attempts")
 
+	// 所有收件人都在抑制名单中
+	ErrRecipientsSuppressed = errors.New("all recipients are suppressed")
+
+	// 收信错误
+	ErrReceiveFailed = errors.New("failed to receive email")
+
 	// 附件错误
 	ErrAttachmentTooLarge = errors.New("attachment size exceeds limit")
 	ErrInvalidAttachment  = errors.New("invalid attachment")
+
+	// 签名与加密错误
+	ErrMissingRecipientKey = errors.New("no encryption key for recipient")
 )
+
+// BatchError 批量发送部分失败，Errors 与消息一一对应，发送成功的位置为 nil
+type BatchError struct {
+	Errors []error
+}
+
+// newBatchError 全部成功时返回 nil
+func newBatchError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return &BatchError{Errors: errs}
+		}
+	}
+	return nil
+}
+
+func (e *BatchError) Error() string {
+	failed := e.Failed()
+	return fmt.Sprintf("%d of %d messages failed, first error: %v", len(failed), len(e.Errors), e.Errors[failed[0]])
+}
+
+// Unwrap 返回所有失败原因，支持 errors.Is/As
+func (e *BatchError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Failed 返回发送失败的消息下标
+func (e *BatchError) Failed() []int {
+	var failed []int
+	for i, err := range e.Errors {
+		if err != nil {
+			failed = append(failed, i)
+		}
+	}
+	return failed
+}
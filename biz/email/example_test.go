@@ -45,8 +45,8 @@ func ExampleSMTPSender_Send() {
 	fmt.Println("邮件发送成功")
 }
 
-// ExampleSendHTML 发送HTML邮件示例
-func ExampleSendHTML() {
+// Example_sendHTML 发送HTML邮件示例
+func Example_sendHTML() {
 	config := email.DefaultConfig()
 	config.Host = "smtp.example.com"
 	config.Username = "user@example.com"
@@ -97,8 +97,8 @@ func ExampleSendHTML() {
 	fmt.Println("HTML邮件发送成功")
 }
 
-// ExampleSendWithAttachment 发送带附件的邮件示例
-func ExampleSendWithAttachment() {
+// Example_sendWithAttachment 发送带附件的邮件示例
+func Example_sendWithAttachment() {
 	config := &email.Config{
 		Host:      "smtp.example.com",
 		Port:      587,
@@ -0,0 +1,86 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileSender 将邮件写为 .eml 文件，便于本地开发时用邮件客户端查看
+type FileSender struct {
+	config *Config
+	dir    string
+	seq    uint64
+}
+
+// NewFileSender 创建文件发送器，邮件写入 Config.OutputDir（为空时写入系统临时目录下的 emails 目录）
+func NewFileSender(config *Config) (*FileSender, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	dir := config.OutputDir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "emails")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return &FileSender{config: config, dir: dir}, nil
+}
+
+// Send 写入 .eml 文件
+func (f *FileSender) Send(ctx context.Context, message *Message) error {
+	_, err := f.Write(message)
+	return err
+}
+
+// Write 写入 .eml 文件并返回文件路径
+func (f *FileSender) Write(message *Message) (string, error) {
+	if err := prepareMessage(f.config, message); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%04d.eml", time.Now().Format("20060102-150405.000"), atomic.AddUint64(&f.seq, 1))
+	path := filepath.Join(f.dir, name)
+
+	// 需要签名时先构建完整邮件，否则直接流式写入文件
+	if f.config.DKIM != nil {
+		body, err := BuildMessage(message, f.config.Protectors...)
+		if err != nil {
+			return "", fmt.Errorf("failed to build message: %w", err)
+		}
+		if body, err = f.config.DKIM.Sign(body); err != nil {
+			return "", fmt.Errorf("failed to sign message: %w", err)
+		}
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := WriteMessage(file, message, f.config.Protectors...); err != nil {
+		file.Close()
+		os.Remove(path)
+		return "", fmt.Errorf("failed to build message: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// SendBatch 批量写入
+func (f *FileSender) SendBatch(ctx context.Context, messages []*Message) error {
+	return sendEach(ctx, f, messages)
+}
+
+// Close 无需关闭
+func (f *FileSender) Close() error {
+	return nil
+}
@@ -0,0 +1,683 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IMAPClient IMAP 收信客户端（IMAP4rev1），支持 IDLE 监听新邮件；非并发安全
+type IMAPClient struct {
+	config *ReceiverConfig
+
+	conn    net.Conn
+	r       *bufio.Reader
+	partial []byte // 读超时时已读取的不完整行
+	tag     int
+	caps    map[string]bool
+	exists  uint32 // 当前邮箱的邮件数
+
+	onParseError func(uid uint32, err error) // Watch 跳过无法解析的邮件时回调
+}
+
+// MessageParseError 单封邮件解析失败，Fetch 返回的错误由一个或多个 MessageParseError 组成
+type MessageParseError struct {
+	UID uint32
+	Err error
+}
+
+func (e *MessageParseError) Error() string {
+	return fmt.Sprintf("uid %d: %v", e.UID, e.Err)
+}
+
+func (e *MessageParseError) Unwrap() error {
+	return e.Err
+}
+
+// imapResponse 服务器响应，fields 为解析后的字段：原子与引号字符串为 string，文字量为 []byte，列表为 []any，NIL 为 nil
+type imapResponse struct {
+	tag    string
+	fields []any
+	text   string // 状态响应的文本部分
+}
+
+// NewIMAPClient 创建IMAP客户端，需调用 Connect 建立连接
+func NewIMAPClient(config *ReceiverConfig) (*IMAPClient, error) {
+	if config == nil {
+		config = DefaultReceiverConfig()
+	}
+	if err := config.validate(993); err != nil {
+		return nil, err
+	}
+	if config.Mailbox == "" {
+		config.Mailbox = "INBOX"
+	}
+	return &IMAPClient{config: config}, nil
+}
+
+// Connect 建立连接，完成 TLS 与登录并选择邮箱
+func (c *IMAPClient) Connect(ctx context.Context) error {
+	mode := c.config.tlsMode(993)
+	conn, err := c.config.dial(ctx, mode == TLSImplicit)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+	}
+	c.setConn(conn)
+	c.setDeadline(ctx)
+
+	greeting, err := c.readResponse()
+	if err != nil {
+		c.conn.Close()
+		return fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+	}
+	if greeting.status() == "BYE" {
+		c.conn.Close()
+		return fmt.Errorf("%w: server refused connection: %s", ErrReceiveFailed, greeting.text)
+	}
+	if err := c.capability(ctx); err != nil {
+		c.conn.Close()
+		return err
+	}
+
+	// STARTTLS 升级
+	if mode == TLSStartTLS || mode == TLSStartTLSOpportunistic {
+		if c.caps["STARTTLS"] {
+			if err := c.command(ctx, nil, "STARTTLS"); err != nil {
+				c.conn.Close()
+				return err
+			}
+			tlsConn := tls.Client(c.conn, c.config.tlsConfig())
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				c.conn.Close()
+				return fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+			}
+			c.setConn(tlsConn)
+			if err := c.capability(ctx); err != nil {
+				c.conn.Close()
+				return err
+			}
+		} else if mode == TLSStartTLS {
+			c.conn.Close()
+			return fmt.Errorf("%w: server does not support STARTTLS", ErrReceiveFailed)
+		}
+	}
+
+	if greeting.status() != "PREAUTH" {
+		if err := c.login(ctx); err != nil {
+			c.conn.Close()
+			return err
+		}
+	}
+	if err := c.Select(ctx, c.config.Mailbox); err != nil {
+		c.conn.Close()
+		return err
+	}
+	return nil
+}
+
+// login 使用 XOAUTH2 或 LOGIN 登录
+func (c *IMAPClient) login(ctx context.Context) error {
+	if c.config.TokenSource != nil {
+		token, err := c.config.TokenSource(ctx)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrAuthFailed, err)
+		}
+		ir := base64.StdEncoding.EncodeToString([]byte("user=" + c.config.Username + "\x01auth=Bearer " + token + "\x01\x01"))
+		if err := c.command(ctx, nil, "AUTHENTICATE XOAUTH2 %s", ir); err != nil {
+			return fmt.Errorf("%w: %v", ErrAuthFailed, err)
+		}
+	} else {
+		if c.caps["LOGINDISABLED"] {
+			return fmt.Errorf("%w: server disabled LOGIN on this connection", ErrAuthFailed)
+		}
+		if err := c.command(ctx, nil, "LOGIN %s %s", imapQuote(c.config.Username), imapQuote(c.config.Password)); err != nil {
+			return fmt.Errorf("%w: %v", ErrAuthFailed, err)
+		}
+	}
+	// 登录后服务器能力可能变化
+	return c.capability(ctx)
+}
+
+// capability 查询服务器能力
+func (c *IMAPClient) capability(ctx context.Context) error {
+	caps := map[string]bool{}
+	err := c.command(ctx, func(resp *imapResponse) {
+		if resp.status() == "CAPABILITY" {
+			for _, field := range resp.fields[1:] {
+				if s, ok := field.(string); ok {
+					caps[strings.ToUpper(s)] = true
+				}
+			}
+		}
+	}, "CAPABILITY")
+	c.caps = caps
+	return err
+}
+
+// Select 选择邮箱
+func (c *IMAPClient) Select(ctx context.Context, mailbox string) error {
+	return c.command(ctx, nil, "SELECT %s", imapQuote(mailbox))
+}
+
+// Search 按 IMAP 搜索条件查找邮件，如 "UNSEEN"、"FROM \"a@example.com\""，返回 UID 列表
+func (c *IMAPClient) Search(ctx context.Context, criteria string) ([]uint32, error) {
+	var uids []uint32
+	err := c.command(ctx, func(resp *imapResponse) {
+		if resp.status() != "SEARCH" {
+			return
+		}
+		for _, field := range resp.fields[1:] {
+			if s, ok := field.(string); ok {
+				if uid, err := strconv.ParseUint(s, 10, 32); err == nil {
+					uids = append(uids, uint32(uid))
+				}
+			}
+		}
+	}, "UID SEARCH %s", criteria)
+	return uids, err
+}
+
+// Unseen 返回未读邮件的 UID
+func (c *IMAPClient) Unseen(ctx context.Context) ([]uint32, error) {
+	return c.Search(ctx, "UNSEEN")
+}
+
+// Fetch 按 UID 获取并解析邮件，不会将邮件标记为已读
+// 部分邮件解析失败时仍返回其余邮件，错误为各封邮件的 MessageParseError
+func (c *IMAPClient) Fetch(ctx context.Context, uids ...uint32) ([]*ReceivedMessage, error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	var messages []*ReceivedMessage
+	var parseErr error
+	err := c.command(ctx, func(resp *imapResponse) {
+		if len(resp.fields) < 3 || resp.status() != "FETCH" {
+			return
+		}
+		items, _ := resp.fields[2].([]any)
+		var uid uint32
+		var flags []string
+		var body []byte
+		for i := 0; i+1 < len(items); i += 2 {
+			name, _ := items[i].(string)
+			switch strings.ToUpper(name) {
+			case "UID":
+				if s, ok := items[i+1].(string); ok {
+					n, _ := strconv.ParseUint(s, 10, 32)
+					uid = uint32(n)
+				}
+			case "FLAGS":
+				list, _ := items[i+1].([]any)
+				for _, f := range list {
+					if s, ok := f.(string); ok {
+						flags = append(flags, s)
+					}
+				}
+			case "BODY[]":
+				switch v := items[i+1].(type) {
+				case []byte:
+					body = v
+				case string:
+					body = []byte(v)
+				}
+			}
+		}
+		// 忽略服务器主动推送的标记变更
+		if body == nil {
+			return
+		}
+		msg, err := ParseMessage(bytes.NewReader(body))
+		if err != nil {
+			parseErr = errors.Join(parseErr, &MessageParseError{UID: uid, Err: err})
+			return
+		}
+		msg.UID = uid
+		msg.Flags = flags
+		messages = append(messages, msg)
+	}, "UID FETCH %s (UID FLAGS BODY.PEEK[])", imapSequenceSet(uids))
+	if err != nil {
+		return nil, err
+	}
+	return messages, parseErr
+}
+
+// MarkSeen 将邮件标记为已读
+func (c *IMAPClient) MarkSeen(ctx context.Context, uids ...uint32) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	return c.command(ctx, nil, "UID STORE %s +FLAGS.SILENT (\\Seen)", imapSequenceSet(uids))
+}
+
+// Delete 删除邮件（标记 \Deleted 并 EXPUNGE）
+func (c *IMAPClient) Delete(ctx context.Context, uids ...uint32) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	if err := c.command(ctx, nil, "UID STORE %s +FLAGS.SILENT (\\Deleted)", imapSequenceSet(uids)); err != nil {
+		return err
+	}
+	return c.command(ctx, nil, "EXPUNGE")
+}
+
+// Idle 等待邮箱出现新邮件，收到新邮件或到达 IdleTimeout 时返回 nil，ctx 结束时返回 ctx.Err()；
+// 服务器不支持 IDLE 时按 PollInterval 轮询
+func (c *IMAPClient) Idle(ctx context.Context) error {
+	if !c.caps["IDLE"] {
+		return c.poll(ctx)
+	}
+
+	before := c.exists
+	tag := c.nextTag()
+	c.setDeadline(ctx)
+	if _, err := fmt.Fprintf(c.conn, "%s IDLE\r\n", tag); err != nil {
+		return fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+	}
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+		}
+		if resp.tag == "+" {
+			break
+		}
+		if resp.tag == tag {
+			return fmt.Errorf("%w: IDLE: %s", ErrReceiveFailed, resp.text)
+		}
+		c.handleUntagged(resp)
+	}
+
+	// ctx 结束时通过读超时打断等待
+	timeout := c.config.IdleTimeout
+	if timeout <= 0 {
+		timeout = DefaultReceiverConfig().IdleTimeout
+	}
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+			c.conn.SetReadDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	var waitErr error
+	for c.exists <= before {
+		resp, err := c.readResponse()
+		if err != nil {
+			waitErr = err
+			break
+		}
+		c.handleUntagged(resp)
+		// 期间有邮件被删除时以新的数量为基准
+		if resp.status() == "EXPUNGE" {
+			before = c.exists
+		}
+	}
+	close(stop)
+	<-done
+	if waitErr != nil && !errors.Is(waitErr, os.ErrDeadlineExceeded) {
+		return fmt.Errorf("%w: %v", ErrReceiveFailed, waitErr)
+	}
+
+	// 结束 IDLE，此时 ctx 可能已结束，只按命令超时等待
+	c.conn.SetDeadline(receiverDeadline(c.config.CommandTimeout))
+	if _, err := io.WriteString(c.conn, "DONE\r\n"); err != nil {
+		return fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+	}
+	if err := c.waitTagged(tag, nil); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// poll 轮询等待新邮件
+func (c *IMAPClient) poll(ctx context.Context) error {
+	interval := c.config.PollInterval
+	if interval <= 0 {
+		interval = DefaultReceiverConfig().PollInterval
+	}
+	before := c.exists
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		if err := c.command(ctx, nil, "NOOP"); err != nil {
+			return err
+		}
+		if c.exists > before {
+			return nil
+		}
+		before = c.exists
+	}
+}
+
+// OnParseError 设置 Watch 跳过无法解析的邮件时的回调
+func (c *IMAPClient) OnParseError(handler func(uid uint32, err error)) *IMAPClient {
+	c.onParseError = handler
+	return c
+}
+
+// Watch 持续处理未读邮件：先处理已有的未读邮件，再通过 IDLE 等待新邮件，直到 ctx 结束、连接出错或 handler 返回错误。
+// 邮件不会自动标记为已读，handler 可调用 MarkSeen 或 Delete；同一次 Watch 中每封邮件只处理一次，
+// 无法解析的邮件会被跳过并交给 OnParseError 设置的回调
+func (c *IMAPClient) Watch(ctx context.Context, handler func(ctx context.Context, message *ReceivedMessage) error) error {
+	var last uint32
+	for {
+		uids, err := c.Search(ctx, fmt.Sprintf("UID %d:* UNSEEN", last+1))
+		if err != nil {
+			return err
+		}
+		var fresh []uint32
+		for _, uid := range uids {
+			if uid > last {
+				fresh = append(fresh, uid)
+			}
+		}
+		if len(fresh) > 0 {
+			messages, err := c.Fetch(ctx, fresh...)
+			if err != nil && !isParseError(err) {
+				return err
+			}
+			c.reportParseErrors(err)
+			for _, msg := range messages {
+				if err := handler(ctx, msg); err != nil {
+					return err
+				}
+				last = max(last, msg.UID)
+			}
+			// 无法解析的邮件保持未读，跳过以免每次都重新获取
+			last = max(last, slices.Max(fresh))
+		}
+		if err := c.Idle(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// isParseError 判断 Fetch 的错误是否仅由邮件解析失败组成
+func isParseError(err error) bool {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			if !isParseError(e) {
+				return false
+			}
+		}
+		return true
+	}
+	_, ok := err.(*MessageParseError)
+	return ok
+}
+
+// reportParseErrors 将解析失败的邮件交给回调
+func (c *IMAPClient) reportParseErrors(err error) {
+	if err == nil || c.onParseError == nil {
+		return
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			c.reportParseErrors(e)
+		}
+		return
+	}
+	if e, ok := err.(*MessageParseError); ok {
+		c.onParseError(e.UID, e.Err)
+	}
+}
+
+// Close 登出并关闭连接
+func (c *IMAPClient) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	c.command(context.Background(), nil, "LOGOUT")
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *IMAPClient) setConn(conn net.Conn) {
+	c.conn = conn
+	c.r = bufio.NewReader(conn)
+}
+
+// setDeadline 按 CommandTimeout 与 ctx 设置读写截止时间
+func (c *IMAPClient) setDeadline(ctx context.Context) {
+	deadline := receiverDeadline(c.config.CommandTimeout)
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	c.conn.SetDeadline(deadline)
+}
+
+func (c *IMAPClient) nextTag() string {
+	c.tag++
+	return "a" + strconv.Itoa(c.tag)
+}
+
+// command 发送命令并等待带标签的结果，期间的非标签响应交给 handle
+func (c *IMAPClient) command(ctx context.Context, handle func(*imapResponse), format string, args ...any) error {
+	if c.conn == nil {
+		return fmt.Errorf("%w: not connected", ErrReceiveFailed)
+	}
+	tag := c.nextTag()
+	c.setDeadline(ctx)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+	}
+	return c.waitTagged(tag, handle)
+}
+
+// waitTagged 读取响应直到带 tag 的结果
+func (c *IMAPClient) waitTagged(tag string, handle func(*imapResponse)) error {
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+		}
+		switch resp.tag {
+		case tag:
+			if resp.status() != "OK" {
+				return fmt.Errorf("%w: %s %s", ErrReceiveFailed, resp.status(), resp.text)
+			}
+			return nil
+		case "+":
+			// 未预期的续行请求（如认证失败后的错误详情），发送空行取消
+			if _, err := io.WriteString(c.conn, "\r\n"); err != nil {
+				return fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+			}
+		default:
+			c.handleUntagged(resp)
+			if handle != nil {
+				handle(resp)
+			}
+		}
+	}
+}
+
+// handleUntagged 记录邮箱状态变化
+func (c *IMAPClient) handleUntagged(resp *imapResponse) {
+	if len(resp.fields) < 2 {
+		return
+	}
+	n, err := strconv.ParseUint(fmt.Sprint(resp.fields[0]), 10, 32)
+	if err != nil {
+		return
+	}
+	switch resp.status() {
+	case "EXISTS":
+		c.exists = uint32(n)
+	case "EXPUNGE":
+		if c.exists > 0 {
+			c.exists--
+		}
+	}
+}
+
+// readResponse 读取一条完整响应（含文字量）
+func (c *IMAPClient) readResponse() (*imapResponse, error) {
+	var raw []byte
+	for {
+		line, err := c.r.ReadBytes('\n')
+		if err != nil {
+			c.partial = append(c.partial, line...)
+			return nil, err
+		}
+		if len(c.partial) > 0 {
+			line = append(c.partial, line...)
+			c.partial = nil
+		}
+		raw = append(raw, line...)
+		size, ok := imapLiteralSize(line)
+		if !ok {
+			break
+		}
+		if max := c.config.maxMessageSize(); int64(size) > max || int64(len(raw)+size) > max {
+			return nil, fmt.Errorf("%w: literal of %d bytes exceeds MaxMessageSize %d", ErrReceiveFailed, size, max)
+		}
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return nil, err
+		}
+		raw = append(raw, literal...)
+	}
+
+	tag, rest, _ := bytes.Cut(raw, []byte(" "))
+	resp := &imapResponse{tag: strings.TrimRight(string(tag), "\r\n")}
+	resp.fields = (&imapParser{data: rest}).parse()
+	// 状态响应的文本部分不按字段解析
+	status, text, _ := strings.Cut(strings.TrimRight(string(rest), "\r\n"), " ")
+	if _, err := strconv.Atoi(status); err == nil {
+		_, text, _ = strings.Cut(text, " ")
+	}
+	resp.text = text
+	return resp, nil
+}
+
+// status 返回响应类型，如 OK、NO、CAPABILITY、EXISTS、FETCH
+func (r *imapResponse) status() string {
+	if len(r.fields) == 0 {
+		return ""
+	}
+	first, _ := r.fields[0].(string)
+	if _, err := strconv.Atoi(first); err == nil && len(r.fields) > 1 {
+		first, _ = r.fields[1].(string)
+	}
+	return strings.ToUpper(first)
+}
+
+// imapLiteralSize 行尾为 {n}\r\n 时返回文字量长度
+func imapLiteralSize(line []byte) (int, bool) {
+	line = bytes.TrimRight(line, "\r\n")
+	if !bytes.HasSuffix(line, []byte("}")) {
+		return 0, false
+	}
+	i := bytes.LastIndexByte(line, '{')
+	if i < 0 {
+		return 0, false
+	}
+	size, err := strconv.Atoi(string(line[i+1 : len(line)-1]))
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+// imapParser 宽松的响应字段解析器
+type imapParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *imapParser) parse() []any {
+	var fields []any
+	for {
+		field, ok := p.next()
+		if !ok {
+			return fields
+		}
+		fields = append(fields, field)
+	}
+}
+
+func (p *imapParser) next() (any, bool) {
+	for p.pos < len(p.data) && p.data[p.pos] == ' ' {
+		p.pos++
+	}
+	if p.pos >= len(p.data) {
+		return nil, false
+	}
+	switch p.data[p.pos] {
+	case '\r', '\n', ')':
+		return nil, false
+	case '(':
+		p.pos++
+		list := p.parse()
+		if p.pos < len(p.data) && p.data[p.pos] == ')' {
+			p.pos++
+		}
+		if list == nil {
+			list = []any{}
+		}
+		return list, true
+	case '"':
+		var b strings.Builder
+		for p.pos++; p.pos < len(p.data) && p.data[p.pos] != '"'; p.pos++ {
+			if p.data[p.pos] == '\\' && p.pos+1 < len(p.data) {
+				p.pos++
+			}
+			b.WriteByte(p.data[p.pos])
+		}
+		p.pos++
+		return b.String(), true
+	case '{':
+		end := bytes.IndexByte(p.data[p.pos:], '}')
+		if end > 0 {
+			size, err := strconv.Atoi(string(p.data[p.pos+1 : p.pos+end]))
+			start := p.pos + end + 3 // 跳过 }\r\n
+			if err == nil && start+size <= len(p.data) {
+				p.pos = start + size
+				return p.data[start : start+size], true
+			}
+		}
+	}
+
+	start := p.pos
+	for p.pos < len(p.data) && !bytes.ContainsRune([]byte(" ()\r\n"), rune(p.data[p.pos])) {
+		p.pos++
+	}
+	atom := string(p.data[start:p.pos])
+	if strings.EqualFold(atom, "NIL") {
+		return nil, true
+	}
+	return atom, true
+}
+
+// imapQuote 生成引号字符串
+func imapQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// imapSequenceSet 生成 UID 集合，如 1,5,9
+func imapSequenceSet(uids []uint32) string {
+	parts := make([]string, len(uids))
+	for i, uid := range uids {
+		parts[i] = strconv.FormatUint(uint64(uid), 10)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,407 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeIMAPMessage struct {
+	uid   uint32
+	flags map[string]bool
+	data  string
+}
+
+// fakeIMAPServer 进程内 IMAP 服务器，仅实现客户端用到的命令
+type fakeIMAPServer struct {
+	t        *testing.T
+	ln       net.Listener
+	noIdle   bool
+	mu       sync.Mutex
+	messages []*fakeIMAPMessage
+	nextUID  uint32
+	sessions map[chan struct{}]bool
+}
+
+func newFakeIMAPServer(t *testing.T, noIdle bool) *fakeIMAPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s := &fakeIMAPServer{t: t, ln: ln, noIdle: noIdle, nextUID: 100, sessions: map[chan struct{}]bool{}}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeIMAPServer) config() *ReceiverConfig {
+	config := DefaultReceiverConfig()
+	config.Host = "127.0.0.1"
+	config.Port = s.ln.Addr().(*net.TCPAddr).Port
+	config.TLSMode = TLSNone
+	config.Username = "user"
+	config.Password = `p"ass`
+	config.CommandTimeout = time.Second * 5
+	config.PollInterval = time.Millisecond * 20
+	return config
+}
+
+// add 投递一封邮件并唤醒处于 IDLE 的会话
+func (s *fakeIMAPServer) add(data string) uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextUID++
+	s.messages = append(s.messages, &fakeIMAPMessage{uid: s.nextUID, flags: map[string]bool{}, data: data})
+	for ch := range s.sessions {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	return s.nextUID
+}
+
+func (s *fakeIMAPServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.messages)
+}
+
+var uidFromRe = regexp.MustCompile(`UID (\d+):\*`)
+
+func (s *fakeIMAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	notify := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.sessions[notify] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, notify)
+		s.mu.Unlock()
+	}()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			lines <- strings.TrimRight(line, "\r\n")
+		}
+	}()
+
+	w := bufio.NewWriter(conn)
+	send := func(format string, args ...any) {
+		fmt.Fprintf(w, format+"\r\n", args...)
+		w.Flush()
+	}
+	known := 0
+	reportExists := func() {
+		if n := s.count(); n != known {
+			known = n
+			send("* %d EXISTS", n)
+		}
+	}
+
+	send("* OK fake IMAP ready")
+	for line := range lines {
+		tag, rest, _ := strings.Cut(line, " ")
+		cmd, args, _ := strings.Cut(rest, " ")
+		cmd = strings.ToUpper(cmd)
+		if cmd == "UID" {
+			sub, subArgs, _ := strings.Cut(args, " ")
+			cmd, args = "UID "+strings.ToUpper(sub), subArgs
+		}
+		switch cmd {
+		case "CAPABILITY":
+			if s.noIdle {
+				send("* CAPABILITY IMAP4rev1")
+			} else {
+				send("* CAPABILITY IMAP4rev1 IDLE")
+			}
+			send("%s OK done", tag)
+		case "LOGIN":
+			if args != `"user" "p\"ass"` {
+				send("%s NO [AUTHENTICATIONFAILED] invalid credentials", tag)
+				continue
+			}
+			send("%s OK logged in", tag)
+		case "SELECT":
+			known = -1
+			reportExists()
+			send("* OK [UIDVALIDITY 1] ok")
+			send("%s OK [READ-WRITE] selected", tag)
+		case "NOOP":
+			reportExists()
+			send("%s OK done", tag)
+		case "UID SEARCH":
+			from := 0
+			if m := uidFromRe.FindStringSubmatch(args); m != nil {
+				from, _ = strconv.Atoi(m[1])
+			}
+			var uids []string
+			s.mu.Lock()
+			for _, msg := range s.messages {
+				if int(msg.uid) >= from && !(strings.Contains(args, "UNSEEN") && msg.flags[`\Seen`]) {
+					uids = append(uids, strconv.Itoa(int(msg.uid)))
+				}
+			}
+			s.mu.Unlock()
+			send("* SEARCH %s", strings.Join(uids, " "))
+			send("%s OK done", tag)
+		case "UID FETCH":
+			set, _, _ := strings.Cut(args, " ")
+			s.mu.Lock()
+			for i, msg := range s.messages {
+				if !containsUID(set, msg.uid) {
+					continue
+				}
+				var flags []string
+				for f := range msg.flags {
+					flags = append(flags, f)
+				}
+				fmt.Fprintf(w, "* %d FETCH (UID %d FLAGS (%s) BODY[] {%d}\r\n%s)\r\n", i+1, msg.uid, strings.Join(flags, " "), len(msg.data), msg.data)
+			}
+			s.mu.Unlock()
+			send("%s OK done", tag)
+		case "UID STORE":
+			set, flags, _ := strings.Cut(args, " ")
+			flag := strings.TrimSuffix(strings.TrimPrefix(flags, "+FLAGS.SILENT ("), ")")
+			s.mu.Lock()
+			for _, msg := range s.messages {
+				if containsUID(set, msg.uid) {
+					msg.flags[flag] = true
+				}
+			}
+			s.mu.Unlock()
+			send("%s OK done", tag)
+		case "EXPUNGE":
+			s.mu.Lock()
+			for i := len(s.messages) - 1; i >= 0; i-- {
+				if s.messages[i].flags[`\Deleted`] {
+					s.messages = append(s.messages[:i], s.messages[i+1:]...)
+					fmt.Fprintf(w, "* %d EXPUNGE\r\n", i+1)
+					known--
+				}
+			}
+			s.mu.Unlock()
+			send("%s OK done", tag)
+		case "IDLE":
+			send("+ idling")
+			reportExists()
+		idle:
+			for {
+				select {
+				case <-notify:
+					reportExists()
+				case done, ok := <-lines:
+					if !ok {
+						return
+					}
+					assert.Equal(s.t, "DONE", done)
+					break idle
+				}
+			}
+			send("%s OK idle done", tag)
+		case "LOGOUT":
+			send("* BYE bye")
+			send("%s OK done", tag)
+			return
+		default:
+			send("%s BAD unknown command", tag)
+		}
+	}
+}
+
+func containsUID(set string, uid uint32) bool {
+	for _, s := range strings.Split(set, ",") {
+		if s == strconv.Itoa(int(uid)) {
+			return true
+		}
+	}
+	return false
+}
+
+func rawTestMessage(t *testing.T, subject string) string {
+	raw, err := BuildMessage(&Message{
+		From: "张三 <zhangsan@example.com>", To: []string{"me@example.com"},
+		Subject: subject, Text: "hello", HTML: "<p>hello</p>",
+		Attachments: []*Attachment{{Filename: "a.txt", Data: strings.NewReader("attachment")}},
+	})
+	assert.NoError(t, err)
+	return string(raw)
+}
+
+func TestIMAPClient(t *testing.T) {
+	server := newFakeIMAPServer(t, false)
+	first := server.add(rawTestMessage(t, "第一封"))
+	server.add(rawTestMessage(t, "second"))
+
+	client, err := NewIMAPClient(server.config())
+	assert.NoError(t, err)
+	ctx := context.Background()
+	assert.NoError(t, client.Connect(ctx))
+	defer client.Close()
+
+	uids, err := client.Unseen(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, uids, 2)
+
+	messages, err := client.Fetch(ctx, uids...)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, first, messages[0].UID)
+	assert.Equal(t, "第一封", messages[0].Subject)
+	assert.Equal(t, "张三 <zhangsan@example.com>", messages[0].From)
+	assert.Equal(t, "hello", messages[0].Text)
+	assert.Equal(t, "a.txt", messages[0].Attachments[0].Filename)
+
+	assert.NoError(t, client.MarkSeen(ctx, first))
+	uids, _ = client.Unseen(ctx)
+	assert.Equal(t, []uint32{first + 1}, uids)
+	messages, _ = client.Fetch(ctx, first)
+	assert.Equal(t, []string{`\Seen`}, messages[0].Flags)
+
+	assert.NoError(t, client.Delete(ctx, first))
+	assert.Equal(t, 1, server.count())
+
+	config := server.config()
+	config.Password = "wrong"
+	client, _ = NewIMAPClient(config)
+	assert.ErrorIs(t, client.Connect(ctx), ErrAuthFailed)
+}
+
+func TestIMAPMaxMessageSize(t *testing.T) {
+	// 异常的文字量长度不会按服务器声明的大小分配内存
+	client, _ := NewIMAPClient(&ReceiverConfig{Host: "imap.example.com"})
+	client.r = bufio.NewReader(strings.NewReader("* 1 FETCH (BODY[] {99999999999}\r\n"))
+	_, err := client.readResponse()
+	assert.ErrorIs(t, err, ErrReceiveFailed)
+
+	server := newFakeIMAPServer(t, false)
+	uid := server.add(rawTestMessage(t, "large"))
+	config := server.config()
+	config.MaxMessageSize = 64
+	client, _ = NewIMAPClient(config)
+	ctx := context.Background()
+	assert.NoError(t, client.Connect(ctx))
+	defer client.Close()
+	_, err = client.Fetch(ctx, uid)
+	assert.ErrorIs(t, err, ErrReceiveFailed)
+}
+
+func TestIMAPWatch(t *testing.T) {
+	for _, noIdle := range []bool{false, true} {
+		t.Run(fmt.Sprintf("noIdle=%v", noIdle), func(t *testing.T) {
+			server := newFakeIMAPServer(t, noIdle)
+			server.add(rawTestMessage(t, "existing"))
+
+			client, err := NewIMAPClient(server.config())
+			assert.NoError(t, err)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			assert.NoError(t, client.Connect(ctx))
+			defer client.Close()
+
+			received := make(chan string, 10)
+			done := make(chan error, 1)
+			go func() {
+				done <- client.Watch(ctx, func(ctx context.Context, message *ReceivedMessage) error {
+					received <- message.Subject
+					return nil
+				})
+			}()
+
+			assert.Equal(t, "existing", <-received)
+			time.Sleep(time.Millisecond * 50)
+			server.add(rawTestMessage(t, "new"))
+			select {
+			case subject := <-received:
+				assert.Equal(t, "new", subject)
+			case <-time.After(time.Second * 3):
+				t.Fatal("new message not received")
+			}
+
+			cancel()
+			select {
+			case err := <-done:
+				assert.ErrorIs(t, err, context.Canceled)
+			case <-time.After(time.Second * 3):
+				t.Fatal("watch did not stop")
+			}
+			assert.Empty(t, received)
+		})
+	}
+}
+
+func TestIMAPWatchSkipsMalformed(t *testing.T) {
+	server := newFakeIMAPServer(t, false)
+	bad := server.add("malformed header line\r\n\r\nbody")
+	server.add(rawTestMessage(t, "good"))
+
+	client, err := NewIMAPClient(server.config())
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, client.Connect(ctx))
+	defer client.Close()
+
+	// Fetch 返回可解析的邮件及解析失败的 UID
+	messages, err := client.Fetch(ctx, bad, bad+1)
+	assert.Len(t, messages, 1)
+	var parseErr *MessageParseError
+	assert.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, bad, parseErr.UID)
+
+	var skipped []uint32
+	client.OnParseError(func(uid uint32, err error) {
+		skipped = append(skipped, uid)
+	})
+	received := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Watch(ctx, func(ctx context.Context, message *ReceivedMessage) error {
+			received <- message.Subject
+			return nil
+		})
+	}()
+
+	assert.Equal(t, "good", <-received)
+	time.Sleep(time.Millisecond * 50)
+	server.add(rawTestMessage(t, "new"))
+	select {
+	case subject := <-received:
+		assert.Equal(t, "new", subject)
+	case <-time.After(time.Second * 3):
+		t.Fatal("new message not received")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second * 3):
+		t.Fatal("watch did not stop")
+	}
+	assert.Equal(t, []uint32{bad}, skipped)
+	assert.Empty(t, received)
+}
@@ -50,6 +50,12 @@ type Attachment struct {
 	Filename    string    // 文件名
 	ContentType string    // MIME类型
 	Data        io.Reader // 文件内容
+	ContentID   string    // 内嵌资源ID，非空时作为 multipart/related 内嵌资源，HTML 中以 cid:ContentID 引用
+}
+
+// IsInline 是否为内嵌资源
+func (a *Attachment) IsInline() bool {
+	return a.ContentID != ""
 }
 
 // Priority 邮件优先级
@@ -69,13 +75,31 @@ type Config struct {
 	Username string // 用户名
 	Password string // 密码
 
+	// 认证配置
+	AuthMechanism AuthMechanism                             // 认证方式，为空时按服务器支持的方式自动选择
+	TokenSource   func(ctx context.Context) (string, error) // XOAUTH2 访问令牌来源，为空时使用 Password
+
 	// TLS配置
-	UseTLS          bool // 是否使用TLS
-	InsecureSkipTLS bool // 是否跳过TLS证书验证
+	TLSMode         TLSMode // TLS 模式，为空时由 UseTLS 与端口推断
+	UseTLS          bool    // 是否使用TLS
+	InsecureSkipTLS bool    // 是否跳过TLS证书验证
 
 	// 发送配置
-	FromEmail string // 默认发件人邮箱
-	FromName  string // 默认发件人名称
+	FromEmail string      // 默认发件人邮箱
+	FromName  string      // 默认发件人名称
+	DKIM      *DKIMSigner // DKIM 签名器，为空时不签名
+
+	// Protectors 按顺序对邮件内容签名或加密（S/MIME、OpenPGP），如先签名再加密；仅 SMTP 与文件发送器支持
+	Protectors []Protector
+
+	// 发送渠道配置
+	Provider  Provider // 发送渠道，为空时使用 SMTP
+	APIKey    string   // SendGrid/Mailgun API Key，阿里云 AccessKey ID
+	APISecret string   // 阿里云 AccessKey Secret
+	APIDomain string   // Mailgun 发信域名
+	APIRegion string   // 阿里云区域，如 cn-hangzhou，默认杭州
+	BaseURL   string   // 自定义 API 地址，如 Mailgun 欧洲区 https://api.eu.mailgun.net
+	OutputDir string   // FileSender 输出目录
 
 	// 连接池配置
 	PoolSize        int           // 连接池大小
@@ -87,6 +111,16 @@ type Config struct {
 	KeepAlivePeriod time.Duration // 保持连接周期
 }
 
+// TLSMode TLS 模式
+type TLSMode string
+
+const (
+	TLSNone                  TLSMode = "none"                   // 明文连接
+	TLSStartTLS              TLSMode = "starttls"               // 必须通过 STARTTLS 升级，服务器不支持时报错
+	TLSStartTLSOpportunistic TLSMode = "starttls-opportunistic" // 服务器支持 STARTTLS 时升级
+	TLSImplicit              TLSMode = "implicit"               // 建连即 TLS（SMTPS，通常为 465 端口）
+)
+
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
 	return &Config{
@@ -0,0 +1,135 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+const mailgunBaseURL = "https://api.mailgun.net"
+
+// MailgunSender 通过 Mailgun Messages API 发送邮件
+type MailgunSender struct {
+	config  *Config
+	baseURL string
+	client  *http.Client
+}
+
+// NewMailgunSender 创建 Mailgun 发送器，需配置 APIKey 与 APIDomain；欧洲区通过 BaseURL 指定
+func NewMailgunSender(config *Config) (*MailgunSender, error) {
+	if config == nil || config.APIKey == "" || config.APIDomain == "" {
+		return nil, fmt.Errorf("%w: mailgun api key and domain are required", ErrInvalidConfig)
+	}
+	if len(config.Protectors) > 0 {
+		return nil, fmt.Errorf("%w: mailgun cannot sign or encrypt message content", ErrUnsupportedFeature)
+	}
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = mailgunBaseURL
+	}
+	return &MailgunSender{
+		config:  config,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: httpTimeout(config)},
+	}, nil
+}
+
+// SetHTTPClient 设置 HTTP 客户端
+func (s *MailgunSender) SetHTTPClient(client *http.Client) *MailgunSender {
+	s.client = client
+	return s
+}
+
+// Send 发送邮件
+func (s *MailgunSender) Send(ctx context.Context, message *Message) error {
+	if err := prepareMessage(s.config, message); err != nil {
+		return err
+	}
+	body, contentType, err := s.buildForm(message)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/v3/%s/messages", s.baseURL, s.config.APIDomain)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth("api", s.config.APIKey)
+	req.Header.Set("Content-Type", contentType)
+	_, err = doRequest(s.client, req, ProviderMailgun)
+	return err
+}
+
+// buildForm 构建 multipart 表单，邮件头以 h: 前缀传递，内嵌资源以 ContentID 作为文件名（Mailgun 以文件名作为 cid）
+func (s *MailgunSender) buildForm(message *Message) (*bytes.Buffer, string, error) {
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+	field := func(name string, values ...string) {
+		for _, v := range values {
+			w.WriteField(name, v)
+		}
+	}
+	field("from", message.From)
+	field("to", message.To...)
+	field("cc", message.Cc...)
+	field("bcc", message.Bcc...)
+	field("subject", message.Subject)
+	if message.Text != "" {
+		field("text", message.Text)
+	}
+	if message.HTML != "" {
+		field("html", message.HTML)
+	}
+	if message.ReplyTo != "" {
+		field("h:Reply-To", message.ReplyTo)
+	}
+	for k, v := range messageHeaders(message) {
+		field("h:"+k, v)
+	}
+
+	for _, att := range message.Attachments {
+		data, err := readAttachment(att)
+		if err != nil {
+			return nil, "", err
+		}
+		name, filename := "attachment", att.Filename
+		if att.IsInline() {
+			name, filename = "inline", att.ContentID
+		}
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, name, escapeQuotes(filename)))
+		if att.ContentType != "" {
+			h.Set("Content-Type", att.ContentType)
+		}
+		part, err := w.CreatePart(h)
+		if err != nil {
+			return nil, "", err
+		}
+		part.Write(data)
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf, w.FormDataContentType(), nil
+}
+
+// SendBatch 批量发送邮件
+func (s *MailgunSender) SendBatch(ctx context.Context, messages []*Message) error {
+	return sendEach(ctx, s, messages)
+}
+
+// Close 无需关闭
+func (s *MailgunSender) Close() error {
+	return nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
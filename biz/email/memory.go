@@ -0,0 +1,87 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// MemorySender 将邮件保存在内存中，用于测试断言；附件内容会被读出并替换为可重复读取的副本
+type MemorySender struct {
+	Err error // 非空时 Send 返回该错误，用于模拟发送失败
+
+	mu       sync.Mutex
+	messages []*Message
+	closed   bool
+}
+
+// NewMemorySender 创建内存发送器
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+// Send 保存邮件
+func (m *MemorySender) Send(ctx context.Context, message *Message) error {
+	if err := message.Validate(); err != nil {
+		return err
+	}
+	if m.Err != nil {
+		return m.Err
+	}
+	for _, att := range message.Attachments {
+		data, err := readAttachment(att)
+		if err != nil {
+			return err
+		}
+		att.Data = bytes.NewReader(data)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// SendBatch 批量保存邮件
+func (m *MemorySender) SendBatch(ctx context.Context, messages []*Message) error {
+	return sendEach(ctx, m, messages)
+}
+
+// Close 标记为已关闭
+func (m *MemorySender) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
+
+// Messages 返回已发送的邮件
+func (m *MemorySender) Messages() []*Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Message(nil), m.messages...)
+}
+
+// Last 返回最后一封邮件，没有时返回 nil
+func (m *MemorySender) Last() *Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.messages) == 0 {
+		return nil
+	}
+	return m.messages[len(m.messages)-1]
+}
+
+// Closed 是否已调用 Close
+func (m *MemorySender) Closed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.closed
+}
+
+// Reset 清空已发送的邮件
+func (m *MemorySender) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
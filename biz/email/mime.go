@@ -0,0 +1,417 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yi-nology/common/utils/xuuid"
+)
+
+const (
+	// maxLineLength 邮件头折行与 base64 换行的长度（RFC 5322 建议不超过 78）
+	maxLineLength = 76
+)
+
+// Protector 对邮件内容实体签名或加密，如 S/MIME、OpenPGP
+type Protector interface {
+	// Protect 接收内容实体（实体头、空行与实体内容，不含邮件头），返回替换后的实体
+	Protect(entity []byte, message *Message) ([]byte, error)
+}
+
+// BuildMessage 构建 MIME 邮件，protectors 按顺序处理内容实体（如先签名再加密）
+func BuildMessage(message *Message, protectors ...Protector) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := WriteMessage(buf, message, protectors...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteMessage 将邮件以 MIME 格式写入 w，结构为 mixed(附件) → related(内嵌资源) → alternative(纯文本与HTML)，
+// 不需要的层级会省略。文本使用 quoted-printable 编码，附件以 base64 流式编码写出，不会整体读入内存；
+// 指定 protectors 时内容实体需在内存中签名或加密
+func WriteMessage(w io.Writer, message *Message, protectors ...Protector) error {
+	bw := bufio.NewWriter(w)
+	if len(protectors) == 0 {
+		root := &rootPart{w: bw, header: messageHeader(message)}
+		if err := writeEntity(root.create, message); err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeEntity((&rootPart{w: buf}).create, message); err != nil {
+		return err
+	}
+	entity := buf.Bytes()
+	for _, p := range protectors {
+		var err error
+		if entity, err = p.Protect(entity, message); err != nil {
+			return err
+		}
+	}
+	for _, f := range messageHeader(message) {
+		if _, err := io.WriteString(bw, foldHeader(f.name, f.value)); err != nil {
+			return err
+		}
+	}
+	if _, err := bw.Write(entity); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeEntity 写出内容实体
+func writeEntity(create createPart, message *Message) error {
+	var inlines, attachments []*Attachment
+	for _, attachment := range message.Attachments {
+		// 没有 HTML 时内嵌资源无处引用，作为普通附件发送
+		if attachment.IsInline() && message.IsHTML() {
+			inlines = append(inlines, attachment)
+		} else {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return writeMixed(create, message, inlines, attachments)
+}
+
+// createPart 写出实体头并返回实体内容的写入器
+type createPart func(header textproto.MIMEHeader) (io.Writer, error)
+
+// rootPart 顶层实体，邮件头与实体头一起写出
+type rootPart struct {
+	w      io.Writer
+	header []headerField
+}
+
+func (r *rootPart) create(header textproto.MIMEHeader) (io.Writer, error) {
+	fields := r.header
+	for _, key := range sortedKeys(header) {
+		for _, value := range header[key] {
+			fields = append(fields, headerField{key, value})
+		}
+	}
+	for _, f := range fields {
+		if _, err := io.WriteString(r.w, foldHeader(f.name, f.value)); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := io.WriteString(r.w, "\r\n"); err != nil {
+		return nil, err
+	}
+	return r.w, nil
+}
+
+func writeMixed(create createPart, message *Message, inlines, attachments []*Attachment) error {
+	if len(attachments) == 0 {
+		return writeRelated(create, message, inlines)
+	}
+	mw, err := createMultipart(create, "mixed", "")
+	if err != nil {
+		return err
+	}
+	if err := writeRelated(partCreator(mw), message, inlines); err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		if err := writeAttachment(partCreator(mw), attachment, "attachment"); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func writeRelated(create createPart, message *Message, inlines []*Attachment) error {
+	if len(inlines) == 0 {
+		return writeAlternative(create, message)
+	}
+	rootType := "text/html"
+	if message.Text != "" {
+		rootType = "multipart/alternative"
+	}
+	mw, err := createMultipart(create, "related", rootType)
+	if err != nil {
+		return err
+	}
+	if err := writeAlternative(partCreator(mw), message); err != nil {
+		return err
+	}
+	for _, inline := range inlines {
+		if err := writeAttachment(partCreator(mw), inline, "inline"); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func writeAlternative(create createPart, message *Message) error {
+	switch {
+	case message.Text != "" && message.HTML != "":
+		mw, err := createMultipart(create, "alternative", "")
+		if err != nil {
+			return err
+		}
+		if err := writeText(partCreator(mw), "text/plain", message.Text); err != nil {
+			return err
+		}
+		if err := writeText(partCreator(mw), "text/html", message.HTML); err != nil {
+			return err
+		}
+		return mw.Close()
+	case message.IsHTML():
+		return writeText(create, "text/html", message.HTML)
+	default:
+		return writeText(create, "text/plain", message.Text)
+	}
+}
+
+// partCreator 在 mw 中创建子实体，实体头同样折行
+func partCreator(mw *multipart.Writer) createPart {
+	return func(header textproto.MIMEHeader) (io.Writer, error) {
+		folded := textproto.MIMEHeader{}
+		for key, values := range header {
+			for _, value := range values {
+				line := strings.TrimSuffix(foldHeader(key, value), "\r\n")
+				folded[key] = append(folded[key], strings.TrimPrefix(line[len(key)+1:], " "))
+			}
+		}
+		return mw.CreatePart(folded)
+	}
+}
+
+// createMultipart 创建 multipart 实体，related 类型需指定根实体的类型
+func createMultipart(create createPart, subtype, rootType string) (*multipart.Writer, error) {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	params := map[string]string{"boundary": boundary}
+	if rootType != "" {
+		params["type"] = rootType
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType("multipart/"+subtype, params))
+	w, err := create(header)
+	if err != nil {
+		return nil, err
+	}
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return nil, err
+	}
+	return mw, nil
+}
+
+// writeText 写入 quoted-printable 编码的文本实体
+func writeText(create createPart, contentType, content string) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType+"; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	w, err := create(header)
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qp, content); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeAttachment 写入 base64 编码的附件或内嵌资源，disposition 为 attachment 或 inline
+func writeAttachment(create createPart, attachment *Attachment, disposition string) error {
+	if attachment.Data == nil {
+		return fmt.Errorf("%w: %s has no data", ErrInvalidAttachment, attachment.Filename)
+	}
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(attachment.Filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "base64")
+	params := map[string]string{}
+	if attachment.Filename != "" {
+		params["filename"] = filepath.Base(attachment.Filename)
+	}
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, params))
+	if disposition == "inline" {
+		header.Set("Content-ID", "<"+attachment.ContentID+">")
+	}
+
+	w, err := create(header)
+	if err != nil {
+		return err
+	}
+	lw := &lineWrapper{w: w}
+	enc := base64.NewEncoder(base64.StdEncoding, lw)
+	if _, err := io.Copy(enc, attachment.Data); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAttachment, err)
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return lw.end()
+}
+
+// lineWrapper 每 maxLineLength 个字符插入 CRLF
+type lineWrapper struct {
+	w   io.Writer
+	col int
+}
+
+func (l *lineWrapper) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := maxLineLength - l.col
+		if n > len(p) {
+			n = len(p)
+		}
+		if _, err := l.w.Write(p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		l.col += n
+		p = p[n:]
+		if l.col == maxLineLength {
+			if _, err := io.WriteString(l.w, "\r\n"); err != nil {
+				return written, err
+			}
+			l.col = 0
+		}
+	}
+	return written, nil
+}
+
+// end 结束最后一行
+func (l *lineWrapper) end() error {
+	if l.col == 0 {
+		return nil
+	}
+	_, err := io.WriteString(l.w, "\r\n")
+	return err
+}
+
+// reservedHeaders 由构建器生成的邮件头，自定义邮件头中的同名项会被忽略
+var reservedHeaders = map[string]bool{
+	"From": true, "To": true, "Cc": true, "Bcc": true, "Reply-To": true, "Subject": true, "Date": true,
+	"Mime-Version": true, "Content-Type": true, "Content-Transfer-Encoding": true, "X-Priority": true,
+}
+
+type headerField struct {
+	name, value string
+}
+
+// messageHeader 构建邮件头，地址中的显示名称与主题按 RFC 2047 编码，密送不写入邮件头
+func messageHeader(message *Message) []headerField {
+	fields := []headerField{{"From", encodeAddressList([]string{message.From})}}
+	fields = append(fields, headerField{"To", encodeAddressList(message.To)})
+	if len(message.Cc) > 0 {
+		fields = append(fields, headerField{"Cc", encodeAddressList(message.Cc)})
+	}
+	if message.ReplyTo != "" {
+		fields = append(fields, headerField{"Reply-To", encodeAddressList([]string{message.ReplyTo})})
+	}
+	fields = append(fields,
+		headerField{"Subject", mime.QEncoding.Encode("UTF-8", sanitizeHeader(message.Subject))},
+		headerField{"Date", time.Now().Format(time.RFC1123Z)},
+	)
+
+	custom := map[string]string{}
+	for key, value := range message.Headers {
+		key = textproto.CanonicalMIMEHeaderKey(key)
+		if !reservedHeaders[key] {
+			custom[key] = value
+		}
+	}
+	if _, ok := custom["Message-Id"]; !ok {
+		fields = append(fields, headerField{"Message-ID", newMessageID(message.From)})
+	}
+	fields = append(fields, headerField{"MIME-Version", "1.0"})
+
+	// 优先级
+	if message.Priority != 0 && message.Priority != PriorityNormal {
+		fields = append(fields, headerField{"X-Priority", strconv.Itoa(int(message.Priority))})
+	}
+
+	// 自定义邮件头
+	for _, key := range sortedKeys(custom) {
+		fields = append(fields, headerField{key, mime.QEncoding.Encode("UTF-8", sanitizeHeader(custom[key]))})
+	}
+	return fields
+}
+
+// encodeAddressList 编码地址列表，无法解析的地址原样保留
+func encodeAddressList(list []string) string {
+	encoded := make([]string, 0, len(list))
+	for _, addr := range list {
+		addr = sanitizeHeader(addr)
+		if a, err := mail.ParseAddress(addr); err == nil {
+			encoded = append(encoded, a.String())
+		} else {
+			encoded = append(encoded, addr)
+		}
+	}
+	return strings.Join(encoded, ", ")
+}
+
+// newMessageID 生成 Message-ID，域名取自发件人地址
+func newMessageID(from string) string {
+	domain := "localhost"
+	if a, err := mail.ParseAddress(from); err == nil {
+		if i := strings.LastIndex(a.Address, "@"); i >= 0 {
+			domain = a.Address[i+1:]
+		}
+	}
+	return "<" + xuuid.Uuid() + "@" + domain + ">"
+}
+
+// sanitizeHeader 去除换行，防止邮件头注入
+func sanitizeHeader(value string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+}
+
+// foldHeader 在空白处折行，使每行不超过 maxLineLength（无空白的长单词无法折行）
+func foldHeader(name, value string) string {
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteString(":")
+	col := len(name) + 1
+	for _, word := range strings.Split(value, " ") {
+		// 首个单词仅在换行后能放下时才换行，避免产生空行
+		first := col == len(name)+1
+		if col+1+len(word) > maxLineLength && (!first || 1+len(word) <= maxLineLength) {
+			b.WriteString("\r\n")
+			col = 0
+		}
+		b.WriteString(" ")
+		b.WriteString(word)
+		col += 1 + len(word)
+	}
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,154 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parseMultipart 解析 multipart 实体，返回各子实体的头与解码后的内容
+func parseMultipart(t *testing.T, contentType string, body io.Reader) (string, []*multipart.Part, [][]byte) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	assert.NoError(t, err)
+	reader := multipart.NewReader(body, params["boundary"])
+	var parts []*multipart.Part
+	var contents [][]byte
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		var data []byte
+		if part.Header.Get("Content-Transfer-Encoding") == "base64" {
+			data, err = io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		} else {
+			// quoted-printable 由 multipart.Reader 自动解码
+			data, err = io.ReadAll(part)
+		}
+		assert.NoError(t, err)
+		parts = append(parts, part)
+		contents = append(contents, data)
+	}
+	return mediaType, parts, contents
+}
+
+func TestBuildMessageRoundTrip(t *testing.T) {
+	binary := make([]byte, 100*1024)
+	rand.Read(binary)
+	longText := strings.Repeat("这是一段很长的中文内容，用于验证 quoted-printable 软换行。", 20) + "\nend"
+	subject := "月度报告：" + strings.Repeat("数据汇总 ", 10)
+
+	message := &Message{
+		From:    "张三 <zhangsan@example.com>",
+		To:      []string{"李四 <lisi@example.com>", "wangwu@example.com"},
+		Cc:      []string{"\"Doe, John\" <john@example.com>"},
+		Bcc:     []string{"hidden@example.com"},
+		Subject: subject,
+		Text:    longText,
+		HTML:    `<p>报告</p><img src="cid:logo@inline">`,
+		Headers: map[string]string{"X-Campaign": "周报", "Content-Type": "ignored"},
+		Attachments: []*Attachment{
+			{Filename: "数据.bin", Data: bytes.NewReader(binary)},
+			{Filename: "logo.png", ContentType: "image/png", Data: strings.NewReader("png"), ContentID: "logo@inline"},
+		},
+	}
+	raw, err := BuildMessage(message)
+	assert.NoError(t, err)
+	for _, line := range strings.Split(string(raw), "\r\n") {
+		assert.LessOrEqual(t, len(line), 78, line)
+	}
+	assert.NotContains(t, string(raw), "hidden@example.com")
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.NoError(t, err)
+	dec := new(mime.WordDecoder)
+	gotSubject, err := dec.DecodeHeader(msg.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, subject, gotSubject)
+	from, err := msg.Header.AddressList("From")
+	assert.NoError(t, err)
+	assert.Equal(t, []*mail.Address{{Name: "张三", Address: "zhangsan@example.com"}}, from)
+	to, err := msg.Header.AddressList("To")
+	assert.NoError(t, err)
+	assert.Equal(t, "李四", to[0].Name)
+	assert.Equal(t, "wangwu@example.com", to[1].Address)
+	cc, err := msg.Header.AddressList("Cc")
+	assert.NoError(t, err)
+	assert.Equal(t, "Doe, John", cc[0].Name)
+	campaign, _ := dec.DecodeHeader(msg.Header.Get("X-Campaign"))
+	assert.Equal(t, "周报", campaign)
+	assert.NotEmpty(t, msg.Header.Get("Message-ID"))
+	assert.Contains(t, msg.Header.Get("Message-ID"), "@example.com>")
+
+	// mixed → related → alternative
+	mediaType, mixed, contents := parseMultipart(t, msg.Header.Get("Content-Type"), msg.Body)
+	assert.Equal(t, "multipart/mixed", mediaType)
+	assert.Len(t, mixed, 2)
+	assert.Equal(t, binary, contents[1])
+	_, params, _ := mime.ParseMediaType(mixed[1].Header.Get("Content-Disposition"))
+	assert.Equal(t, "数据.bin", params["filename"])
+	assert.Equal(t, "application/octet-stream", mixed[1].Header.Get("Content-Type"))
+
+	mediaType, related, contents := parseMultipart(t, mixed[0].Header.Get("Content-Type"), bytes.NewReader(contents[0]))
+	assert.Equal(t, "multipart/related", mediaType)
+	assert.Equal(t, "<logo@inline>", related[1].Header.Get("Content-Id"))
+	assert.Equal(t, "png", string(contents[1]))
+
+	mediaType, alternative, contents := parseMultipart(t, related[0].Header.Get("Content-Type"), bytes.NewReader(contents[0]))
+	assert.Equal(t, "multipart/alternative", mediaType)
+	assert.Equal(t, "text/plain; charset=UTF-8", alternative[0].Header.Get("Content-Type"))
+	// 文本行尾按 MIME 规范转换为 CRLF
+	assert.Equal(t, strings.ReplaceAll(longText, "\n", "\r\n"), string(contents[0]))
+	assert.Equal(t, message.HTML, string(contents[1]))
+}
+
+func TestBuildMessageSinglePart(t *testing.T) {
+	message := &Message{
+		From: "a@example.com", To: []string{"b@example.com"},
+		Subject: "hi\r\nBcc: injected@example.com", Text: "a=b",
+		Attachments: []*Attachment{{Filename: "x.txt", Data: strings.NewReader("x"), ContentID: "x"}},
+	}
+	raw, err := BuildMessage(message)
+	assert.NoError(t, err)
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.NoError(t, err)
+	assert.Empty(t, msg.Header.Get("Bcc"))
+
+	// 没有 HTML 时内嵌资源作为普通附件
+	mediaType, parts, contents := parseMultipart(t, msg.Header.Get("Content-Type"), msg.Body)
+	assert.Equal(t, "multipart/mixed", mediaType)
+	assert.Equal(t, "a=b", string(contents[0]))
+	assert.Contains(t, string(raw), "Content-Transfer-Encoding: quoted-printable\r\n")
+	assert.True(t, strings.HasPrefix(parts[1].Header.Get("Content-Disposition"), "attachment"))
+	assert.Equal(t, "text/plain; charset=utf-8", parts[1].Header.Get("Content-Type"))
+
+	message.Attachments = nil
+	raw, _ = BuildMessage(message)
+	msg, _ = mail.ReadMessage(bytes.NewReader(raw))
+	assert.Equal(t, "text/plain; charset=UTF-8", msg.Header.Get("Content-Type"))
+	assert.Equal(t, "quoted-printable", msg.Header.Get("Content-Transfer-Encoding"))
+	body, _ := io.ReadAll(msg.Body)
+	assert.Equal(t, "a=3Db", string(body))
+}
+
+func TestFoldHeader(t *testing.T) {
+	folded := foldHeader("To", strings.Repeat("someone@example.com, ", 6)+"last@example.com")
+	lines := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+	assert.Greater(t, len(lines), 1)
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), maxLineLength)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(line, " "))
+		}
+	}
+	assert.Equal(t, "Subject: short\r\n", foldHeader("Subject", "short"))
+}
@@ -0,0 +1,169 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+var (
+	pgpSignatureHeaders = textproto.MIMEHeader{
+		"Content-Type":        {`application/pgp-signature; name="signature.asc"`},
+		"Content-Description": {"OpenPGP digital signature"},
+		"Content-Disposition": {`attachment; filename="signature.asc"`},
+	}
+	pgpEncryptedHeaders = textproto.MIMEHeader{
+		"Content-Type":        {`application/octet-stream; name="encrypted.asc"`},
+		"Content-Description": {"OpenPGP encrypted message"},
+		"Content-Disposition": {`inline; filename="encrypted.asc"`},
+	}
+)
+
+// ParseOpenPGPKeyRing 解析 ASCII armor 或二进制格式的 OpenPGP 公钥或私钥
+func ParseOpenPGPKeyRing(data []byte) (openpgp.EntityList, error) {
+	var keyring openpgp.EntityList
+	var err error
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")) {
+		keyring, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	} else {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return keyring, nil
+}
+
+// OpenPGPSigner OpenPGP 签名，生成 multipart/signed（RFC 3156）
+type OpenPGPSigner struct {
+	entity *openpgp.Entity
+}
+
+// NewOpenPGPSigner 创建OpenPGP签名器，私钥需已解密
+func NewOpenPGPSigner(entity *openpgp.Entity) (*OpenPGPSigner, error) {
+	if entity == nil || entity.PrivateKey == nil {
+		return nil, fmt.Errorf("%w: OpenPGP private key is required", ErrInvalidConfig)
+	}
+	if entity.PrivateKey.Encrypted {
+		return nil, fmt.Errorf("%w: OpenPGP private key must be decrypted", ErrInvalidConfig)
+	}
+	return &OpenPGPSigner{entity: entity}, nil
+}
+
+// Protect 对内容实体签名，返回 multipart/signed 实体
+func (s *OpenPGPSigner) Protect(entity []byte, message *Message) ([]byte, error) {
+	sig := &bytes.Buffer{}
+	if err := openpgp.ArmoredDetachSign(sig, s.entity, bytes.NewReader(entity), nil); err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+	params := map[string]string{"protocol": "application/pgp-signature", "micalg": "pgp-sha256"}
+	return wrapMultipart("signed", params, entity, pgpSignatureHeaders, armoredCRLF(sig.Bytes())), nil
+}
+
+// OpenPGPEncryptor OpenPGP 加密，生成 multipart/encrypted（RFC 3156）
+type OpenPGPEncryptor struct {
+	keyring openpgp.EntityList
+	signer  *openpgp.Entity
+	self    *openpgp.Entity
+}
+
+// NewOpenPGPEncryptor 创建OpenPGP加密器，keyring 中需包含每个收件人的公钥，可以是共享的公钥目录；
+// 每封邮件只加密给该邮件的收件人，以及 SetSelf 指定的发件人公钥
+func NewOpenPGPEncryptor(keyring openpgp.EntityList) (*OpenPGPEncryptor, error) {
+	if len(keyring) == 0 {
+		return nil, fmt.Errorf("%w: OpenPGP keyring is empty", ErrInvalidConfig)
+	}
+	return &OpenPGPEncryptor{keyring: keyring}, nil
+}
+
+// SetSigner 设置签名私钥，加密的同时在密文内签名
+func (e *OpenPGPEncryptor) SetSigner(entity *openpgp.Entity) *OpenPGPEncryptor {
+	e.signer = entity
+	return e
+}
+
+// SetSelf 设置发件人公钥，每封邮件额外加密给发件人，以便在已发送邮件中解密
+func (e *OpenPGPEncryptor) SetSelf(entity *openpgp.Entity) *OpenPGPEncryptor {
+	e.self = entity
+	return e
+}
+
+// Protect 为所有收件人加密内容实体，任一收件人缺少公钥时返回 ErrMissingRecipientKey
+func (e *OpenPGPEncryptor) Protect(entity []byte, message *Message) ([]byte, error) {
+	recipients, err := e.recipients(message)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	aw, err := armor.Encode(buf, "PGP MESSAGE", nil)
+	if err != nil {
+		return nil, err
+	}
+	w, err := openpgp.Encrypt(aw, recipients, e.signer, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt message: %w", err)
+	}
+	if _, err := w.Write(entity); err != nil {
+		return nil, fmt.Errorf("failed to encrypt message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt message: %w", err)
+	}
+	if err := aw.Close(); err != nil {
+		return nil, err
+	}
+
+	// 第一部分为版本声明，第二部分为密文
+	params := map[string]string{"protocol": "application/pgp-encrypted"}
+	version := []byte("Content-Type: application/pgp-encrypted\r\nContent-Description: PGP/MIME version identification\r\n\r\nVersion: 1\r\n")
+	return wrapMultipart("encrypted", params, version, pgpEncryptedHeaders, armoredCRLF(buf.Bytes())), nil
+}
+
+// recipients 按邮箱地址匹配收件人公钥，再加上发件人公钥
+func (e *OpenPGPEncryptor) recipients(message *Message) ([]*openpgp.Entity, error) {
+	now := time.Now()
+	var selected []*openpgp.Entity
+	used := map[*openpgp.Entity]bool{}
+	for _, addr := range recipientAddresses(message) {
+		entity := e.lookup(addr, now)
+		if entity == nil {
+			return nil, fmt.Errorf("%w: no OpenPGP key for %s", ErrMissingRecipientKey, addr)
+		}
+		if !used[entity] {
+			used[entity] = true
+			selected = append(selected, entity)
+		}
+	}
+	if e.self != nil && !used[e.self] {
+		if _, ok := e.self.EncryptionKey(now); !ok {
+			return nil, fmt.Errorf("%w: sender OpenPGP key cannot encrypt", ErrInvalidConfig)
+		}
+		selected = append(selected, e.self)
+	}
+	return selected, nil
+}
+
+func (e *OpenPGPEncryptor) lookup(addr string, now time.Time) *openpgp.Entity {
+	for _, entity := range e.keyring {
+		for _, identity := range entity.Identities {
+			if strings.EqualFold(identity.UserId.Email, addr) {
+				if _, ok := entity.EncryptionKey(now); ok {
+					return entity
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// armoredCRLF 将 armor 输出的换行统一为 CRLF
+func armoredCRLF(data []byte) []byte {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(bytes.TrimRight(data, "\n"), []byte("\n"), []byte("\r\n"))
+}
@@ -0,0 +1,101 @@
+package email
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPGPEntity(t *testing.T, name, email string) *openpgp.Entity {
+	entity, err := openpgp.NewEntity(name, "", email, &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	assert.NoError(t, err)
+	return entity
+}
+
+func TestOpenPGPSign(t *testing.T) {
+	alice := newTestPGPEntity(t, "Finance", "finance@example.com")
+	signer, err := NewOpenPGPSigner(alice)
+	assert.NoError(t, err)
+
+	raw, err := BuildMessage(protectedMessage(), signer)
+	assert.NoError(t, err)
+	content, sigPart, msg := splitSigned(t, raw)
+	assert.Contains(t, msg.Header.Get("Content-Type"), `protocol="application/pgp-signature"`)
+	assert.Contains(t, msg.Header.Get("Content-Type"), "micalg=pgp-sha256")
+
+	sig, _ := io.ReadAll(sigPart)
+	signerEntity, err := openpgp.CheckArmoredDetachedSignature(openpgp.EntityList{alice}, bytes.NewReader(content), bytes.NewReader(sig), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, alice.PrimaryKey.KeyId, signerEntity.PrimaryKey.KeyId)
+
+	_, err = openpgp.CheckArmoredDetachedSignature(openpgp.EntityList{alice}, bytes.NewReader(append(content, 'x')), bytes.NewReader(sig), nil)
+	assert.Error(t, err)
+}
+
+func TestOpenPGPEncrypt(t *testing.T) {
+	alice := newTestPGPEntity(t, "Finance", "finance@example.com")
+	boss := newTestPGPEntity(t, "Boss", "boss@example.com")
+	stranger := newTestPGPEntity(t, "Stranger", "stranger@example.com")
+	signer, _ := NewOpenPGPSigner(alice)
+	// 共享公钥目录中与本邮件无关的公钥不参与加密
+	encryptor, err := NewOpenPGPEncryptor(openpgp.EntityList{boss, stranger})
+	assert.NoError(t, err)
+	encryptor.SetSelf(alice)
+
+	raw, err := BuildMessage(protectedMessage(), signer, encryptor)
+	assert.NoError(t, err)
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.NoError(t, err)
+	mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.Equal(t, "multipart/encrypted", mediaType)
+	assert.Equal(t, "application/pgp-encrypted", params["protocol"])
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	version, _ := mr.NextPart()
+	assert.Equal(t, "application/pgp-encrypted", version.Header.Get("Content-Type"))
+	data, _ := mr.NextPart()
+	block, err := armor.Decode(data)
+	assert.NoError(t, err)
+	md, err := openpgp.ReadMessage(block.Body, openpgp.EntityList{boss}, nil, nil)
+	assert.NoError(t, err)
+	assert.True(t, md.IsEncrypted)
+	keyID := func(e *openpgp.Entity) uint64 {
+		key, _ := e.EncryptionKey(time.Now())
+		return key.PublicKey.KeyId
+	}
+	assert.ElementsMatch(t, []uint64{keyID(boss), keyID(alice)}, md.EncryptedToKeyIds)
+	plain, err := io.ReadAll(md.UnverifiedBody)
+	assert.NoError(t, err)
+
+	// 解密得到签名后的实体，签名可独立校验
+	content, sigPart, _ := splitSigned(t, plain)
+	sig, _ := io.ReadAll(sigPart)
+	_, err = openpgp.CheckArmoredDetachedSignature(openpgp.EntityList{alice}, bytes.NewReader(content), bytes.NewReader(sig), nil)
+	assert.NoError(t, err)
+	inner, err := ParseMessage(bytes.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, "见附件", inner.Text)
+
+	message := protectedMessage()
+	message.Bcc = []string{"auditor@example.com"}
+	_, err = BuildMessage(message, encryptor)
+	assert.ErrorIs(t, err, ErrMissingRecipientKey)
+
+	// 公钥可从 armor 文本加载
+	buf := &bytes.Buffer{}
+	w, _ := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	boss.Serialize(w)
+	w.Close()
+	keyring, err := ParseOpenPGPKeyRing(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, boss.PrimaryKey.KeyId, keyring[0].PrimaryKey.KeyId)
+}
@@ -0,0 +1,124 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"sync"
+	"time"
+)
+
+var ErrPoolClosed = errors.New("SMTP connection pool closed")
+
+// smtpConn 已完成 TLS 与认证的 SMTP 会话
+type smtpConn struct {
+	conn     net.Conn
+	client   *smtp.Client
+	lastUsed time.Time
+}
+
+// close 关闭会话，尽量发送 QUIT
+func (c *smtpConn) close() {
+	c.conn.SetDeadline(time.Now().Add(time.Second))
+	c.client.Quit()
+	c.client.Close()
+}
+
+// connPool SMTP 连接池：最多 size 个会话，空闲会话复用前以 NOOP 检查健康状态
+type connPool struct {
+	size    int
+	idleTTL time.Duration
+	dial    func(ctx context.Context) (*smtpConn, error)
+
+	sem    chan struct{}
+	mu     sync.Mutex
+	idle   []*smtpConn
+	closed bool
+}
+
+func newConnPool(size int, idleTTL time.Duration, dial func(ctx context.Context) (*smtpConn, error)) *connPool {
+	if size <= 0 {
+		size = 1
+	}
+	return &connPool{
+		size:    size,
+		idleTTL: idleTTL,
+		dial:    dial,
+		sem:     make(chan struct{}, size),
+	}
+}
+
+// get 获取会话，池中无可用空闲会话时新建连接
+func (p *connPool) get(ctx context.Context) (*smtpConn, error) {
+	select {
+	case p.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			<-p.sem
+			return nil, ErrPoolClosed
+		}
+		if len(p.idle) == 0 {
+			p.mu.Unlock()
+			break
+		}
+		c := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		p.mu.Unlock()
+
+		if p.idleTTL > 0 && time.Since(c.lastUsed) > p.idleTTL {
+			c.close()
+			continue
+		}
+		c.conn.SetDeadline(time.Now().Add(5 * time.Second))
+		if err := c.client.Noop(); err != nil {
+			c.client.Close()
+			continue
+		}
+		return c, nil
+	}
+
+	c, err := p.dial(ctx)
+	if err != nil {
+		<-p.sem
+		return nil, err
+	}
+	return c, nil
+}
+
+// put 归还会话，reuse 为 false 或连接池已关闭时关闭会话
+func (p *connPool) put(c *smtpConn, reuse bool) {
+	defer func() { <-p.sem }()
+
+	if reuse {
+		c.lastUsed = time.Now()
+		c.conn.SetDeadline(time.Time{})
+		p.mu.Lock()
+		if !p.closed {
+			p.idle = append(p.idle, c)
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+	}
+	c.close()
+}
+
+// close 关闭连接池及所有空闲会话，使用中的会话在归还时关闭
+func (p *connPool) close() {
+	p.mu.Lock()
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, c := range idle {
+		c.close()
+	}
+}
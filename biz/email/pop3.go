@@ -0,0 +1,201 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// POP3Client POP3 收信客户端；删除操作在 Close（QUIT）时生效，非并发安全
+type POP3Client struct {
+	config *ReceiverConfig
+	conn   net.Conn
+	text   *textproto.Conn
+}
+
+// POP3Entry 邮件列表项
+type POP3Entry struct {
+	Number int    // 会话内的邮件序号
+	Size   int    // 邮件大小（字节）
+	UIDL   string // 唯一标识，服务器不支持 UIDL 时为空
+}
+
+// NewPOP3Client 创建POP3客户端，需调用 Connect 建立连接
+func NewPOP3Client(config *ReceiverConfig) (*POP3Client, error) {
+	if config == nil {
+		config = DefaultReceiverConfig()
+	}
+	if err := config.validate(995); err != nil {
+		return nil, err
+	}
+	return &POP3Client{config: config}, nil
+}
+
+// Connect 建立连接，完成 TLS 与登录
+func (c *POP3Client) Connect(ctx context.Context) error {
+	mode := c.config.tlsMode(995)
+	conn, err := c.config.dial(ctx, mode == TLSImplicit)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+	}
+	c.setConn(conn)
+	c.setDeadline(ctx)
+
+	if _, err := c.readStatus(); err != nil {
+		c.conn.Close()
+		return err
+	}
+
+	// STLS 升级（RFC 2595）
+	if mode == TLSStartTLS || mode == TLSStartTLSOpportunistic {
+		if _, err := c.cmd(ctx, "STLS"); err == nil {
+			tlsConn := tls.Client(c.conn, c.config.tlsConfig())
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				c.conn.Close()
+				return fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+			}
+			c.setConn(tlsConn)
+		} else if mode == TLSStartTLS {
+			c.conn.Close()
+			return fmt.Errorf("%w: server does not support STLS", ErrReceiveFailed)
+		}
+	}
+
+	if _, err := c.cmd(ctx, "USER %s", c.config.Username); err != nil {
+		c.conn.Close()
+		return fmt.Errorf("%w: %v", ErrAuthFailed, err)
+	}
+	if _, err := c.cmd(ctx, "PASS %s", c.config.Password); err != nil {
+		c.conn.Close()
+		return fmt.Errorf("%w: %v", ErrAuthFailed, err)
+	}
+	return nil
+}
+
+// Stat 返回邮件数量与总大小
+func (c *POP3Client) Stat(ctx context.Context) (count, size int, err error) {
+	line, err := c.cmd(ctx, "STAT")
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscanf(line, "%d %d", &count, &size); err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid STAT response %q", ErrReceiveFailed, line)
+	}
+	return count, size, nil
+}
+
+// List 返回邮件列表，服务器支持时附带 UIDL
+func (c *POP3Client) List(ctx context.Context) ([]*POP3Entry, error) {
+	lines, err := c.multiline(ctx, "LIST")
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*POP3Entry, 0, len(lines))
+	index := map[int]*POP3Entry{}
+	for _, line := range lines {
+		entry := &POP3Entry{}
+		if _, err := fmt.Sscanf(line, "%d %d", &entry.Number, &entry.Size); err != nil {
+			return nil, fmt.Errorf("%w: invalid LIST line %q", ErrReceiveFailed, line)
+		}
+		entries = append(entries, entry)
+		index[entry.Number] = entry
+	}
+
+	// UIDL 为可选扩展
+	if lines, err := c.multiline(ctx, "UIDL"); err == nil {
+		for _, line := range lines {
+			number, uidl, _ := strings.Cut(line, " ")
+			if n, err := strconv.Atoi(number); err == nil && index[n] != nil {
+				index[n].UIDL = uidl
+			}
+		}
+	}
+	return entries, nil
+}
+
+// Retrieve 获取并解析邮件
+func (c *POP3Client) Retrieve(ctx context.Context, number int) (*ReceivedMessage, error) {
+	if _, err := c.cmd(ctx, "RETR %d", number); err != nil {
+		return nil, err
+	}
+	msg, err := ParseMessage(&sizeLimitReader{r: c.text.DotReader(), n: c.config.maxMessageSize()})
+	if err != nil {
+		return nil, err
+	}
+	if line, err := c.cmd(ctx, "UIDL %d", number); err == nil {
+		_, msg.UIDL, _ = strings.Cut(line, " ")
+	}
+	return msg, nil
+}
+
+// Delete 标记删除邮件，Close 时生效
+func (c *POP3Client) Delete(ctx context.Context, number int) error {
+	_, err := c.cmd(ctx, "DELE %d", number)
+	return err
+}
+
+// Close 发送 QUIT 提交删除并关闭连接
+func (c *POP3Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	_, err := c.cmd(context.Background(), "QUIT")
+	c.text.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *POP3Client) setConn(conn net.Conn) {
+	c.conn = conn
+	c.text = textproto.NewConn(conn)
+}
+
+// setDeadline 按 CommandTimeout 与 ctx 设置读写截止时间
+func (c *POP3Client) setDeadline(ctx context.Context) {
+	deadline := receiverDeadline(c.config.CommandTimeout)
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	c.conn.SetDeadline(deadline)
+}
+
+// cmd 发送命令并返回 +OK 之后的内容
+func (c *POP3Client) cmd(ctx context.Context, format string, args ...any) (string, error) {
+	if c.conn == nil {
+		return "", fmt.Errorf("%w: not connected", ErrReceiveFailed)
+	}
+	c.setDeadline(ctx)
+	if err := c.text.PrintfLine(format, args...); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+	}
+	return c.readStatus()
+}
+
+// multiline 发送命令并读取以 "." 结束的多行响应
+func (c *POP3Client) multiline(ctx context.Context, format string, args ...any) ([]string, error) {
+	if _, err := c.cmd(ctx, format, args...); err != nil {
+		return nil, err
+	}
+	lines, err := c.text.ReadDotLines()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+	}
+	return lines, nil
+}
+
+// readStatus 读取状态行，-ERR 返回错误
+func (c *POP3Client) readStatus() (string, error) {
+	line, err := c.text.ReadLine()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrReceiveFailed, err)
+	}
+	if status, rest, _ := strings.Cut(line, " "); status == "+OK" {
+		return rest, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrReceiveFailed, line)
+}
@@ -0,0 +1,134 @@
+package email
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// servePOP3 进程内 POP3 服务器，处理一个会话，返回 QUIT 时被删除的邮件序号
+func servePOP3(t *testing.T, messages []string) (*ReceiverConfig, <-chan []int) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	deleted := make(chan []int, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		var dele []int
+		text.PrintfLine("+OK POP3 ready")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd, arg, _ := strings.Cut(line, " ")
+			n, _ := strconv.Atoi(arg)
+			switch cmd {
+			case "USER":
+				text.PrintfLine("+OK")
+			case "PASS":
+				if arg != "secret" {
+					text.PrintfLine("-ERR invalid password")
+					continue
+				}
+				text.PrintfLine("+OK logged in")
+			case "STAT":
+				text.PrintfLine("+OK %d %d", len(messages), len(messages[0])+len(messages[1]))
+			case "LIST":
+				text.PrintfLine("+OK")
+				for i, m := range messages {
+					text.PrintfLine("%d %d", i+1, len(m))
+				}
+				text.PrintfLine(".")
+			case "UIDL":
+				if arg != "" {
+					text.PrintfLine("+OK %d uid-%d", n, n)
+					continue
+				}
+				text.PrintfLine("+OK")
+				for i := range messages {
+					text.PrintfLine("%d uid-%d", i+1, i+1)
+				}
+				text.PrintfLine(".")
+			case "RETR":
+				text.PrintfLine("+OK")
+				w := text.DotWriter()
+				w.Write([]byte(messages[n-1]))
+				w.Close()
+			case "DELE":
+				dele = append(dele, n)
+				text.PrintfLine("+OK")
+			case "QUIT":
+				deleted <- dele
+				text.PrintfLine("+OK bye")
+				return
+			default:
+				text.PrintfLine("-ERR unknown command")
+			}
+		}
+	}()
+
+	config := DefaultReceiverConfig()
+	config.Host = "127.0.0.1"
+	config.Port = ln.Addr().(*net.TCPAddr).Port
+	config.TLSMode = TLSNone
+	config.Username = "user"
+	config.Password = "secret"
+	config.CommandTimeout = time.Second * 5
+	return config, deleted
+}
+
+func TestPOP3Client(t *testing.T) {
+	// 以 "." 开头的行需经过点填充传输
+	messages := []string{rawTestMessage(t, "first"), dsnMessage + ".\r\n"}
+	config, deleted := servePOP3(t, messages)
+	client, err := NewPOP3Client(config)
+	assert.NoError(t, err)
+	ctx := context.Background()
+	assert.NoError(t, client.Connect(ctx))
+
+	count, _, err := client.Stat(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	entries, err := client.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &POP3Entry{Number: 2, Size: len(messages[1]), UIDL: "uid-2"}, entries[1])
+
+	msg, err := client.Retrieve(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", msg.Subject)
+	assert.Equal(t, "uid-1", msg.UIDL)
+
+	msg, err = client.Retrieve(ctx, 2)
+	assert.NoError(t, err)
+	assert.True(t, msg.IsBounce())
+	assert.Equal(t, "nobody@example.org", msg.Bounces[0].Recipient)
+
+	assert.NoError(t, client.Delete(ctx, 2))
+	assert.NoError(t, client.Close())
+	assert.Equal(t, []int{2}, <-deleted)
+}
+
+func TestPOP3MaxMessageSize(t *testing.T) {
+	config, _ := servePOP3(t, []string{rawTestMessage(t, "large")})
+	config.MaxMessageSize = 64
+	client, _ := NewPOP3Client(config)
+	ctx := context.Background()
+	assert.NoError(t, client.Connect(ctx))
+	defer client.conn.Close()
+	_, err := client.Retrieve(ctx, 1)
+	assert.ErrorIs(t, err, ErrReceiveFailed)
+}
@@ -0,0 +1,56 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+)
+
+// wrapMultipart 以 multipart/<subtype> 包装两个子实体：first 为完整实体原样写出（签名时即被签名的内容），
+// second 由实体头与内容组成
+func wrapMultipart(subtype string, params map[string]string, first []byte, header textproto.MIMEHeader, body []byte) []byte {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	params["boundary"] = boundary
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(foldHeader("Content-Type", mime.FormatMediaType("multipart/"+subtype, params)))
+	buf.WriteString("\r\n--" + boundary + "\r\n")
+	buf.Write(first)
+	// 分隔线前的 CRLF 属于分隔线，不属于被签名的内容（RFC 1847）
+	buf.WriteString("\r\n--" + boundary + "\r\n")
+	for _, key := range sortedKeys(header) {
+		for _, value := range header[key] {
+			buf.WriteString(foldHeader(key, value))
+		}
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body)
+	buf.WriteString("\r\n--" + boundary + "--\r\n")
+	return buf.Bytes()
+}
+
+// encodeBase64Lines base64 编码并按 maxLineLength 换行
+func encodeBase64Lines(data []byte) []byte {
+	buf := &bytes.Buffer{}
+	lw := &lineWrapper{w: buf}
+	enc := base64.NewEncoder(base64.StdEncoding, lw)
+	enc.Write(data)
+	enc.Close()
+	lw.end()
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\r\n"))
+}
+
+// recipientAddresses 返回收件人、抄送与密送的邮箱地址（小写）
+func recipientAddresses(message *Message) []string {
+	var addrs []string
+	for _, list := range [][]string{message.To, message.Cc, message.Bcc} {
+		for _, addr := range list {
+			addrs = append(addrs, strings.ToLower(parseAddress(addr).Address))
+		}
+	}
+	return addrs
+}
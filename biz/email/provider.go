@@ -0,0 +1,112 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strconv"
+)
+
+// Provider 发送渠道
+type Provider string
+
+const (
+	ProviderSMTP     Provider = "smtp"
+	ProviderSendGrid Provider = "sendgrid"
+	ProviderMailgun  Provider = "mailgun"
+	ProviderAliyun   Provider = "aliyun"
+	ProviderFile     Provider = "file"
+	ProviderMemory   Provider = "memory"
+)
+
+var ErrUnsupportedFeature = errors.New("email feature not supported by provider")
+
+// NewSender 按 Config.Provider 创建发送器，切换渠道只需修改配置
+func NewSender(config *Config) (Sender, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	switch config.Provider {
+	case "", ProviderSMTP:
+		return NewSMTPSender(config)
+	case ProviderSendGrid:
+		return NewSendGridSender(config)
+	case ProviderMailgun:
+		return NewMailgunSender(config)
+	case ProviderAliyun:
+		return NewAliyunSender(config)
+	case ProviderFile:
+		return NewFileSender(config)
+	case ProviderMemory:
+		return NewMemorySender(), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown provider %q", ErrInvalidConfig, config.Provider)
+	}
+}
+
+// prepareMessage 填充默认发件人并校验邮件
+func prepareMessage(config *Config, message *Message) error {
+	if message.From == "" && config.FromEmail != "" {
+		message.From = (&mail.Address{Name: config.FromName, Address: config.FromEmail}).String()
+	}
+	return message.Validate()
+}
+
+// sendEach 逐封发送，部分失败时返回 *BatchError
+func sendEach(ctx context.Context, sender Sender, messages []*Message) error {
+	errs := make([]error, len(messages))
+	for i, msg := range messages {
+		errs[i] = sender.Send(ctx, msg)
+	}
+	return newBatchError(errs)
+}
+
+// parseAddress 解析 "名称 <邮箱>" 格式的地址，无法解析时整体视为邮箱
+func parseAddress(addr string) *mail.Address {
+	if a, err := mail.ParseAddress(addr); err == nil {
+		return a
+	}
+	return &mail.Address{Address: addr}
+}
+
+// readAttachment 读取附件内容
+func readAttachment(a *Attachment) ([]byte, error) {
+	if a.Data == nil {
+		return nil, fmt.Errorf("%w: %s has no data", ErrInvalidAttachment, a.Filename)
+	}
+	data, err := io.ReadAll(a.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAttachment, err)
+	}
+	return data, nil
+}
+
+// messageHeaders 合并自定义邮件头与优先级头
+func messageHeaders(message *Message) map[string]string {
+	headers := map[string]string{}
+	for k, v := range message.Headers {
+		headers[k] = v
+	}
+	if message.Priority != 0 && message.Priority != PriorityNormal {
+		headers["X-Priority"] = strconv.Itoa(int(message.Priority))
+	}
+	return headers
+}
+
+// doRequest 发送 HTTP 请求，非 2xx 响应返回 ErrSendFailed
+func doRequest(client *http.Client, req *http.Request, provider Provider) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrSendFailed, provider, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return body, fmt.Errorf("%w: %s status %d: %s", ErrSendFailed, provider, resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return body, nil
+}
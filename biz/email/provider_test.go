@@ -0,0 +1,211 @@
+package email
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func providerMessage() *Message {
+	return &Message{
+		From:     "Sender <sender@example.com>",
+		To:       []string{"Alice <a@example.com>", "b@example.com"},
+		ReplyTo:  "reply@example.com",
+		Subject:  "Report",
+		Text:     "plain",
+		HTML:     `<p>html <img src="cid:logo"></p>`,
+		Headers:  map[string]string{"X-Campaign": "weekly"},
+		Priority: PriorityHigh,
+		Attachments: []*Attachment{
+			{Filename: "report.csv", ContentType: "text/csv", Data: strings.NewReader("a,b")},
+			{Filename: "logo.png", ContentType: "image/png", Data: strings.NewReader("png"), ContentID: "logo"},
+		},
+	}
+}
+
+func TestNewSender(t *testing.T) {
+	config := DefaultConfig()
+	config.Provider = ProviderSendGrid
+	config.APIKey = "key"
+	sender, err := NewSender(config)
+	assert.NoError(t, err)
+	assert.IsType(t, &SendGridSender{}, sender)
+
+	config.Provider = ProviderMailgun
+	_, err = NewSender(config)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	config.APIDomain = "mg.example.com"
+	sender, err = NewSender(config)
+	assert.NoError(t, err)
+	assert.IsType(t, &MailgunSender{}, sender)
+
+	config.Provider = ProviderMemory
+	sender, _ = NewSender(config)
+	assert.IsType(t, &MemorySender{}, sender)
+
+	config.Provider = "pigeon"
+	_, err = NewSender(config)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestMemorySender(t *testing.T) {
+	sender := NewMemorySender()
+	ctx := context.Background()
+	assert.NoError(t, sender.Send(ctx, providerMessage()))
+	assert.ErrorIs(t, sender.Send(ctx, &Message{From: "a@example.com"}), ErrNoRecipients)
+
+	last := sender.Last()
+	assert.Equal(t, "Report", last.Subject)
+	for i := 0; i < 2; i++ {
+		data, _ := io.ReadAll(last.Attachments[0].Data)
+		assert.Equal(t, "a,b", string(data))
+		last.Attachments[0].Data.(io.Seeker).Seek(0, io.SeekStart)
+	}
+
+	sender.Err = ErrSendFailed
+	err := sender.SendBatch(ctx, []*Message{providerMessage()})
+	assert.ErrorIs(t, err, ErrSendFailed)
+	assert.Len(t, sender.Messages(), 1)
+	sender.Reset()
+	assert.Nil(t, sender.Last())
+}
+
+func TestFileSender(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultConfig()
+	config.OutputDir = dir
+	sender, err := NewFileSender(config)
+	assert.NoError(t, err)
+
+	path, err := sender.Write(providerMessage())
+	assert.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(path))
+	assert.Equal(t, ".eml", filepath.Ext(path))
+
+	f, _ := os.Open(path)
+	defer f.Close()
+	msg, err := mail.ReadMessage(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "Report", msg.Header.Get("Subject"))
+	assert.Equal(t, "weekly", msg.Header.Get("X-Campaign"))
+	assert.True(t, strings.HasPrefix(msg.Header.Get("Content-Type"), "multipart/mixed"))
+}
+
+func TestSendGridSender(t *testing.T) {
+	var got sendGridMail
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/mail/send", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		got = sendGridMail{}
+		json.NewDecoder(r.Body).Decode(&got)
+		if got.Subject == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"message":"invalid"}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig()
+	config.APIKey = "key"
+	config.BaseURL = srv.URL
+	sender, err := NewSendGridSender(config)
+	assert.NoError(t, err)
+
+	assert.NoError(t, sender.Send(context.Background(), providerMessage()))
+	assert.Equal(t, []sendGridAddress{{Email: "a@example.com", Name: "Alice"}, {Email: "b@example.com"}}, got.Personalizations[0].To)
+	assert.Equal(t, sendGridAddress{Email: "sender@example.com", Name: "Sender"}, got.From)
+	assert.Equal(t, "reply@example.com", got.ReplyTo.Email)
+	assert.Equal(t, "text/plain", got.Content[0].Type)
+	assert.Equal(t, "text/html", got.Content[1].Type)
+	assert.Equal(t, map[string]string{"X-Campaign": "weekly", "X-Priority": "5"}, got.Headers)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("a,b")), got.Attachments[0].Content)
+	assert.Equal(t, "attachment", got.Attachments[0].Disposition)
+	assert.Equal(t, "inline", got.Attachments[1].Disposition)
+	assert.Equal(t, "logo", got.Attachments[1].ContentID)
+
+	msg := providerMessage()
+	msg.Subject = "bad"
+	err = sender.Send(context.Background(), msg)
+	assert.ErrorIs(t, err, ErrSendFailed)
+	assert.Contains(t, err.Error(), "status 400")
+}
+
+func TestMailgunSender(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/mg.example.com/messages", r.URL.Path)
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "api", user)
+		assert.Equal(t, "key", pass)
+
+		assert.NoError(t, r.ParseMultipartForm(1<<20))
+		form := r.MultipartForm
+		assert.Equal(t, []string{"Sender <sender@example.com>"}, form.Value["from"])
+		assert.Equal(t, []string{"Alice <a@example.com>", "b@example.com"}, form.Value["to"])
+		assert.Equal(t, []string{"plain"}, form.Value["text"])
+		assert.Equal(t, []string{"reply@example.com"}, form.Value["h:Reply-To"])
+		assert.Equal(t, []string{"5"}, form.Value["h:X-Priority"])
+		assert.Equal(t, []string{"weekly"}, form.Value["h:X-Campaign"])
+		assert.Equal(t, "report.csv", form.File["attachment"][0].Filename)
+		assert.Equal(t, "logo", form.File["inline"][0].Filename)
+		w.Write([]byte(`{"id":"<1@mg.example.com>","message":"Queued. Thank you."}`))
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig()
+	config.APIKey = "key"
+	config.APIDomain = "mg.example.com"
+	config.BaseURL = srv.URL
+	sender, err := NewMailgunSender(config)
+	assert.NoError(t, err)
+	assert.NoError(t, sender.Send(context.Background(), providerMessage()))
+}
+
+func TestAliyunSender(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		form := r.PostForm
+		assert.Equal(t, aliyunSignature(http.MethodPost, form, "secret"), form.Get("Signature"))
+		assert.Equal(t, "SingleSendMail", form.Get("Action"))
+		assert.Equal(t, "id", form.Get("AccessKeyId"))
+		assert.Equal(t, "sender@example.com", form.Get("AccountName"))
+		assert.Equal(t, "Sender", form.Get("FromAlias"))
+		assert.Equal(t, "a@example.com,b@example.com", form.Get("ToAddress"))
+		assert.Equal(t, "reply@example.com", form.Get("ReplyAddress"))
+		assert.JSONEq(t, `{"X-Campaign":"weekly","X-Priority":"5"}`, form.Get("Headers"))
+		if form.Get("Subject") == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"Code":"InvalidMailAddress.NotFound","Message":"not found"}`))
+			return
+		}
+		w.Write([]byte(`{"EnvId":"1","RequestId":"2"}`))
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig()
+	config.APIKey = "id"
+	config.APISecret = "secret"
+	config.BaseURL = srv.URL
+	sender, err := NewAliyunSender(config)
+	assert.NoError(t, err)
+
+	msg := providerMessage()
+	assert.ErrorIs(t, sender.Send(context.Background(), msg), ErrUnsupportedFeature)
+	msg.Attachments = nil
+	assert.NoError(t, sender.Send(context.Background(), msg))
+	msg.Subject = "bad"
+	err = sender.Send(context.Background(), msg)
+	assert.ErrorIs(t, err, ErrSendFailed)
+	assert.Contains(t, err.Error(), "InvalidMailAddress.NotFound")
+}
@@ -0,0 +1,377 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// ReceiverConfig 收信配置，IMAP 与 POP3 共用
+type ReceiverConfig struct {
+	Host     string // 服务器地址
+	Port     int    // 端口，为 0 时 IMAP 使用 993，POP3 使用 995
+	Username string // 用户名
+	Password string // 密码
+
+	TokenSource func(ctx context.Context) (string, error) // IMAP XOAUTH2 访问令牌来源，非空时代替密码登录
+
+	// TLS配置
+	TLSMode         TLSMode // TLS 模式，为空时 993/995 端口使用隐式 TLS，其余端口按需 STARTTLS
+	InsecureSkipTLS bool    // 是否跳过TLS证书验证
+
+	Mailbox        string        // IMAP 邮箱，默认 INBOX
+	ConnectTimeout time.Duration // 连接超时
+	CommandTimeout time.Duration // 单条命令超时，IDLE 等待不受此限制
+	IdleTimeout    time.Duration // IDLE 重新发起周期，RFC 2177 建议不超过 29 分钟
+	PollInterval   time.Duration // 服务器不支持 IDLE 时的轮询间隔
+
+	MaxMessageSize int64 // 单封邮件（IMAP 文字量）的最大字节数，超过时返回 ErrReceiveFailed，为 0 时使用 50MB
+}
+
+const defaultMaxMessageSize = 50 << 20
+
+// DefaultReceiverConfig 返回默认收信配置
+func DefaultReceiverConfig() *ReceiverConfig {
+	return &ReceiverConfig{
+		Mailbox:        "INBOX",
+		ConnectTimeout: time.Second * 10,
+		CommandTimeout: time.Minute,
+		IdleTimeout:    time.Minute * 25,
+		PollInterval:   time.Minute,
+		MaxMessageSize: defaultMaxMessageSize,
+	}
+}
+
+// maxMessageSize 返回单封邮件的最大字节数
+func (c *ReceiverConfig) maxMessageSize() int64 {
+	if c.MaxMessageSize > 0 {
+		return c.MaxMessageSize
+	}
+	return defaultMaxMessageSize
+}
+
+// sizeLimitReader 读取超过 n 字节时返回 ErrReceiveFailed，而不是像 io.LimitReader 一样静默截断
+type sizeLimitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, fmt.Errorf("%w: message exceeds MaxMessageSize", ErrReceiveFailed)
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, fmt.Errorf("%w: message exceeds MaxMessageSize", ErrReceiveFailed)
+	}
+	return n, err
+}
+
+// validate 验证配置并补全端口
+func (c *ReceiverConfig) validate(implicitPort int) error {
+	if c.Host == "" {
+		return fmt.Errorf("%w: host is required", ErrInvalidConfig)
+	}
+	if c.Port == 0 {
+		c.Port = implicitPort
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("%w: invalid port", ErrInvalidConfig)
+	}
+	switch c.TLSMode {
+	case "", TLSNone, TLSStartTLS, TLSStartTLSOpportunistic, TLSImplicit:
+	default:
+		return fmt.Errorf("%w: invalid TLS mode %q", ErrInvalidConfig, c.TLSMode)
+	}
+	return nil
+}
+
+// tlsMode 返回实际使用的 TLS 模式
+func (c *ReceiverConfig) tlsMode(implicitPort int) TLSMode {
+	switch {
+	case c.TLSMode != "":
+		return c.TLSMode
+	case c.Port == implicitPort:
+		return TLSImplicit
+	default:
+		return TLSStartTLSOpportunistic
+	}
+}
+
+func (c *ReceiverConfig) tlsConfig() *tls.Config {
+	return &tls.Config{
+		ServerName:         c.Host,
+		InsecureSkipVerify: c.InsecureSkipTLS,
+	}
+}
+
+// dial 创建连接，implicit 为 true 时直接建立 TLS 连接
+func (c *ReceiverConfig) dial(ctx context.Context, implicit bool) (net.Conn, error) {
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	dialer := &net.Dialer{Timeout: c.ConnectTimeout}
+	if implicit {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: c.tlsConfig()}
+		return tlsDialer.DialContext(ctx, "tcp", addr)
+	}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// ReceivedMessage 收到的邮件，字段与 Message 对应，地址与主题已按 RFC 2047 解码
+type ReceivedMessage struct {
+	UID  uint32 // IMAP UID
+	UIDL string // POP3 唯一标识
+
+	From    string
+	To      []string
+	Cc      []string
+	ReplyTo string
+
+	Subject string
+	Text    string
+	HTML    string
+
+	Attachments []*Attachment
+
+	// 邮件头，同名头只保留第一个
+	Headers map[string]string
+
+	MessageID  string    // 不含尖括号
+	InReplyTo  string    // 不含尖括号
+	References []string  // 不含尖括号
+	Date       time.Time // 发送时间，无法解析时为零值
+	Flags      []string  // IMAP 标记，如 \Seen
+
+	// 退信信息，仅 DSN（multipart/report）邮件非空
+	Bounces []*Bounce
+}
+
+// IsBounce 是否为退信
+func (m *ReceivedMessage) IsBounce() bool {
+	return len(m.Bounces) > 0
+}
+
+// ParseMessage 解析 RFC 5322 邮件，解码传输编码与字符集，DSN 退信解析到 Bounces
+func ParseMessage(r io.Reader) (*ReceivedMessage, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	m := &ReceivedMessage{Headers: map[string]string{}}
+	for key, values := range msg.Header {
+		m.Headers[key] = decodeHeader(values[0])
+	}
+	m.From = decodeAddress(msg.Header.Get("From"))
+	m.ReplyTo = decodeAddress(msg.Header.Get("Reply-To"))
+	m.To = decodeAddressList(msg.Header, "To")
+	m.Cc = decodeAddressList(msg.Header, "Cc")
+	m.Subject = m.Headers["Subject"]
+	m.MessageID = trimAngle(msg.Header.Get("Message-Id"))
+	m.InReplyTo = trimAngle(msg.Header.Get("In-Reply-To"))
+	for _, ref := range strings.Fields(msg.Header.Get("References")) {
+		m.References = append(m.References, trimAngle(ref))
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		m.Date = date
+	}
+
+	p := &messageParser{message: m}
+	if err := p.parsePart(textproto.MIMEHeader(msg.Header), msg.Body, false); err != nil {
+		return nil, err
+	}
+	for _, bounce := range m.Bounces {
+		bounce.OriginalMessageID = p.originalMessageID
+	}
+	return m, nil
+}
+
+// messageParser 递归解析 MIME 实体
+type messageParser struct {
+	message           *ReceivedMessage
+	originalMessageID string
+}
+
+func (p *messageParser) parsePart(header textproto.MIMEHeader, body io.Reader, report bool) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	body = transferDecoder(header.Get("Content-Transfer-Encoding"), body)
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		isReport := mediaType == "multipart/report" && strings.EqualFold(params["report-type"], "delivery-status")
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to parse multipart: %w", err)
+			}
+			if err := p.parsePart(part.Header, part, isReport); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read part: %w", err)
+	}
+
+	switch {
+	case report && (mediaType == "message/delivery-status" || mediaType == "message/global-delivery-status"):
+		bounces, err := ParseDeliveryStatus(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		p.message.Bounces = append(p.message.Bounces, bounces...)
+		return nil
+	case report && (mediaType == "message/rfc822" || mediaType == "text/rfc822-headers"):
+		// 原始邮件或其邮件头，用于关联发出的邮件
+		if original, err := mail.ReadMessage(io.MultiReader(bytes.NewReader(data), strings.NewReader("\r\n\r\n"))); err == nil {
+			p.originalMessageID = trimAngle(original.Header.Get("Message-Id"))
+		}
+		return nil
+	}
+
+	disposition, dparams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dparams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	isText := mediaType == "text/plain" || mediaType == "text/html"
+	if isText && disposition != "attachment" && filename == "" {
+		text := decodeCharset(params["charset"], data)
+		if mediaType == "text/html" {
+			p.message.HTML += text
+		} else {
+			p.message.Text += text
+		}
+		return nil
+	}
+
+	p.message.Attachments = append(p.message.Attachments, &Attachment{
+		Filename:    decodeHeader(filename),
+		ContentType: mediaType,
+		Data:        bytes.NewReader(data),
+		ContentID:   trimAngle(header.Get("Content-Id")),
+	})
+	return nil
+}
+
+// transferDecoder 按 Content-Transfer-Encoding 解码，multipart.Reader 已自动解码 quoted-printable
+func transferDecoder(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// decodeCharset 将文本转换为 UTF-8，未知字符集原样返回
+func decodeCharset(charset string, data []byte) string {
+	charset = strings.ToLower(charset)
+	if charset == "" || charset == "utf-8" || charset == "us-ascii" {
+		return string(data)
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return string(data)
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+// headerDecoder 解码 RFC 2047 编码字，支持 GBK 等非 UTF-8 字符集
+var headerDecoder = &mime.WordDecoder{
+	CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+		enc, err := htmlindex.Get(charset)
+		if err != nil {
+			return nil, err
+		}
+		return enc.NewDecoder().Reader(input), nil
+	},
+}
+
+func decodeHeader(value string) string {
+	decoded, err := headerDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// decodeAddress 解码单个地址，无法解析时返回解码后的原文
+func decodeAddress(value string) string {
+	if value == "" {
+		return ""
+	}
+	parser := &mail.AddressParser{WordDecoder: headerDecoder}
+	if a, err := parser.Parse(value); err == nil {
+		return formatReceivedAddress(a)
+	}
+	return decodeHeader(value)
+}
+
+func decodeAddressList(header mail.Header, key string) []string {
+	value := header.Get(key)
+	if value == "" {
+		return nil
+	}
+	parser := &mail.AddressParser{WordDecoder: headerDecoder}
+	list, err := parser.ParseList(value)
+	if err != nil {
+		return []string{decodeHeader(value)}
+	}
+	addrs := make([]string, 0, len(list))
+	for _, a := range list {
+		addrs = append(addrs, formatReceivedAddress(a))
+	}
+	return addrs
+}
+
+// formatReceivedAddress 格式化为 "名称 <邮箱>"，与 Message 中的写法一致
+func formatReceivedAddress(a *mail.Address) string {
+	if a.Name == "" {
+		return a.Address
+	}
+	return fmt.Sprintf("%s <%s>", a.Name, a.Address)
+}
+
+func trimAngle(value string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(value), "<"), ">")
+}
+
+// receiverDeadline 返回命令超时对应的截止时间
+func receiverDeadline(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(timeout)
+}
@@ -0,0 +1,114 @@
+package email
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMessage(t *testing.T) {
+	raw, err := BuildMessage(&Message{
+		From:    "张三 <zhangsan@example.com>",
+		To:      []string{"李四 <lisi@example.com>", "b@example.com"},
+		Cc:      []string{"c@example.com"},
+		ReplyTo: "reply@example.com",
+		Subject: "月度报告",
+		Text:    "第一行\n第二行",
+		HTML:    `<p>报告</p><img src="cid:logo">`,
+		Headers: map[string]string{"In-Reply-To": "<orig@example.com>", "References": "<a@example.com> <orig@example.com>"},
+		Attachments: []*Attachment{
+			{Filename: "数据.csv", ContentType: "text/csv", Data: strings.NewReader("a,b")},
+			{Filename: "logo.png", ContentType: "image/png", Data: strings.NewReader("png"), ContentID: "logo"},
+		},
+	})
+	assert.NoError(t, err)
+
+	msg, err := ParseMessage(strings.NewReader(string(raw)))
+	assert.NoError(t, err)
+	assert.Equal(t, "张三 <zhangsan@example.com>", msg.From)
+	assert.Equal(t, []string{"李四 <lisi@example.com>", "b@example.com"}, msg.To)
+	assert.Equal(t, []string{"c@example.com"}, msg.Cc)
+	assert.Equal(t, "reply@example.com", msg.ReplyTo)
+	assert.Equal(t, "月度报告", msg.Subject)
+	assert.Equal(t, "第一行\r\n第二行", msg.Text)
+	assert.Equal(t, `<p>报告</p><img src="cid:logo">`, msg.HTML)
+	assert.Equal(t, "orig@example.com", msg.InReplyTo)
+	assert.Equal(t, []string{"a@example.com", "orig@example.com"}, msg.References)
+	assert.NotEmpty(t, msg.MessageID)
+	assert.False(t, msg.Date.IsZero())
+	assert.False(t, msg.IsBounce())
+
+	assert.Len(t, msg.Attachments, 2)
+	assert.Equal(t, "logo", msg.Attachments[0].ContentID)
+	assert.Equal(t, "数据.csv", msg.Attachments[1].Filename)
+	data, _ := io.ReadAll(msg.Attachments[1].Data)
+	assert.Equal(t, "a,b", string(data))
+}
+
+func TestParseMessageCharset(t *testing.T) {
+	// GBK 编码的主题与正文
+	raw := "From: =?GBK?B?1cXI/Q==?= <a@example.com>\r\n" +
+		"Subject: =?GBK?B?xOO6ww==?=\r\n" +
+		"Content-Type: text/plain; charset=GBK\r\n" +
+		"Content-Transfer-Encoding: base64\r\n\r\n" +
+		"xOO6ww==\r\n"
+	msg, err := ParseMessage(strings.NewReader(raw))
+	assert.NoError(t, err)
+	assert.Equal(t, "张三 <a@example.com>", msg.From)
+	assert.Equal(t, "你好", msg.Subject)
+	assert.Equal(t, "你好", msg.Text)
+}
+
+const dsnMessage = "From: MAILER-DAEMON@mx.example.com\r\n" +
+	"To: sender@example.com\r\n" +
+	"Subject: Undelivered Mail Returned to Sender\r\n" +
+	"Content-Type: multipart/report; report-type=delivery-status; boundary=\"b1\"\r\n\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain\r\n\r\n" +
+	"Delivery to the following recipients failed.\r\n" +
+	"--b1\r\n" +
+	"Content-Type: message/delivery-status\r\n\r\n" +
+	"Reporting-MTA: dns; mx.example.com\r\n" +
+	"Arrival-Date: Mon, 19 Oct 2026 10:00:00 +0800\r\n\r\n" +
+	"Final-Recipient: rfc822; nobody@example.org\r\n" +
+	"Action: failed\r\n" +
+	"Status: 5.1.1\r\n" +
+	"Remote-MTA: dns; mx.example.org\r\n" +
+	"Diagnostic-Code: smtp; 550 5.1.1 <nobody@example.org>: User unknown\r\n\r\n" +
+	"Final-Recipient: rfc822; full@example.org\r\n" +
+	"Action: delayed\r\n" +
+	"Status: 4.2.2\r\n" +
+	"Diagnostic-Code: smtp; 452 4.2.2 Mailbox full\r\n\r\n" +
+	"Final-Recipient: rfc822; ok@example.org\r\n" +
+	"Action: delivered\r\n" +
+	"Status: 2.0.0\r\n\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/rfc822-headers\r\n\r\n" +
+	"From: sender@example.com\r\n" +
+	"Message-ID: <original@example.com>\r\n" +
+	"Subject: hello\r\n" +
+	"--b1--\r\n"
+
+func TestParseBounce(t *testing.T) {
+	msg, err := ParseMessage(strings.NewReader(dsnMessage))
+	assert.NoError(t, err)
+	assert.True(t, msg.IsBounce())
+	assert.Equal(t, "Delivery to the following recipients failed.", msg.Text)
+	assert.Len(t, msg.Bounces, 2)
+
+	hard := msg.Bounces[0]
+	assert.Equal(t, "nobody@example.org", hard.Recipient)
+	assert.Equal(t, BounceHard, hard.Type)
+	assert.Equal(t, "5.1.1", hard.Status)
+	assert.Equal(t, "550 5.1.1 <nobody@example.org>: User unknown", hard.DiagnosticCode)
+	assert.Equal(t, "mx.example.org", hard.RemoteMTA)
+	assert.Equal(t, "mx.example.com", hard.ReportingMTA)
+	assert.Equal(t, "original@example.com", hard.OriginalMessageID)
+
+	soft := msg.Bounces[1]
+	assert.Equal(t, "full@example.org", soft.Recipient)
+	assert.Equal(t, "delayed", soft.Action)
+	assert.Equal(t, BounceSoft, soft.Type)
+}
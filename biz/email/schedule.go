@@ -0,0 +1,476 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yi-nology/common/tools/timertask"
+	"github.com/yi-nology/common/utils/xlogger"
+	"github.com/yi-nology/common/utils/xuuid"
+)
+
+const (
+	EMAIL_SCHEDULE      = "email.schedule.%s"      // timertask 业务类型
+	EMAIL_SCHEDULE_DATA = "email.schedule.%s.data" // 定时邮件内容，HASH id -> 邮件
+
+	scheduleAction = "email.send"
+)
+
+var (
+	ErrScheduleNotFound   = errors.New("scheduled email not found")
+	ErrScheduleInProgress = errors.New("scheduled email is being delivered")
+
+	errScheduleChanged = errors.New("scheduled email changed concurrently")
+
+	// swapScript 邮件内容仍为 ARGV[2] 时替换为 ARGV[3]（为空时删除），不存在返回 -1，已被修改返回 0
+	swapScript = redis.NewScript(`
+local cur = redis.call('hget', KEYS[1], ARGV[1])
+if not cur then
+	return -1
+end
+if cur ~= ARGV[2] then
+	return 0
+end
+if ARGV[3] == '' then
+	redis.call('hdel', KEYS[1], ARGV[1])
+else
+	redis.call('hset', KEYS[1], ARGV[1], ARGV[3])
+end
+return 1
+`)
+)
+
+// ScheduledMessage 定时邮件，连同附件内容一起保存在 Redis 中，投递成功后删除
+type ScheduledMessage struct {
+	ID         string        `json:"id"`
+	Message    StoredMessage `json:"message"`
+	RunAt      int64         `json:"run_at"`      // 下次投递时间（秒）
+	Attempts   int           `json:"attempts"`    // 已投递次数
+	LeaseUntil int64         `json:"lease_until"` // 投递中的租约到期时间（秒），过期未完成视为中断
+	Failed     bool          `json:"failed"`      // 超过最大重试次数，不再投递
+	LastError  string        `json:"last_error"`
+	CreateTime int64         `json:"create_time"`
+	Task       string        `json:"task"` // 当前定时任务，用于取消
+}
+
+// StoredMessage 可序列化的邮件，附件内容直接保存
+type StoredMessage struct {
+	From        string             `json:"from"`
+	To          []string           `json:"to"`
+	Cc          []string           `json:"cc,omitempty"`
+	Bcc         []string           `json:"bcc,omitempty"`
+	ReplyTo     string             `json:"reply_to,omitempty"`
+	Subject     string             `json:"subject"`
+	Text        string             `json:"text,omitempty"`
+	HTML        string             `json:"html,omitempty"`
+	Attachments []StoredAttachment `json:"attachments,omitempty"`
+	Headers     map[string]string  `json:"headers,omitempty"`
+	Priority    Priority           `json:"priority,omitempty"`
+}
+
+// StoredAttachment 可序列化的附件
+type StoredAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	ContentID   string `json:"content_id,omitempty"`
+	Data        []byte `json:"data"`
+}
+
+// Scheduler 定时邮件：SendAt 在未来的邮件写入 Redis，由 timertask 到期触发，通过任意 Sender 投递。
+// 投递至少一次：邮件在投递成功后才删除，投递中断（进程退出等）的邮件在租约过期后重新调度
+type Scheduler struct {
+	name          string
+	sender        Sender
+	client        *redis.Client
+	tasks         *timertask.TimeTaskManager
+	log           xlogger.Logger
+	maxAttempts   int
+	retryInterval time.Duration
+	leaseTimeout  time.Duration
+}
+
+// NewScheduler 创建定时邮件调度器，name 用于区分 Redis key
+func NewScheduler(name string, sender Sender, client *redis.Client, log xlogger.Logger) *Scheduler {
+	return &Scheduler{
+		name:          name,
+		sender:        sender,
+		client:        client,
+		tasks:         timertask.NewTimeTaskManager(fmt.Sprintf(EMAIL_SCHEDULE, name), client, log),
+		log:           log,
+		maxAttempts:   3,
+		retryInterval: time.Minute,
+		leaseTimeout:  5 * time.Minute,
+	}
+}
+
+// SetRetry 设置最大投递次数与重试间隔，第 n 次失败后等待 n*interval
+func (s *Scheduler) SetRetry(maxAttempts int, interval time.Duration) *Scheduler {
+	s.maxAttempts = maxAttempts
+	s.retryInterval = interval
+	return s
+}
+
+// SetLeaseTimeout 设置单次投递的最长时间，超过后视为中断并重新调度
+func (s *Scheduler) SetLeaseTimeout(timeout time.Duration) *Scheduler {
+	s.leaseTimeout = timeout
+	return s
+}
+
+func (s *Scheduler) dataKey() string {
+	return fmt.Sprintf(EMAIL_SCHEDULE_DATA, s.name)
+}
+
+// Send 实现 Sender：SendAt 为空或已过期时立即发送，否则定时发送
+func (s *Scheduler) Send(ctx context.Context, message *Message) error {
+	if message.SendAt == nil || !message.SendAt.After(time.Now()) {
+		return s.sender.Send(ctx, message)
+	}
+	_, err := s.Schedule(ctx, message)
+	return err
+}
+
+// SendBatch 批量发送，部分失败时返回 *BatchError
+func (s *Scheduler) SendBatch(ctx context.Context, messages []*Message) error {
+	errs := make([]error, len(messages))
+	for i, msg := range messages {
+		errs[i] = s.Send(ctx, msg)
+	}
+	return newBatchError(errs)
+}
+
+// Close 关闭底层发送器
+func (s *Scheduler) Close() error {
+	return s.sender.Close()
+}
+
+// Schedule 保存邮件并在 SendAt 时投递（为空时尽快投递），返回用于取消的 ID；附件内容会被读取
+func (s *Scheduler) Schedule(ctx context.Context, message *Message) (string, error) {
+	if err := message.Validate(); err != nil {
+		return "", err
+	}
+	stored, err := toStoredMessage(message)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	runAt := now
+	if message.SendAt != nil {
+		runAt = *message.SendAt
+	}
+	m := &ScheduledMessage{
+		ID:         xuuid.ShortUuid(),
+		Message:    stored,
+		RunAt:      runAt.Unix(),
+		CreateTime: now.Unix(),
+	}
+	if err := s.schedule(ctx, m, ""); err != nil {
+		s.client.HDel(ctx, s.dataKey(), m.ID)
+		return "", err
+	}
+	return m.ID, nil
+}
+
+// schedule 保存邮件并创建 RunAt 到期的定时任务，prev 不为空时仅在邮件内容仍为 prev 时保存
+func (s *Scheduler) schedule(ctx context.Context, m *ScheduledMessage, prev string) error {
+	task := &timertask.TaskInfo{
+		TaskID:     m.ID,
+		CreateTime: time.Now().Unix(),
+		EndTime:    m.RunAt,
+		Action:     scheduleAction,
+		Param:      m.ID,
+	}
+	info, _ := json.Marshal(task)
+	m.Task = string(info)
+	if prev == "" {
+		if err := s.save(ctx, m); err != nil {
+			return err
+		}
+	} else if _, err := s.swap(ctx, m.ID, prev, m); err != nil {
+		return err
+	}
+	return s.tasks.SendTask(ctx, task)
+}
+
+func (s *Scheduler) save(ctx context.Context, m *ScheduledMessage) error {
+	info, _ := json.Marshal(m)
+	if err := s.client.HSet(ctx, s.dataKey(), m.ID, string(info)).Err(); err != nil {
+		s.log.Errorf("Scheduler|save|HSet %s %s error %s", s.dataKey(), m.ID, err)
+		return err
+	}
+	return nil
+}
+
+// swap 邮件内容仍为 prev 时原子地替换为 m（为 nil 时删除），返回新的内容；
+// 邮件不存在返回 ErrScheduleNotFound，已被其他实例或 Cancel 修改返回 errScheduleChanged
+func (s *Scheduler) swap(ctx context.Context, id, prev string, m *ScheduledMessage) (string, error) {
+	var next string
+	if m != nil {
+		info, _ := json.Marshal(m)
+		next = string(info)
+	}
+	n, err := swapScript.Run(ctx, s.client, []string{s.dataKey()}, id, prev, next).Int()
+	if err != nil {
+		s.log.Errorf("Scheduler|swap|%s %s error %s", s.dataKey(), id, err)
+		return "", err
+	}
+	switch n {
+	case -1:
+		return "", ErrScheduleNotFound
+	case 0:
+		return "", errScheduleChanged
+	}
+	return next, nil
+}
+
+// Get 查询定时邮件
+func (s *Scheduler) Get(ctx context.Context, id string) (*ScheduledMessage, error) {
+	m, _, err := s.get(ctx, id)
+	return m, err
+}
+
+// get 查询定时邮件及其原始内容，原始内容用于 swap
+func (s *Scheduler) get(ctx context.Context, id string) (*ScheduledMessage, string, error) {
+	data, err := s.client.HGet(ctx, s.dataKey(), id).Result()
+	if err == redis.Nil {
+		return nil, "", ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	m := new(ScheduledMessage)
+	if err := json.Unmarshal([]byte(data), m); err != nil {
+		return nil, "", err
+	}
+	return m, data, nil
+}
+
+// Cancel 取消定时邮件，正在投递中的邮件无法取消；与投递领取互斥，返回 nil 后邮件不会再被发送
+func (s *Scheduler) Cancel(ctx context.Context, id string) error {
+	m, raw, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if m.LeaseUntil > time.Now().Unix() {
+		return ErrScheduleInProgress
+	}
+	if _, err := s.swap(ctx, id, raw, nil); err != nil {
+		if err == errScheduleChanged {
+			return ErrScheduleInProgress
+		}
+		return err
+	}
+	// 残留的定时任务到期时找不到邮件，直接跳过
+	if _, err := s.tasks.TaskPool.DelTask(ctx, timertask.TIME_TASK_SHARD, m.Task); err != nil {
+		s.log.Errorf("Scheduler|Cancel|DelTask %s error %s", id, err)
+	}
+	return nil
+}
+
+// ProcessDue 投递所有到期邮件，返回处理的数量
+func (s *Scheduler) ProcessDue(ctx context.Context) (int, error) {
+	tasks, err := s.tasks.TaskPool.GetAllTask(ctx, timertask.TIME_TASK_SHARD)
+	if err != nil {
+		return 0, err
+	}
+	processed := 0
+	for _, member := range tasks {
+		// 删除成功才处理，保证多实例下同一任务只被领取一次
+		succ, err := s.tasks.TaskPool.DelTask(ctx, timertask.TIME_TASK_SHARD, member)
+		if err != nil || !succ {
+			continue
+		}
+		task := new(timertask.TaskInfo)
+		if err := json.Unmarshal([]byte(member), task); err != nil {
+			s.log.Errorf("Scheduler|ProcessDue|Unmarshal %s error %s", member, err)
+			continue
+		}
+		if task.Action != scheduleAction {
+			continue
+		}
+		if err := s.deliver(ctx, task.Param); err != nil {
+			s.log.Errorf("Scheduler|ProcessDue|deliver %s error %s", task.Param, err)
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// deliver 投递一封定时邮件，失败时按重试间隔重新调度；
+// 领取租约与之后的写入均通过 swap 校验邮件未被修改，已取消的邮件不会被发送或重新写入
+func (s *Scheduler) deliver(ctx context.Context, id string) error {
+	m, raw, err := s.get(ctx, id)
+	if err == ErrScheduleNotFound {
+		// 已取消或已由其他实例投递
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if m.Failed || m.LeaseUntil > now.Unix() {
+		return nil
+	}
+
+	m.Attempts++
+	m.LeaseUntil = now.Add(s.leaseTimeout).Unix()
+	claimed, err := s.swap(ctx, id, raw, m)
+	if err == ErrScheduleNotFound || err == errScheduleChanged {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	sendErr := s.sender.Send(ctx, m.Message.Message())
+	if sendErr == nil {
+		return ignoreChanged(s.swap(ctx, id, claimed, nil))
+	}
+
+	m.LeaseUntil = 0
+	m.LastError = sendErr.Error()
+	if m.Attempts >= s.maxAttempts {
+		m.Failed = true
+		s.log.Errorf("Scheduler|deliver|%s failed after %d attempts: %s", id, m.Attempts, sendErr)
+		return ignoreChanged(s.swap(ctx, id, claimed, m))
+	}
+	m.RunAt = now.Add(s.retryInterval * time.Duration(m.Attempts)).Unix()
+	if err := ignoreChanged("", s.schedule(ctx, m, claimed)); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// ignoreChanged 租约过期后邮件被取消或重新领取时放弃写入
+func ignoreChanged(_ string, err error) error {
+	if err == ErrScheduleNotFound || err == errScheduleChanged {
+		return nil
+	}
+	return err
+}
+
+// Recover 重新调度投递中断的邮件：到期超过租约时长仍未完成且不在投递中，返回重新调度的数量
+func (s *Scheduler) Recover(ctx context.Context) (int, error) {
+	items, err := s.client.HGetAll(ctx, s.dataKey()).Result()
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	deadline := now.Add(-s.leaseTimeout).Unix()
+	recovered := 0
+	for id, data := range items {
+		m := new(ScheduledMessage)
+		if err := json.Unmarshal([]byte(data), m); err != nil {
+			s.log.Errorf("Scheduler|Recover|Unmarshal %s error %s", id, err)
+			continue
+		}
+		if m.Failed || m.RunAt > deadline || m.LeaseUntil > now.Unix() {
+			continue
+		}
+		// 任务仍在队列中说明只是尚未被领取
+		if _, err := s.client.ZScore(ctx, s.taskKey(), m.Task).Result(); err == nil {
+			continue
+		}
+		m.LeaseUntil = 0
+		m.RunAt = now.Unix()
+		if err := s.schedule(ctx, m, data); err != nil {
+			if err == ErrScheduleNotFound || err == errScheduleChanged {
+				continue
+			}
+			return recovered, err
+		}
+		recovered++
+	}
+	return recovered, nil
+}
+
+func (s *Scheduler) taskKey() string {
+	return fmt.Sprintf(timertask.TIME_TASK, fmt.Sprintf(EMAIL_SCHEDULE, s.name), timertask.TIME_TASK_SHARD)
+}
+
+// Start 后台每秒投递到期邮件，每分钟检查一次中断的邮件，ctx 取消后停止
+func (s *Scheduler) Start(ctx context.Context) error {
+	if _, err := s.Recover(ctx); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		lastRecover := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.ProcessDue(ctx); err != nil && ctx.Err() == nil {
+					s.log.Errorf("Scheduler|Start|ProcessDue error %s", err)
+				}
+				if time.Since(lastRecover) >= time.Minute {
+					lastRecover = time.Now()
+					if _, err := s.Recover(ctx); err != nil && ctx.Err() == nil {
+						s.log.Errorf("Scheduler|Start|Recover error %s", err)
+					}
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func toStoredMessage(message *Message) (StoredMessage, error) {
+	stored := StoredMessage{
+		From:     message.From,
+		To:       message.To,
+		Cc:       message.Cc,
+		Bcc:      message.Bcc,
+		ReplyTo:  message.ReplyTo,
+		Subject:  message.Subject,
+		Text:     message.Text,
+		HTML:     message.HTML,
+		Headers:  message.Headers,
+		Priority: message.Priority,
+	}
+	for _, att := range message.Attachments {
+		data, err := io.ReadAll(att.Data)
+		if err != nil {
+			return stored, fmt.Errorf("%w: %v", ErrInvalidAttachment, err)
+		}
+		stored.Attachments = append(stored.Attachments, StoredAttachment{
+			Filename:    att.Filename,
+			ContentType: att.ContentType,
+			ContentID:   att.ContentID,
+			Data:        data,
+		})
+	}
+	return stored, nil
+}
+
+// Message 还原为邮件，SendAt 置空以便底层 Sender 立即发送
+func (m StoredMessage) Message() *Message {
+	message := &Message{
+		From:     m.From,
+		To:       m.To,
+		Cc:       m.Cc,
+		Bcc:      m.Bcc,
+		ReplyTo:  m.ReplyTo,
+		Subject:  m.Subject,
+		Text:     m.Text,
+		HTML:     m.HTML,
+		Headers:  m.Headers,
+		Priority: m.Priority,
+	}
+	for _, att := range m.Attachments {
+		message.Attachments = append(message.Attachments, &Attachment{
+			Filename:    att.Filename,
+			ContentType: att.ContentType,
+			ContentID:   att.ContentID,
+			Data:        bytes.NewReader(att.Data),
+		})
+	}
+	return message
+}
@@ -0,0 +1,212 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Errorf(string, ...interface{}) {}
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Infof(string, ...interface{})  {}
+
+// recordSender 记录发送的邮件，fail 次数内返回错误
+type recordSender struct {
+	mu   sync.Mutex
+	sent []*Message
+	fail int
+}
+
+func (r *recordSender) Send(ctx context.Context, message *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail > 0 {
+		r.fail--
+		return errors.New("temporary failure")
+	}
+	r.sent = append(r.sent, message)
+	return nil
+}
+
+func (r *recordSender) SendBatch(ctx context.Context, messages []*Message) error { return nil }
+func (r *recordSender) Close() error                                             { return nil }
+
+func newTestScheduler(t *testing.T) (*Scheduler, *recordSender, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	sender := &recordSender{}
+	return NewScheduler("test", sender, client, nopLogger{}), sender, mr
+}
+
+func testMessage(sendAt time.Time) *Message {
+	return &Message{
+		From:    "sender@example.com",
+		To:      []string{"a@example.com"},
+		Subject: "scheduled",
+		Text:    "body",
+		Attachments: []*Attachment{
+			{Filename: "report.csv", ContentType: "text/csv", Data: strings.NewReader("a,b\n1,2\n")},
+		},
+		SendAt: &sendAt,
+	}
+}
+
+func TestSchedulerDeliverDue(t *testing.T) {
+	s, sender, _ := newTestScheduler(t)
+	ctx := context.Background()
+
+	id, err := s.Schedule(ctx, testMessage(time.Now().Add(-time.Second)))
+	assert.NoError(t, err)
+
+	n, err := s.ProcessDue(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, sender.sent, 1)
+	assert.Nil(t, sender.sent[0].SendAt)
+	data, _ := io.ReadAll(sender.sent[0].Attachments[0].Data)
+	assert.Equal(t, "a,b\n1,2\n", string(data))
+
+	_, err = s.Get(ctx, id)
+	assert.ErrorIs(t, err, ErrScheduleNotFound)
+}
+
+func TestSchedulerSendAndCancel(t *testing.T) {
+	s, sender, mr := newTestScheduler(t)
+	ctx := context.Background()
+
+	// 未设置 SendAt 的邮件立即发送
+	now := &Message{From: "sender@example.com", To: []string{"a@example.com"}, Subject: "now", Text: "body"}
+	assert.NoError(t, s.Send(ctx, now))
+	assert.Len(t, sender.sent, 1)
+
+	assert.NoError(t, s.Send(ctx, testMessage(time.Now().Add(time.Hour))))
+	assert.Len(t, sender.sent, 1)
+
+	id, err := s.Schedule(ctx, testMessage(time.Now().Add(time.Hour)))
+	assert.NoError(t, err)
+	n, _ := s.ProcessDue(ctx)
+	assert.Equal(t, 0, n)
+
+	assert.NoError(t, s.Cancel(ctx, id))
+	assert.ErrorIs(t, s.Cancel(ctx, id), ErrScheduleNotFound)
+	members, _ := mr.ZMembers(s.taskKey())
+	assert.Len(t, members, 1)
+	keys, _ := mr.HKeys(s.dataKey())
+	assert.Len(t, keys, 1)
+}
+
+func TestSchedulerRetry(t *testing.T) {
+	s, sender, _ := newTestScheduler(t)
+	s.SetRetry(2, 0)
+	ctx := context.Background()
+
+	sender.fail = 1
+	id, err := s.Schedule(ctx, testMessage(time.Now()))
+	assert.NoError(t, err)
+
+	s.ProcessDue(ctx)
+	m, err := s.Get(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, m.Attempts)
+	assert.Equal(t, "temporary failure", m.LastError)
+	assert.Zero(t, m.LeaseUntil)
+
+	s.ProcessDue(ctx)
+	assert.Len(t, sender.sent, 1)
+	_, err = s.Get(ctx, id)
+	assert.ErrorIs(t, err, ErrScheduleNotFound)
+
+	sender.fail = 2
+	id, _ = s.Schedule(ctx, testMessage(time.Now()))
+	s.ProcessDue(ctx)
+	s.ProcessDue(ctx)
+	m, _ = s.Get(ctx, id)
+	assert.True(t, m.Failed)
+	assert.Equal(t, 2, m.Attempts)
+	n, _ := s.ProcessDue(ctx)
+	assert.Equal(t, 0, n)
+}
+
+func TestSchedulerRecover(t *testing.T) {
+	s, sender, mr := newTestScheduler(t)
+	s.SetLeaseTimeout(time.Second)
+	ctx := context.Background()
+
+	id, err := s.Schedule(ctx, testMessage(time.Now().Add(-time.Minute)))
+	assert.NoError(t, err)
+
+	// 模拟任务已被领取但进程在投递前退出
+	m, _ := s.Get(ctx, id)
+	mr.ZRem(s.taskKey(), m.Task)
+
+	n, err := s.Recover(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, _ = s.Recover(ctx)
+	assert.Equal(t, 0, n)
+
+	s.ProcessDue(ctx)
+	assert.Len(t, sender.sent, 1)
+}
+
+func TestSchedulerCancelRace(t *testing.T) {
+	s, sender, mr := newTestScheduler(t)
+	ctx := context.Background()
+
+	// deliver 读取后邮件被取消，领取租约失败，邮件不会被重新写入
+	id, err := s.Schedule(ctx, testMessage(time.Now().Add(time.Hour)))
+	assert.NoError(t, err)
+	m, raw, err := s.get(ctx, id)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Cancel(ctx, id))
+	m.LeaseUntil = time.Now().Add(time.Minute).Unix()
+	_, err = s.swap(ctx, id, raw, m)
+	assert.ErrorIs(t, err, ErrScheduleNotFound)
+	assert.False(t, mr.Exists(s.dataKey()))
+
+	// 并发投递与取消：Cancel 返回 nil 的邮件一定没有发送
+	ids := make([]string, 50)
+	for i := range ids {
+		message := testMessage(time.Now().Add(time.Hour))
+		message.Subject = strconv.Itoa(i)
+		ids[i], err = s.Schedule(ctx, message)
+		assert.NoError(t, err)
+	}
+	cancelled := make([]bool, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, s.deliver(ctx, id))
+		}()
+		go func() {
+			defer wg.Done()
+			err := s.Cancel(ctx, id)
+			cancelled[i] = err == nil
+			if err != nil {
+				assert.ErrorIs(t, err, ErrScheduleInProgress)
+			}
+		}()
+	}
+	wg.Wait()
+	sent := map[string]bool{}
+	for _, message := range sender.sent {
+		sent[message.Subject] = true
+	}
+	for i := range ids {
+		assert.NotEqual(t, cancelled[i], sent[strconv.Itoa(i)], "message %d", i)
+	}
+	assert.False(t, mr.Exists(s.dataKey()))
+}
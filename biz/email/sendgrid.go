@@ -0,0 +1,171 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const sendGridBaseURL = "https://api.sendgrid.com"
+
+// SendGridSender 通过 SendGrid v3 Mail Send API 发送邮件
+type SendGridSender struct {
+	config  *Config
+	baseURL string
+	client  *http.Client
+}
+
+// NewSendGridSender 创建 SendGrid 发送器，需配置 APIKey
+func NewSendGridSender(config *Config) (*SendGridSender, error) {
+	if config == nil || config.APIKey == "" {
+		return nil, fmt.Errorf("%w: sendgrid api key is required", ErrInvalidConfig)
+	}
+	if len(config.Protectors) > 0 {
+		return nil, fmt.Errorf("%w: sendgrid cannot sign or encrypt message content", ErrUnsupportedFeature)
+	}
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = sendGridBaseURL
+	}
+	return &SendGridSender{
+		config:  config,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: httpTimeout(config)},
+	}, nil
+}
+
+// SetHTTPClient 设置 HTTP 客户端
+func (s *SendGridSender) SetHTTPClient(client *http.Client) *SendGridSender {
+	s.client = client
+	return s
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridPersonalization struct {
+	To  []sendGridAddress `json:"to"`
+	Cc  []sendGridAddress `json:"cc,omitempty"`
+	Bcc []sendGridAddress `json:"bcc,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridAttachment struct {
+	Content     string `json:"content"`
+	Type        string `json:"type,omitempty"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition"`
+	ContentID   string `json:"content_id,omitempty"`
+}
+
+type sendGridMail struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	ReplyTo          *sendGridAddress          `json:"reply_to,omitempty"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Attachments      []sendGridAttachment      `json:"attachments,omitempty"`
+	Headers          map[string]string         `json:"headers,omitempty"`
+}
+
+// Send 发送邮件
+func (s *SendGridSender) Send(ctx context.Context, message *Message) error {
+	if err := prepareMessage(s.config, message); err != nil {
+		return err
+	}
+	payload, err := s.buildMail(message)
+	if err != nil {
+		return err
+	}
+	body, _ := json.Marshal(payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.config.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+	_, err = doRequest(s.client, req, ProviderSendGrid)
+	return err
+}
+
+func (s *SendGridSender) buildMail(message *Message) (*sendGridMail, error) {
+	addresses := func(list []string) []sendGridAddress {
+		var out []sendGridAddress
+		for _, addr := range list {
+			a := parseAddress(addr)
+			out = append(out, sendGridAddress{Email: a.Address, Name: a.Name})
+		}
+		return out
+	}
+	from := parseAddress(message.From)
+	m := &sendGridMail{
+		Personalizations: []sendGridPersonalization{{
+			To:  addresses(message.To),
+			Cc:  addresses(message.Cc),
+			Bcc: addresses(message.Bcc),
+		}},
+		From:    sendGridAddress{Email: from.Address, Name: from.Name},
+		Subject: message.Subject,
+	}
+	if message.ReplyTo != "" {
+		a := parseAddress(message.ReplyTo)
+		m.ReplyTo = &sendGridAddress{Email: a.Address, Name: a.Name}
+	}
+	// SendGrid 要求 text/plain 在 text/html 之前
+	if message.Text != "" {
+		m.Content = append(m.Content, sendGridContent{Type: "text/plain", Value: message.Text})
+	}
+	if message.HTML != "" {
+		m.Content = append(m.Content, sendGridContent{Type: "text/html", Value: message.HTML})
+	}
+	for _, att := range message.Attachments {
+		data, err := readAttachment(att)
+		if err != nil {
+			return nil, err
+		}
+		a := sendGridAttachment{
+			Content:     base64.StdEncoding.EncodeToString(data),
+			Type:        att.ContentType,
+			Filename:    att.Filename,
+			Disposition: "attachment",
+		}
+		if att.IsInline() {
+			a.Disposition, a.ContentID = "inline", att.ContentID
+		}
+		m.Attachments = append(m.Attachments, a)
+	}
+	if headers := messageHeaders(message); len(headers) > 0 {
+		m.Headers = headers
+	}
+	return m, nil
+}
+
+// SendBatch 批量发送邮件
+func (s *SendGridSender) SendBatch(ctx context.Context, messages []*Message) error {
+	return sendEach(ctx, s, messages)
+}
+
+// Close 无需关闭
+func (s *SendGridSender) Close() error {
+	return nil
+}
+
+// httpTimeout HTTP 发送器使用 SendTimeout 作为请求超时
+func httpTimeout(config *Config) time.Duration {
+	if config.SendTimeout > 0 {
+		return config.SendTimeout
+	}
+	return 30 * time.Second
+}
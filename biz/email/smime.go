@@ -0,0 +1,352 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PKCS#7 / CMS 对象标识符
+var (
+	oidData               = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidEnvelopedData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidAttrContentType    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttrMessageDigest  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttrSigningTime    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidSHA256             = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256    = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidAES256CBC          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	asn1NullParam         = asn1.RawValue{Tag: asn1.TagNull}
+	smimeSignatureHeaders = textproto.MIMEHeader{
+		"Content-Type":              {`application/pkcs7-signature; name="smime.p7s"`},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {`attachment; filename="smime.p7s"`},
+	}
+)
+
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      pkcs7ContentInfo
+	Certificates     asn1.RawValue     `asn1:"optional,tag:0"`
+	SignerInfos      []pkcs7SignerInfo `asn1:"set"`
+}
+
+type pkcs7IssuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type pkcs7SignerInfo struct {
+	Version            int
+	IssuerAndSerial    pkcs7IssuerAndSerial
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttributes   asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type pkcs7Attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+type pkcs7EnvelopedData struct {
+	Version              int
+	RecipientInfos       []pkcs7RecipientInfo `asn1:"set"`
+	EncryptedContentInfo pkcs7EncryptedContentInfo
+}
+
+type pkcs7RecipientInfo struct {
+	Version                int
+	IssuerAndSerial        pkcs7IssuerAndSerial
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+type pkcs7EncryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"tag:0,optional"`
+}
+
+// SMIMESigner S/MIME 签名，生成 multipart/signed（RFC 8551），签名为 SHA-256 的 PKCS#7 分离签名
+type SMIMESigner struct {
+	cert  *x509.Certificate
+	chain []*x509.Certificate
+	key   crypto.Signer
+}
+
+// NewSMIMESigner 创建S/MIME签名器，支持 RSA 与 ECDSA 私钥；chain 为随签名附带的中间证书
+func NewSMIMESigner(cert *x509.Certificate, key crypto.Signer, chain ...*x509.Certificate) (*SMIMESigner, error) {
+	if cert == nil || key == nil {
+		return nil, fmt.Errorf("%w: certificate and key are required", ErrInvalidConfig)
+	}
+	switch key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey:
+	default:
+		return nil, fmt.Errorf("%w: unsupported S/MIME key type %T", ErrInvalidConfig, key)
+	}
+	return &SMIMESigner{cert: cert, chain: chain, key: key}, nil
+}
+
+// NewSMIMESignerFromPEM 从 PEM 格式的证书（可含证书链）与私钥创建S/MIME签名器
+func NewSMIMESignerFromPEM(certPEM, keyPEM []byte) (*SMIMESigner, error) {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	certs := make([]*x509.Certificate, len(pair.Certificate))
+	for i, der := range pair.Certificate {
+		if certs[i], err = x509.ParseCertificate(der); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+		}
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: private key cannot sign", ErrInvalidConfig)
+	}
+	return NewSMIMESigner(certs[0], key, certs[1:]...)
+}
+
+// Protect 对内容实体签名，返回 multipart/signed 实体
+func (s *SMIMESigner) Protect(entity []byte, message *Message) ([]byte, error) {
+	sig, err := s.sign(entity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+	params := map[string]string{"protocol": "application/pkcs7-signature", "micalg": "sha-256"}
+	return wrapMultipart("signed", params, entity, smimeSignatureHeaders, encodeBase64Lines(sig)), nil
+}
+
+// sign 生成 DER 编码的 PKCS#7 分离签名
+func (s *SMIMESigner) sign(content []byte) ([]byte, error) {
+	digest := sha256.Sum256(content)
+	attrs, err := marshalAttributes(
+		attribute(oidAttrContentType, oidData),
+		attribute(oidAttrMessageDigest, digest[:]),
+		attribute(oidAttrSigningTime, time.Now().UTC()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// 签名的是以 SET 标签编码的签名属性
+	set, err := asn1.Marshal(asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: attrs})
+	if err != nil {
+		return nil, err
+	}
+	attrDigest := sha256.Sum256(set)
+	signature, err := s.key.Sign(rand.Reader, attrDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	sigAlg := pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1NullParam}
+	if _, ok := s.key.(*ecdsa.PrivateKey); ok {
+		sigAlg = pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256}
+	}
+
+	var certs []byte
+	for _, cert := range append([]*x509.Certificate{s.cert}, s.chain...) {
+		certs = append(certs, cert.Raw...)
+	}
+	sd := pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		ContentInfo:      pkcs7ContentInfo{ContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos: []pkcs7SignerInfo{{
+			Version:            1,
+			IssuerAndSerial:    issuerAndSerial(s.cert),
+			DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			SignedAttributes:   asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrs},
+			SignatureAlgorithm: sigAlg,
+			Signature:          signature,
+		}},
+	}
+	return marshalContentInfo(oidSignedData, sd)
+}
+
+// SMIMEEncryptor S/MIME 加密，生成 application/pkcs7-mime 实体，内容以 AES-256-CBC 加密，会话密钥以收件人 RSA 公钥加密
+type SMIMEEncryptor struct {
+	certs []*x509.Certificate
+	self  *x509.Certificate
+}
+
+// NewSMIMEEncryptor 创建S/MIME加密器，每个收件人需有一张邮箱地址匹配的 RSA 证书，可以是共享的证书目录；
+// 每封邮件只加密给该邮件的收件人，以及 SetSelf 指定的发件人证书
+func NewSMIMEEncryptor(certs ...*x509.Certificate) (*SMIMEEncryptor, error) {
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%w: at least one certificate is required", ErrInvalidConfig)
+	}
+	for _, cert := range certs {
+		if _, ok := cert.PublicKey.(*rsa.PublicKey); !ok {
+			return nil, fmt.Errorf("%w: S/MIME encryption requires RSA certificates", ErrInvalidConfig)
+		}
+	}
+	return &SMIMEEncryptor{certs: certs}, nil
+}
+
+// SetSelf 设置发件人证书，每封邮件额外加密给发件人，以便在已发送邮件中解密
+func (e *SMIMEEncryptor) SetSelf(cert *x509.Certificate) *SMIMEEncryptor {
+	e.self = cert
+	return e
+}
+
+// Protect 为所有收件人加密内容实体，任一收件人缺少证书时返回 ErrMissingRecipientKey
+func (e *SMIMEEncryptor) Protect(entity []byte, message *Message) ([]byte, error) {
+	recipients, err := e.recipients(message)
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	// PKCS#7 填充
+	padding := aes.BlockSize - len(entity)%aes.BlockSize
+	ciphertext := append(append([]byte{}, entity...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+
+	ed := pkcs7EnvelopedData{
+		EncryptedContentInfo: pkcs7EncryptedContentInfo{
+			ContentType:                oidData,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC},
+			EncryptedContent:           asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: ciphertext},
+		},
+	}
+	if ed.EncryptedContentInfo.ContentEncryptionAlgorithm.Parameters.FullBytes, err = asn1.Marshal(iv); err != nil {
+		return nil, err
+	}
+	for _, cert := range recipients {
+		encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, cert.PublicKey.(*rsa.PublicKey), key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt message: %w", err)
+		}
+		ed.RecipientInfos = append(ed.RecipientInfos, pkcs7RecipientInfo{
+			IssuerAndSerial:        issuerAndSerial(cert),
+			KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1NullParam},
+			EncryptedKey:           encryptedKey,
+		})
+	}
+	der, err := marshalContentInfo(oidEnvelopedData, ed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt message: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(`Content-Type: application/pkcs7-mime; smime-type=enveloped-data; name="smime.p7m"` + "\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+	buf.WriteString(`Content-Disposition: attachment; filename="smime.p7m"` + "\r\n\r\n")
+	buf.Write(encodeBase64Lines(der))
+	buf.WriteString("\r\n")
+	return buf.Bytes(), nil
+}
+
+// recipients 按邮箱地址匹配收件人证书，再加上发件人证书，同一证书只使用一次
+func (e *SMIMEEncryptor) recipients(message *Message) ([]*x509.Certificate, error) {
+	var selected []*x509.Certificate
+	used := map[*x509.Certificate]bool{}
+	for _, addr := range recipientAddresses(message) {
+		cert := e.lookup(addr)
+		if cert == nil {
+			return nil, fmt.Errorf("%w: no S/MIME certificate for %s", ErrMissingRecipientKey, addr)
+		}
+		if !used[cert] {
+			used[cert] = true
+			selected = append(selected, cert)
+		}
+	}
+	if e.self != nil && !used[e.self] {
+		if _, ok := e.self.PublicKey.(*rsa.PublicKey); !ok {
+			return nil, fmt.Errorf("%w: S/MIME encryption requires RSA certificates", ErrInvalidConfig)
+		}
+		selected = append(selected, e.self)
+	}
+	return selected, nil
+}
+
+func (e *SMIMEEncryptor) lookup(addr string) *x509.Certificate {
+	for _, cert := range e.certs {
+		for _, email := range cert.EmailAddresses {
+			if strings.EqualFold(email, addr) {
+				return cert
+			}
+		}
+	}
+	return nil
+}
+
+func issuerAndSerial(cert *x509.Certificate) pkcs7IssuerAndSerial {
+	return pkcs7IssuerAndSerial{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber}
+}
+
+// attribute 构建单值属性
+func attribute(oid asn1.ObjectIdentifier, value any) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		encoded, err := asn1.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return asn1.Marshal(pkcs7Attribute{
+			Type:   oid,
+			Values: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: encoded},
+		})
+	}
+}
+
+// marshalAttributes 按 DER 规则排序后拼接属性（SET OF 的内容）
+func marshalAttributes(attrs ...func() ([]byte, error)) ([]byte, error) {
+	encoded := make([][]byte, len(attrs))
+	for i, attr := range attrs {
+		var err error
+		if encoded[i], err = attr(); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(encoded, func(i, j int) bool { return bytes.Compare(encoded[i], encoded[j]) < 0 })
+	return bytes.Join(encoded, nil), nil
+}
+
+// marshalContentInfo 以 ContentInfo 包装内容
+func marshalContentInfo(contentType asn1.ObjectIdentifier, content any) ([]byte, error) {
+	inner, err := asn1.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs7ContentInfo{
+		ContentType: contentType,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner},
+	})
+}
@@ -0,0 +1,267 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCertificate(t *testing.T, email string) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber:   serial,
+		Subject:        pkix.Name{CommonName: email},
+		EmailAddresses: []string{email},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+func protectedMessage() *Message {
+	return &Message{
+		From: "finance@example.com", To: []string{"Boss <boss@example.com>"},
+		Subject: "季度财报", Text: "见附件", HTML: "<p>见附件</p>",
+		Attachments: []*Attachment{{Filename: "report.csv", Data: strings.NewReader("a,b")}},
+	}
+}
+
+// splitSigned 拆分 multipart/signed 邮件，返回被签名的原始实体与签名实体
+func splitSigned(t *testing.T, raw []byte) ([]byte, *multipart.Part, *mail.Message) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/signed", mediaType)
+	body, _ := io.ReadAll(msg.Body)
+
+	delimiter := "--" + params["boundary"] + "\r\n"
+	start := bytes.Index(body, []byte(delimiter)) + len(delimiter)
+	end := bytes.Index(body, []byte("\r\n"+delimiter))
+	content := body[start:end]
+
+	mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	mr.NextPart()
+	sigPart, err := mr.NextPart()
+	assert.NoError(t, err)
+	return content, sigPart, msg
+}
+
+func TestSMIMESign(t *testing.T) {
+	cert, key := newTestCertificate(t, "finance@example.com")
+	signer, err := NewSMIMESigner(cert, key)
+	assert.NoError(t, err)
+
+	raw, err := BuildMessage(protectedMessage(), signer)
+	assert.NoError(t, err)
+	content, sigPart, msg := splitSigned(t, raw)
+	assert.Contains(t, msg.Header.Get("Content-Type"), `protocol="application/pkcs7-signature"`)
+	assert.Equal(t, "季度财报", decodeHeader(msg.Header.Get("Subject")))
+	assert.Equal(t, `application/pkcs7-signature; name="smime.p7s"`, sigPart.Header.Get("Content-Type"))
+	inner, err := ParseMessage(bytes.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, "见附件", inner.Text)
+
+	der, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, sigPart))
+	assert.NoError(t, err)
+	var ci pkcs7ContentInfo
+	_, err = asn1.Unmarshal(der, &ci)
+	assert.NoError(t, err)
+	assert.True(t, ci.ContentType.Equal(oidSignedData))
+	var sd pkcs7SignedData
+	_, err = asn1.Unmarshal(ci.Content.Bytes, &sd)
+	assert.NoError(t, err)
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	assert.NoError(t, err)
+	assert.True(t, certs[0].Equal(cert))
+
+	// 签名属性中的摘要与被签名内容一致，签名覆盖以 SET 编码的签名属性
+	si := sd.SignerInfos[0]
+	assert.Equal(t, cert.SerialNumber, si.IssuerAndSerial.SerialNumber)
+	set, _ := asn1.Marshal(asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: si.SignedAttributes.Bytes})
+	var attrs []pkcs7Attribute
+	_, err = asn1.UnmarshalWithParams(set, &attrs, "set")
+	assert.NoError(t, err)
+	digest := sha256.Sum256(content)
+	var found bool
+	for _, attr := range attrs {
+		if attr.Type.Equal(oidAttrMessageDigest) {
+			var value []byte
+			asn1.Unmarshal(attr.Values.Bytes, &value)
+			assert.Equal(t, digest[:], value)
+			found = true
+		}
+	}
+	assert.True(t, found)
+	assert.NoError(t, cert.CheckSignature(x509.SHA256WithRSA, set, si.Signature))
+}
+
+// decryptSMIME 解密 application/pkcs7-mime 实体，仅用于测试
+func decryptSMIME(t *testing.T, entity io.Reader, cert *x509.Certificate, key *rsa.PrivateKey) []byte {
+	der, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, entity))
+	assert.NoError(t, err)
+	var ci pkcs7ContentInfo
+	_, err = asn1.Unmarshal(der, &ci)
+	assert.NoError(t, err)
+	assert.True(t, ci.ContentType.Equal(oidEnvelopedData))
+	var ed pkcs7EnvelopedData
+	_, err = asn1.Unmarshal(ci.Content.Bytes, &ed)
+	assert.NoError(t, err)
+
+	for _, ri := range ed.RecipientInfos {
+		if ri.IssuerAndSerial.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+			continue
+		}
+		cek, err := rsa.DecryptPKCS1v15(rand.Reader, key, ri.EncryptedKey)
+		assert.NoError(t, err)
+		var iv []byte
+		_, err = asn1.Unmarshal(ed.EncryptedContentInfo.ContentEncryptionAlgorithm.Parameters.FullBytes, &iv)
+		assert.NoError(t, err)
+		block, _ := aes.NewCipher(cek)
+		plain := append([]byte{}, ed.EncryptedContentInfo.EncryptedContent.Bytes...)
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, plain)
+		return plain[:len(plain)-int(plain[len(plain)-1])]
+	}
+	t.Fatal("no recipient info for certificate")
+	return nil
+}
+
+func TestSMIMEEncrypt(t *testing.T) {
+	signCert, signKey := newTestCertificate(t, "finance@example.com")
+	bossCert, bossKey := newTestCertificate(t, "boss@example.com")
+	strangerCert, _ := newTestCertificate(t, "stranger@example.com")
+	signer, _ := NewSMIMESigner(signCert, signKey)
+	// 共享证书目录中与本邮件无关的证书不参与加密
+	encryptor, err := NewSMIMEEncryptor(bossCert, strangerCert)
+	assert.NoError(t, err)
+	encryptor.SetSelf(signCert)
+
+	// 先签名再加密
+	raw, err := BuildMessage(protectedMessage(), signer, encryptor)
+	assert.NoError(t, err)
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	assert.NoError(t, err)
+	assert.Equal(t, `application/pkcs7-mime; smime-type=enveloped-data; name="smime.p7m"`, msg.Header.Get("Content-Type"))
+	assert.NotContains(t, string(raw), "a,b")
+
+	body, _ := io.ReadAll(msg.Body)
+	der, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(body)))
+	var ci pkcs7ContentInfo
+	asn1.Unmarshal(der, &ci)
+	var ed pkcs7EnvelopedData
+	asn1.Unmarshal(ci.Content.Bytes, &ed)
+	var serials []*big.Int
+	for _, ri := range ed.RecipientInfos {
+		serials = append(serials, ri.IssuerAndSerial.SerialNumber)
+	}
+	assert.ElementsMatch(t, []*big.Int{bossCert.SerialNumber, signCert.SerialNumber}, serials)
+
+	for _, recipient := range []struct {
+		cert *x509.Certificate
+		key  *rsa.PrivateKey
+	}{{bossCert, bossKey}, {signCert, signKey}} {
+		entity := decryptSMIME(t, bytes.NewReader(body), recipient.cert, recipient.key)
+		signed, err := mail.ReadMessage(bytes.NewReader(entity))
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(signed.Header.Get("Content-Type"), "multipart/signed"))
+	}
+
+	// 收件人缺少证书时拒绝发送
+	message := protectedMessage()
+	message.Cc = []string{"auditor@example.com"}
+	_, err = BuildMessage(message, encryptor)
+	assert.ErrorIs(t, err, ErrMissingRecipientKey)
+
+	// 通过 SMTP 发送时同样生效，API 渠道不支持
+	server := newFakeSMTPServer(t)
+	config := server.config()
+	config.Protectors = []Protector{encryptor}
+	sender, err := NewSMTPSender(config)
+	assert.NoError(t, err)
+	assert.NoError(t, sender.Send(context.Background(), protectedMessage()))
+	assert.Contains(t, server.received()[0].Data, "application/pkcs7-mime")
+
+	config.Provider, config.APIKey = ProviderSendGrid, "key"
+	_, err = NewSender(config)
+	assert.ErrorIs(t, err, ErrUnsupportedFeature)
+}
+
+// TestSMIMEOpenSSLInterop 使用 openssl 独立校验签名并解密，未安装 openssl 时跳过
+func TestSMIMEOpenSSLInterop(t *testing.T) {
+	openssl, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Skip("openssl is not installed")
+	}
+	dir := t.TempDir()
+	writePEM := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+		return path
+	}
+	run := func(args ...string) []byte {
+		out, err := exec.Command(openssl, args...).CombinedOutput()
+		assert.NoError(t, err, string(out))
+		return out
+	}
+	signCert, signKey := newTestCertificate(t, "finance@example.com")
+	bossCert, bossKey := newTestCertificate(t, "boss@example.com")
+	signCertPath := writePEM("finance.pem", "CERTIFICATE", signCert.Raw)
+	bossCertPath := writePEM("boss.pem", "CERTIFICATE", bossCert.Raw)
+	bossKeyPath := writePEM("boss.key", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(bossKey))
+	signer, _ := NewSMIMESigner(signCert, signKey)
+	encryptor, _ := NewSMIMEEncryptor(bossCert)
+
+	raw, err := BuildMessage(protectedMessage(), signer, encryptor)
+	assert.NoError(t, err)
+	encrypted := filepath.Join(dir, "encrypted.eml")
+	assert.NoError(t, os.WriteFile(encrypted, raw, 0o600))
+	signed := filepath.Join(dir, "signed.eml")
+	run("smime", "-decrypt", "-in", encrypted, "-recip", bossCertPath, "-inkey", bossKeyPath, "-out", signed)
+
+	content := filepath.Join(dir, "content.eml")
+	run("smime", "-verify", "-in", signed, "-CAfile", signCertPath, "-purpose", "any", "-out", content)
+	data, err := os.ReadFile(content)
+	assert.NoError(t, err)
+	inner, err := ParseMessage(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "见附件", inner.Text)
+	assert.Len(t, inner.Attachments, 1)
+
+	// 篡改被签名内容后校验失败
+	tampered, _ := os.ReadFile(signed)
+	tampered = bytes.Replace(tampered, []byte("Content-Transfer-Encoding"), []byte("Content-transfer-encoding"), 1)
+	assert.NoError(t, os.WriteFile(signed, tampered, 0o600))
+	out, err := exec.Command(openssl, "smime", "-verify", "-in", signed, "-CAfile", signCertPath, "-purpose", "any").CombinedOutput()
+	assert.Error(t, err)
+	assert.Contains(t, string(out), "digest failure")
+}
@@ -3,22 +3,25 @@ package email
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
-	"mime"
-	"mime/multipart"
 	"net"
 	"net/smtp"
 	"net/textproto"
-	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// errDataAborted 邮件内容未完整写入 DATA
+var errDataAborted = errors.New("smtp data aborted")
+
 // SMTPSender SMTP邮件发送器
 type SMTPSender struct {
 	config *Config
-	auth   smtp.Auth
+	pool   *connPool
 }
 
 // NewSMTPSender 创建SMTP邮件发送器
@@ -31,15 +34,11 @@ func NewSMTPSender(config *Config) (*SMTPSender, error) {
 		return nil, err
 	}
 
-	var auth smtp.Auth
-	if config.Username != "" && config.Password != "" {
-		auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
-	}
-
-	return &SMTPSender{
+	s := &SMTPSender{
 		config: config,
-		auth:   auth,
-	}, nil
+	}
+	s.pool = newConnPool(config.PoolSize, config.KeepAlivePeriod, s.connect)
+	return s, nil
 }
 
 // Send 发送邮件
@@ -57,33 +56,111 @@ func (s *SMTPSender) Send(ctx context.Context, message *Message) error {
 	}
 
 	// 构建邮件内容
-	body, err := s.buildMessage(message)
+	write, err := s.payload(message)
 	if err != nil {
-		return fmt.Errorf("failed to build message: %w", err)
+		return err
 	}
 
 	// 发送邮件
-	return s.sendWithRetry(ctx, message, body)
+	return s.sendWithRetry(ctx, message, write)
 }
 
-// SendBatch 批量发送邮件
-func (s *SMTPSender) SendBatch(ctx context.Context, messages []*Message) error {
-	for _, msg := range messages {
-		if err := s.Send(ctx, msg); err != nil {
-			return err
+// payload 返回写入 DATA 的邮件内容。默认每次投递时将 WriteMessage 流式写入 DATA，重试前将附件 Seek 回起始位置；
+// 以下情况退化为预先用 BuildMessage 构建到内存：配置 DKIM（需对完整邮件签名）、配置 Protectors（签名加密本就需要完整内容）、
+// 附件不可 Seek（重试时无法重新读取）
+func (s *SMTPSender) payload(message *Message) (func(w io.Writer) error, error) {
+	offsets, seekable := attachmentOffsets(message)
+	if s.config.DKIM == nil && len(s.config.Protectors) == 0 && seekable {
+		// 固定 Message-ID，重试时重新生成的邮件与首次投递一致
+		pinned := *message
+		pinned.Headers = make(map[string]string, len(message.Headers)+1)
+		for k, v := range message.Headers {
+			pinned.Headers[textproto.CanonicalMIMEHeaderKey(k)] = v
 		}
+		if _, ok := pinned.Headers["Message-Id"]; !ok {
+			pinned.Headers["Message-Id"] = newMessageID(message.From)
+		}
+		return func(w io.Writer) error {
+			for i, a := range message.Attachments {
+				if _, err := a.Data.(io.Seeker).Seek(offsets[i], io.SeekStart); err != nil {
+					return fmt.Errorf("%w: %v", ErrInvalidAttachment, err)
+				}
+			}
+			return WriteMessage(w, &pinned)
+		}, nil
 	}
-	return nil
+
+	body, err := BuildMessage(message, s.config.Protectors...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build message: %w", err)
+	}
+	if s.config.DKIM != nil {
+		if body, err = s.config.DKIM.Sign(body); err != nil {
+			return nil, fmt.Errorf("failed to sign message: %w", err)
+		}
+	}
+	return func(w io.Writer) error {
+		_, err := w.Write(body)
+		return err
+	}, nil
+}
+
+// attachmentOffsets 记录附件读取的起始位置，任一附件不可 Seek 时返回 false
+func attachmentOffsets(message *Message) ([]int64, bool) {
+	offsets := make([]int64, len(message.Attachments))
+	for i, a := range message.Attachments {
+		seeker, ok := a.Data.(io.Seeker)
+		if !ok {
+			return nil, false
+		}
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, false
+		}
+		offsets[i] = offset
+	}
+	return offsets, true
 }
 
-// Close 关闭连接
+// SendBatch 批量发送邮件，通过连接池并发发送；部分失败时返回 *BatchError，包含每封邮件的发送结果
+func (s *SMTPSender) SendBatch(ctx context.Context, messages []*Message) error {
+	errs := make([]error, len(messages))
+	workers := s.config.PoolSize
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(messages) {
+		workers = len(messages)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				errs[idx] = s.Send(ctx, messages[idx])
+			}
+		}()
+	}
+	for i := range messages {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return newBatchError(errs)
+}
+
+// Close 关闭连接池
 func (s *SMTPSender) Close() error {
-	// SMTP连接是短连接,不需要特殊关闭
+	s.pool.close()
 	return nil
 }
 
 // sendWithRetry 发送邮件(带重试)
-func (s *SMTPSender) sendWithRetry(ctx context.Context, message *Message, body []byte) error {
+func (s *SMTPSender) sendWithRetry(ctx context.Context, message *Message, write func(w io.Writer) error) error {
 	var lastErr error
 
 	for i := 0; i < s.config.MaxRetries; i++ {
@@ -95,7 +172,7 @@ func (s *SMTPSender) sendWithRetry(ctx context.Context, message *Message, body [
 			}
 		}
 
-		if err := s.send(ctx, message, body); err != nil {
+		if err := s.send(ctx, message, write); err != nil {
 			lastErr = err
 			continue
 		}
@@ -104,203 +181,185 @@ func (s *SMTPSender) sendWithRetry(ctx context.Context, message *Message, body [
 	}
 
 	if lastErr != nil {
-		return fmt.Errorf("%w: %v", ErrTooManyRetries, lastErr)
+		return fmt.Errorf("%w: %w", ErrTooManyRetries, lastErr)
 	}
 	return ErrSendFailed
 }
 
-// send 实际发送邮件
-func (s *SMTPSender) send(ctx context.Context, message *Message, body []byte) error {
+// send 实际发送邮件，开启 KeepAlive 时复用连接池中的会话
+func (s *SMTPSender) send(ctx context.Context, message *Message, write func(w io.Writer) error) error {
+	c, err := s.pool.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.deliver(ctx, c, message, write)
+	if errors.Is(err, errDataAborted) {
+		// 写入中途失败时 DATA 未结束，会话已不可用，直接断开避免服务器收下不完整的邮件
+		c.conn.Close()
+		s.pool.put(c, false)
+		return err
+	}
+	if !s.config.KeepAlive {
+		s.pool.put(c, false)
+		return err
+	}
+	// 无论成功与否都以 RSET 重置会话，重置失败说明连接已不可用
+	s.pool.put(c, c.client.Reset() == nil)
+	return err
+}
+
+// connect 建立连接并完成 TLS 与认证
+func (s *SMTPSender) connect(ctx context.Context) (*smtpConn, error) {
+	mode := s.tlsMode()
+
 	// 创建带超时的连接
-	conn, err := s.dial(ctx)
+	conn, err := s.dial(ctx, mode == TLSImplicit)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrConnectFailed, err)
+		return nil, fmt.Errorf("%w: %v", ErrConnectFailed, err)
+	}
+	if s.config.ConnectTimeout > 0 {
+		conn.SetDeadline(time.Now().Add(s.config.ConnectTimeout))
 	}
-	defer conn.Close()
 
 	// 创建SMTP客户端
 	client, err := smtp.NewClient(conn, s.config.Host)
 	if err != nil {
-		return err
+		conn.Close()
+		return nil, fmt.Errorf("%w: %v", ErrConnectFailed, err)
 	}
-	defer client.Close()
 
-	// 如果支持STARTTLS,启用TLS
-	if s.config.UseTLS {
+	// STARTTLS 升级
+	if mode == TLSStartTLS || mode == TLSStartTLSOpportunistic {
 		if ok, _ := client.Extension("STARTTLS"); ok {
-			tlsConfig := &tls.Config{
-				ServerName:         s.config.Host,
-				InsecureSkipVerify: s.config.InsecureSkipTLS,
-			}
-			if err := client.StartTLS(tlsConfig); err != nil {
-				return err
+			if err := client.StartTLS(s.tlsConfig()); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("%w: %v", ErrConnectFailed, err)
 			}
+		} else if mode == TLSStartTLS {
+			client.Close()
+			return nil, fmt.Errorf("%w: server does not support STARTTLS", ErrConnectFailed)
 		}
 	}
 
 	// 认证
-	if s.auth != nil {
-		if err := client.Auth(s.auth); err != nil {
+	if err := s.authenticate(ctx, client); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &smtpConn{conn: conn, client: client, lastUsed: time.Now()}, nil
+}
+
+// authenticate 按配置或服务器通告的方式认证，未配置凭据时跳过
+func (s *SMTPSender) authenticate(ctx context.Context, client *smtp.Client) error {
+	secret := s.config.Password
+	if s.config.AuthMechanism == AuthXOAUTH2 && s.config.TokenSource != nil {
+		token, err := s.config.TokenSource(ctx)
+		if err != nil {
 			return fmt.Errorf("%w: %v", ErrAuthFailed, err)
 		}
+		secret = token
 	}
-
-	// 设置发件人
-	if err := client.Mail(s.extractEmail(message.From)); err != nil {
-		return err
+	if s.config.Username == "" || secret == "" {
+		return nil
 	}
 
-	// 设置收件人
-	recipients := append(message.To, message.Cc...)
-	recipients = append(recipients, message.Bcc...)
-	for _, addr := range recipients {
-		if err := client.Rcpt(addr); err != nil {
-			return err
+	mechanism := s.config.AuthMechanism
+	if mechanism == "" {
+		ok, advertised := client.Extension("AUTH")
+		if !ok {
+			return fmt.Errorf("%w: server does not support AUTH", ErrAuthFailed)
+		}
+		if mechanism, ok = selectMechanism(advertised); !ok {
+			return fmt.Errorf("%w: no supported mechanism in %q", ErrAuthFailed, advertised)
 		}
 	}
 
-	// 发送邮件内容
-	w, err := client.Data()
+	auth, err := newAuth(mechanism, s.config.Username, secret, s.config.Host)
 	if err != nil {
 		return err
 	}
-
-	if _, err := w.Write(body); err != nil {
-		return err
-	}
-
-	if err := w.Close(); err != nil {
-		return err
+	if err := client.Auth(auth); err != nil {
+		return fmt.Errorf("%w: %v", ErrAuthFailed, err)
 	}
-
-	return client.Quit()
+	return nil
 }
 
-// dial 创建连接
-func (s *SMTPSender) dial(ctx context.Context) (net.Conn, error) {
-	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
-
-	dialer := &net.Dialer{
-		Timeout:   s.config.ConnectTimeout,
-		KeepAlive: s.config.KeepAlivePeriod,
+// tlsMode 返回实际使用的 TLS 模式，未配置时 465 端口使用隐式 TLS，UseTLS 开启时按需 STARTTLS
+func (s *SMTPSender) tlsMode() TLSMode {
+	switch {
+	case s.config.TLSMode != "":
+		return s.config.TLSMode
+	case s.config.Port == 465:
+		return TLSImplicit
+	case s.config.UseTLS:
+		return TLSStartTLSOpportunistic
+	default:
+		return TLSNone
 	}
-
-	return dialer.DialContext(ctx, "tcp", addr)
 }
 
-// buildMessage 构建邮件内容
-func (s *SMTPSender) buildMessage(message *Message) ([]byte, error) {
-	var buf strings.Builder
-
-	// 邮件头
-	s.writeHeaders(&buf, message)
-
-	// 邮件体
-	if message.HasAttachment() {
-		return s.buildMIMEMessage(&buf, message)
+func (s *SMTPSender) tlsConfig() *tls.Config {
+	return &tls.Config{
+		ServerName:         s.config.Host,
+		InsecureSkipVerify: s.config.InsecureSkipTLS,
 	}
-
-	if message.IsHTML() {
-		buf.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
-		buf.WriteString("\r\n")
-		buf.WriteString(message.HTML)
-	} else {
-		buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
-		buf.WriteString("\r\n")
-		buf.WriteString(message.Text)
-	}
-
-	return []byte(buf.String()), nil
 }
 
-// writeHeaders 写入邮件头
-func (s *SMTPSender) writeHeaders(buf *strings.Builder, message *Message) {
-	buf.WriteString(fmt.Sprintf("From: %s\r\n", message.From))
-	buf.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(message.To, ", ")))
-
-	if len(message.Cc) > 0 {
-		buf.WriteString(fmt.Sprintf("Cc: %s\r\n", strings.Join(message.Cc, ", ")))
+// deliver 在已建立的会话上投递一封邮件
+func (s *SMTPSender) deliver(ctx context.Context, c *smtpConn, message *Message, write func(w io.Writer) error) error {
+	deadline := time.Time{}
+	if s.config.SendTimeout > 0 {
+		deadline = time.Now().Add(s.config.SendTimeout)
 	}
-
-	if message.ReplyTo != "" {
-		buf.WriteString(fmt.Sprintf("Reply-To: %s\r\n", message.ReplyTo))
-	}
-
-	buf.WriteString(fmt.Sprintf("Subject: %s\r\n", s.encodeSubject(message.Subject)))
-	buf.WriteString("MIME-Version: 1.0\r\n")
-	buf.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
-
-	// 优先级
-	if message.Priority != PriorityNormal {
-		buf.WriteString(fmt.Sprintf("X-Priority: %d\r\n", message.Priority))
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
 	}
+	c.conn.SetDeadline(deadline)
 
-	// 自定义邮件头
-	for key, value := range message.Headers {
-		buf.WriteString(fmt.Sprintf("%s: %s\r\n", key, value))
-	}
-}
-
-// buildMIMEMessage 构建MIME邮件(带附件)
-func (s *SMTPSender) buildMIMEMessage(buf *strings.Builder, message *Message) ([]byte, error) {
-	writer := multipart.NewWriter(buf)
-	boundary := writer.Boundary()
-
-	buf.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=%s\r\n\r\n", boundary))
-
-	// 邮件正文
-	if message.IsHTML() {
-		s.writeMIMEPart(writer, "text/html", message.HTML)
-	} else {
-		s.writeMIMEPart(writer, "text/plain", message.Text)
+	// 设置发件人
+	if err := c.client.Mail(s.extractEmail(message.From)); err != nil {
+		return err
 	}
 
-	// 附件
-	for _, attachment := range message.Attachments {
-		if err := s.writeAttachment(writer, attachment); err != nil {
-			return nil, err
+	// 设置收件人
+	recipients := make([]string, 0, len(message.To)+len(message.Cc)+len(message.Bcc))
+	recipients = append(recipients, message.To...)
+	recipients = append(recipients, message.Cc...)
+	recipients = append(recipients, message.Bcc...)
+	for _, addr := range recipients {
+		if err := c.client.Rcpt(s.extractEmail(addr)); err != nil {
+			return err
 		}
 	}
 
-	writer.Close()
-	return []byte(buf.String()), nil
-}
-
-// writeMIMEPart 写入MIME部分
-func (s *SMTPSender) writeMIMEPart(writer *multipart.Writer, contentType, content string) error {
-	header := textproto.MIMEHeader{}
-	header.Set("Content-Type", contentType+"; charset=UTF-8")
-	header.Set("Content-Transfer-Encoding", "quoted-printable")
-
-	part, err := writer.CreatePart(header)
+	// 发送邮件内容
+	w, err := c.client.Data()
 	if err != nil {
 		return err
 	}
 
-	_, err = part.Write([]byte(content))
-	return err
+	if err := write(w); err != nil {
+		return fmt.Errorf("%w: %w", errDataAborted, err)
+	}
+
+	return w.Close()
 }
 
-// writeAttachment 写入附件
-func (s *SMTPSender) writeAttachment(writer *multipart.Writer, attachment *Attachment) error {
-	header := textproto.MIMEHeader{}
+// dial 创建连接，implicit 为 true 时直接建立 TLS 连接
+func (s *SMTPSender) dial(ctx context.Context, implicit bool) (net.Conn, error) {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
 
-	if attachment.ContentType == "" {
-		attachment.ContentType = "application/octet-stream"
+	dialer := &net.Dialer{
+		Timeout:   s.config.ConnectTimeout,
+		KeepAlive: s.config.KeepAlivePeriod,
 	}
-
-	header.Set("Content-Type", attachment.ContentType)
-	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s",
-		mime.QEncoding.Encode("UTF-8", filepath.Base(attachment.Filename))))
-	header.Set("Content-Transfer-Encoding", "base64")
-
-	part, err := writer.CreatePart(header)
-	if err != nil {
-		return err
+	if implicit {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: s.tlsConfig()}
+		return tlsDialer.DialContext(ctx, "tcp", addr)
 	}
 
-	_, err = io.Copy(part, attachment.Data)
-	return err
+	return dialer.DialContext(ctx, "tcp", addr)
 }
 
 // formatAddress 格式化邮箱地址
@@ -321,11 +380,6 @@ func (s *SMTPSender) extractEmail(addr string) string {
 	return addr
 }
 
-// encodeSubject 编码主题
-func (s *SMTPSender) encodeSubject(subject string) string {
-	return mime.QEncoding.Encode("UTF-8", subject)
-}
-
 // validateConfig 验证配置
 func validateConfig(config *Config) error {
 	if config.Host == "" {
@@ -334,5 +388,15 @@ func validateConfig(config *Config) error {
 	if config.Port <= 0 || config.Port > 65535 {
 		return fmt.Errorf("%w: invalid port", ErrInvalidConfig)
 	}
+	switch config.TLSMode {
+	case "", TLSNone, TLSStartTLS, TLSStartTLSOpportunistic, TLSImplicit:
+	default:
+		return fmt.Errorf("%w: invalid TLS mode %q", ErrInvalidConfig, config.TLSMode)
+	}
+	switch config.AuthMechanism {
+	case "", AuthPlain, AuthLogin, AuthCRAMMD5, AuthXOAUTH2:
+	default:
+		return fmt.Errorf("%w: unsupported auth mechanism %q", ErrInvalidConfig, config.AuthMechanism)
+	}
 	return nil
 }
@@ -0,0 +1,286 @@
+package email
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeMail 测试服务器收到的邮件
+type fakeMail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// fakeSMTPServer 进程内 SMTP 服务器，仅实现测试所需的命令
+type fakeSMTPServer struct {
+	ln     net.Listener
+	reject map[string]bool // 拒收的收件人
+
+	// 以下字段通过 newFakeSMTPServer 的选项设置
+	username, password string
+	mechanisms         []string    // EHLO 通告的认证方式
+	tlsConfig          *tls.Config // 非空时支持 STARTTLS
+	implicitTLS        bool        // 建连即 TLS
+	noStartTLS         bool        // 不通告 STARTTLS
+
+	conns    int32
+	commands sync.Map // 命令 -> *int32 计数
+
+	mu       sync.Mutex
+	mails    []fakeMail
+	authUsed []string // 认证成功的方式
+	tlsUsed  []bool   // 每次认证时连接是否已加密
+}
+
+func newFakeSMTPServer(t *testing.T, opts ...func(*fakeSMTPServer)) *fakeSMTPServer {
+	s := &fakeSMTPServer{
+		reject:     map[string]bool{},
+		username:   "user",
+		password:   "pass",
+		mechanisms: []string{"PLAIN"},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.implicitTLS {
+		ln = tls.NewListener(ln, s.tlsConfig)
+	}
+	s.ln = ln
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+// withTLS 使用自签名证书开启 TLS
+func withTLS(t *testing.T) func(*fakeSMTPServer) {
+	return func(s *fakeSMTPServer) {
+		s.tlsConfig = selfSignedTLS(t)
+	}
+}
+
+// config 返回指向该服务器的发送配置
+func (s *fakeSMTPServer) config() *Config {
+	config := DefaultConfig()
+	config.Host = "127.0.0.1"
+	config.Port = s.ln.Addr().(*net.TCPAddr).Port
+	config.Username = s.username
+	config.Password = s.password
+	config.UseTLS = false
+	config.InsecureSkipTLS = true
+	config.MaxRetries = 1
+	config.RetryInterval = 0
+	return config
+}
+
+func (s *fakeSMTPServer) connCount() int {
+	return int(atomic.LoadInt32(&s.conns))
+}
+
+func (s *fakeSMTPServer) commandCount(cmd string) int {
+	if v, ok := s.commands.Load(cmd); ok {
+		return int(atomic.LoadInt32(v.(*int32)))
+	}
+	return 0
+}
+
+func (s *fakeSMTPServer) received() []fakeMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeMail(nil), s.mails...)
+}
+
+func (s *fakeSMTPServer) auths() ([]string, []bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.authUsed...), append([]bool(nil), s.tlsUsed...)
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		atomic.AddInt32(&s.conns, 1)
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer func() { conn.Close() }()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 fake ESMTP")
+
+	_, secure := conn.(*tls.Conn)
+	var mail fakeMail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		cmd = strings.ToUpper(cmd)
+		v, _ := s.commands.LoadOrStore(cmd, new(int32))
+		atomic.AddInt32(v.(*int32), 1)
+
+		switch cmd {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-fake")
+			if s.tlsConfig != nil && !secure && !s.noStartTLS {
+				tp.PrintfLine("250-STARTTLS")
+			}
+			tp.PrintfLine("250-PIPELINING")
+			tp.PrintfLine("250 AUTH %s", strings.Join(s.mechanisms, " "))
+		case "STARTTLS":
+			tp.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			tp = textproto.NewConn(conn)
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			mechanism = strings.ToUpper(mechanism)
+			if s.auth(tp, mechanism, initial) {
+				s.mu.Lock()
+				s.authUsed = append(s.authUsed, mechanism)
+				s.tlsUsed = append(s.tlsUsed, secure)
+				s.mu.Unlock()
+				tp.PrintfLine("235 2.7.0 Authentication successful")
+			} else {
+				tp.PrintfLine("535 5.7.8 Authentication credentials invalid")
+			}
+		case "MAIL":
+			mail = fakeMail{From: addrArg(arg)}
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			addr := addrArg(arg)
+			if s.reject[addr] {
+				tp.PrintfLine("550 5.1.1 mailbox unavailable")
+				continue
+			}
+			mail.To = append(mail.To, addr)
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.Data = string(data)
+			s.mu.Lock()
+			s.mails = append(s.mails, mail)
+			n := len(s.mails)
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK queued as %d", n)
+		case "RSET":
+			mail = fakeMail{}
+			tp.PrintfLine("250 OK")
+		case "NOOP":
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+// auth 按认证方式完成交互并校验凭据
+func (s *fakeSMTPServer) auth(tp *textproto.Conn, mechanism, initial string) bool {
+	challenge := func(msg string) (string, bool) {
+		tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(msg)))
+		line, err := tp.ReadLine()
+		if err != nil {
+			return "", false
+		}
+		b, err := base64.StdEncoding.DecodeString(line)
+		return string(b), err == nil
+	}
+	decode := func(s string) string {
+		b, _ := base64.StdEncoding.DecodeString(s)
+		return string(b)
+	}
+
+	switch mechanism {
+	case "PLAIN":
+		parts := strings.Split(decode(initial), "\x00")
+		return len(parts) == 3 && parts[1] == s.username && parts[2] == s.password
+	case "LOGIN":
+		user, ok := challenge("Username:")
+		if !ok {
+			return false
+		}
+		pass, ok := challenge("Password:")
+		return ok && user == s.username && pass == s.password
+	case "CRAM-MD5":
+		nonce := "<12345.67890@fake>"
+		resp, ok := challenge(nonce)
+		if !ok {
+			return false
+		}
+		mac := hmac.New(md5.New, []byte(s.password))
+		mac.Write([]byte(nonce))
+		return resp == s.username+" "+hex.EncodeToString(mac.Sum(nil))
+	case "XOAUTH2":
+		if decode(initial) == "user="+s.username+"\x01auth=Bearer "+s.password+"\x01\x01" {
+			return true
+		}
+		// 失败时先返回错误详情，客户端应答空行后再返回 535
+		challenge(`{"status":"401","schemes":"bearer"}`)
+		return false
+	}
+	return false
+}
+
+// addrArg 提取 "FROM:<a@b>" 形式参数中的地址
+func addrArg(arg string) string {
+	start, end := strings.Index(arg, "<"), strings.Index(arg, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return arg[start+1 : end]
+}
+
+// selfSignedTLS 生成 127.0.0.1 的自签名证书
+func selfSignedTLS(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake smtp"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
@@ -0,0 +1,145 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMTPSenderReuseConnection(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	config := server.config()
+	config.PoolSize = 1
+	sender, err := NewSMTPSender(config)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err := sender.Send(context.Background(), &Message{
+			From:    "sender@example.com",
+			To:      []string{"a@example.com"},
+			Subject: fmt.Sprintf("hello %d", i),
+			Text:    "body",
+		})
+		assert.NoError(t, err)
+	}
+	assert.Len(t, server.received(), 3)
+	assert.Equal(t, 1, server.connCount())
+	assert.Equal(t, 1, server.commandCount("AUTH"))
+	assert.Equal(t, 3, server.commandCount("RSET"))
+
+	assert.NoError(t, sender.Close())
+	_, err = sender.pool.get(context.Background())
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
+
+func TestSMTPSenderWithoutKeepAlive(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	config := server.config()
+	config.KeepAlive = false
+	sender, err := NewSMTPSender(config)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, sender.Send(context.Background(), &Message{
+			From: "sender@example.com", To: []string{"a@example.com"}, Subject: "hi", Text: "body",
+		}))
+	}
+	assert.Equal(t, 2, server.connCount())
+	assert.Equal(t, 2, server.commandCount("QUIT"))
+}
+
+func TestSMTPSenderSendBatch(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.reject["bad@example.com"] = true
+	config := server.config()
+	config.PoolSize = 3
+	sender, err := NewSMTPSender(config)
+	assert.NoError(t, err)
+	defer sender.Close()
+
+	var messages []*Message
+	for i := 0; i < 10; i++ {
+		to := fmt.Sprintf("user%d@example.com", i)
+		if i == 4 || i == 7 {
+			to = "bad@example.com"
+		}
+		messages = append(messages, &Message{From: "sender@example.com", To: []string{to}, Subject: "batch", Text: "body"})
+	}
+
+	err = sender.SendBatch(context.Background(), messages)
+	var batchErr *BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, []int{4, 7}, batchErr.Failed())
+	assert.Len(t, batchErr.Errors, 10)
+	assert.ErrorIs(t, err, ErrTooManyRetries)
+	assert.Len(t, server.received(), 8)
+	assert.LessOrEqual(t, server.connCount(), 3)
+
+	// 被拒收后会话经 RSET 恢复，仍可继续复用
+	assert.NoError(t, sender.SendBatch(context.Background(), messages[:3]))
+	assert.LessOrEqual(t, server.connCount(), 3)
+}
+
+// flakyReader 首次读取时返回错误，Seek 回起点后可正常读取
+type flakyReader struct {
+	r      *bytes.Reader
+	failed bool
+}
+
+func (r *flakyReader) Read(p []byte) (int, error) {
+	if !r.failed {
+		r.failed = true
+		return 0, io.ErrUnexpectedEOF
+	}
+	return r.r.Read(p)
+}
+
+func (r *flakyReader) Seek(offset int64, whence int) (int64, error) {
+	return r.r.Seek(offset, whence)
+}
+
+func TestSMTPSenderStreamAttachment(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	config := server.config()
+	config.MaxRetries = 2
+	sender, err := NewSMTPSender(config)
+	assert.NoError(t, err)
+	defer sender.Close()
+
+	content := strings.Repeat("attachment ", 1000)
+	data := &flakyReader{r: bytes.NewReader([]byte("skip" + content))}
+	data.Seek(4, io.SeekStart)
+	assert.NoError(t, sender.Send(context.Background(), &Message{
+		From:        "sender@example.com",
+		To:          []string{"a@example.com"},
+		Subject:     "report",
+		Text:        "body",
+		Attachments: []*Attachment{{Filename: "a.txt", Data: data}},
+	}))
+
+	// 首次写入中途失败的会话被断开，服务器没有收下不完整的邮件；重试时附件从原起始位置重新读取
+	mails := server.received()
+	assert.Len(t, mails, 1)
+	assert.Equal(t, 2, server.connCount())
+	received, err := ParseMessage(strings.NewReader(mails[0].Data))
+	assert.NoError(t, err)
+	assert.Len(t, received.Attachments, 1)
+	b, _ := io.ReadAll(received.Attachments[0].Data)
+	assert.Equal(t, content, string(b))
+
+	// 不可 Seek 的附件预先构建到内存
+	assert.NoError(t, sender.Send(context.Background(), &Message{
+		From:        "sender@example.com",
+		To:          []string{"a@example.com"},
+		Subject:     "report",
+		Text:        "body",
+		Attachments: []*Attachment{{Filename: "b.txt", Data: io.MultiReader(strings.NewReader(content))}},
+	}))
+	assert.Len(t, server.received(), 2)
+}
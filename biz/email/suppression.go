@@ -0,0 +1,181 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	EMAIL_SUPPRESSION = "email.suppression.%s.%s" // 抑制名单，STRING 名单名称、邮箱 -> 抑制记录
+)
+
+// SuppressionReason 抑制原因
+type SuppressionReason string
+
+const (
+	SuppressHardBounce  SuppressionReason = "hard_bounce" // 硬退信
+	SuppressSoftBounce  SuppressionReason = "soft_bounce" // 软退信，通常设置有效期
+	SuppressComplaint   SuppressionReason = "complaint"   // 投诉
+	SuppressUnsubscribe SuppressionReason = "unsubscribe" // 退订
+	SuppressManual      SuppressionReason = "manual"      // 手动添加
+)
+
+// Suppression 抑制记录，名单中的地址不再发送邮件
+type Suppression struct {
+	Address   string            `json:"address"`
+	Reason    SuppressionReason `json:"reason"`
+	Detail    string            `json:"detail,omitempty"`     // 如退信诊断信息
+	CreatedAt time.Time         `json:"created_at"`           // 加入时间
+	ExpiresAt time.Time         `json:"expires_at,omitempty"` // 过期时间，零值表示永久
+}
+
+// expired 是否已过期
+func (s *Suppression) expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+// SuppressionList 抑制名单，地址不区分大小写
+type SuppressionList interface {
+	// Add 加入名单，已存在时覆盖
+	Add(ctx context.Context, suppression *Suppression) error
+
+	// Remove 移出名单
+	Remove(ctx context.Context, address string) error
+
+	// Get 查询地址，不在名单中或已过期时返回 nil
+	Get(ctx context.Context, address string) (*Suppression, error)
+}
+
+// SuppressBounces 将退信加入抑制名单：硬退信永久抑制，软退信抑制 softTTL，softTTL 为 0 时忽略软退信
+func SuppressBounces(ctx context.Context, list SuppressionList, bounces []*Bounce, softTTL time.Duration) error {
+	now := time.Now()
+	for _, bounce := range bounces {
+		s := &Suppression{Address: bounce.Recipient, Detail: bounce.DiagnosticCode, CreatedAt: now}
+		switch {
+		case bounce.Type == BounceHard:
+			s.Reason = SuppressHardBounce
+		case softTTL > 0:
+			s.Reason = SuppressSoftBounce
+			s.ExpiresAt = now.Add(softTTL)
+		default:
+			continue
+		}
+		if err := list.Add(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func normalizeAddress(address string) string {
+	return strings.ToLower(strings.TrimSpace(parseAddress(address).Address))
+}
+
+// MemorySuppressionList 内存抑制名单，适用于单实例或测试
+type MemorySuppressionList struct {
+	mu      sync.RWMutex
+	entries map[string]*Suppression
+}
+
+// NewMemorySuppressionList 创建内存抑制名单
+func NewMemorySuppressionList() *MemorySuppressionList {
+	return &MemorySuppressionList{entries: map[string]*Suppression{}}
+}
+
+// Add 加入名单
+func (l *MemorySuppressionList) Add(ctx context.Context, suppression *Suppression) error {
+	s := *suppression
+	s.Address = normalizeAddress(s.Address)
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+	}
+	l.mu.Lock()
+	l.entries[s.Address] = &s
+	l.mu.Unlock()
+	return nil
+}
+
+// Remove 移出名单
+func (l *MemorySuppressionList) Remove(ctx context.Context, address string) error {
+	l.mu.Lock()
+	delete(l.entries, normalizeAddress(address))
+	l.mu.Unlock()
+	return nil
+}
+
+// Get 查询地址
+func (l *MemorySuppressionList) Get(ctx context.Context, address string) (*Suppression, error) {
+	l.mu.RLock()
+	s := l.entries[normalizeAddress(address)]
+	l.mu.RUnlock()
+	if s == nil || s.expired(time.Now()) {
+		return nil, nil
+	}
+	copied := *s
+	return &copied, nil
+}
+
+// RedisSuppressionList Redis 抑制名单，多实例共享，有效期通过 key 过期实现
+type RedisSuppressionList struct {
+	name   string
+	client *redis.Client
+}
+
+// NewRedisSuppressionList 创建Redis抑制名单，name 用于区分 Redis key
+func NewRedisSuppressionList(name string, client *redis.Client) *RedisSuppressionList {
+	return &RedisSuppressionList{name: name, client: client}
+}
+
+func (l *RedisSuppressionList) key(address string) string {
+	return fmt.Sprintf(EMAIL_SUPPRESSION, l.name, normalizeAddress(address))
+}
+
+// Add 加入名单
+func (l *RedisSuppressionList) Add(ctx context.Context, suppression *Suppression) error {
+	s := *suppression
+	s.Address = normalizeAddress(s.Address)
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+	}
+	var ttl time.Duration
+	if !s.ExpiresAt.IsZero() {
+		if ttl = time.Until(s.ExpiresAt); ttl <= 0 {
+			return l.Remove(ctx, s.Address)
+		}
+	}
+	data, err := json.Marshal(&s)
+	if err != nil {
+		return err
+	}
+	return l.client.Set(ctx, l.key(s.Address), data, ttl).Err()
+}
+
+// Remove 移出名单
+func (l *RedisSuppressionList) Remove(ctx context.Context, address string) error {
+	return l.client.Del(ctx, l.key(address)).Err()
+}
+
+// Get 查询地址
+func (l *RedisSuppressionList) Get(ctx context.Context, address string) (*Suppression, error) {
+	data, err := l.client.Get(ctx, l.key(address)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &Suppression{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.expired(time.Now()) {
+		return nil, nil
+	}
+	return s, nil
+}
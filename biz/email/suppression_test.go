@@ -0,0 +1,70 @@
+package email
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuppressionList(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	lists := map[string]SuppressionList{
+		"memory": NewMemorySuppressionList(),
+		"redis":  NewRedisSuppressionList("test", redis.NewClient(&redis.Options{Addr: mr.Addr()})),
+	}
+	for name, list := range lists {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, list.Add(ctx, &Suppression{Address: "User <User@Example.com>", Reason: SuppressUnsubscribe}))
+			s, err := list.Get(ctx, "user@example.COM")
+			assert.NoError(t, err)
+			assert.Equal(t, "user@example.com", s.Address)
+			assert.Equal(t, SuppressUnsubscribe, s.Reason)
+			assert.False(t, s.CreatedAt.IsZero())
+
+			assert.NoError(t, list.Remove(ctx, "user@example.com"))
+			s, err = list.Get(ctx, "user@example.com")
+			assert.NoError(t, err)
+			assert.Nil(t, s)
+
+			// 已过期的记录视为不存在
+			assert.NoError(t, list.Add(ctx, &Suppression{Address: "old@example.com", Reason: SuppressManual, ExpiresAt: time.Now().Add(-time.Second)}))
+			s, _ = list.Get(ctx, "old@example.com")
+			assert.Nil(t, s)
+		})
+	}
+
+	// Redis 记录按有效期过期
+	list := lists["redis"]
+	assert.NoError(t, list.Add(ctx, &Suppression{Address: "soft@example.com", Reason: SuppressSoftBounce, ExpiresAt: time.Now().Add(time.Hour)}))
+	assert.True(t, mr.Exists("email.suppression.test.soft@example.com"))
+	mr.FastForward(2 * time.Hour)
+	s, _ := list.Get(ctx, "soft@example.com")
+	assert.Nil(t, s)
+}
+
+func TestSuppressBounces(t *testing.T) {
+	ctx := context.Background()
+	bounces := []*Bounce{
+		{Recipient: "gone@example.com", Type: BounceHard, Status: "5.1.1", DiagnosticCode: "smtp; 550 5.1.1 user unknown"},
+		{Recipient: "full@example.com", Type: BounceSoft, Status: "4.2.2"},
+	}
+
+	list := NewMemorySuppressionList()
+	assert.NoError(t, SuppressBounces(ctx, list, bounces, 0))
+	s, _ := list.Get(ctx, "gone@example.com")
+	assert.Equal(t, SuppressHardBounce, s.Reason)
+	assert.True(t, s.ExpiresAt.IsZero())
+	assert.Contains(t, s.Detail, "user unknown")
+	s, _ = list.Get(ctx, "full@example.com")
+	assert.Nil(t, s)
+
+	assert.NoError(t, SuppressBounces(ctx, list, bounces, time.Hour))
+	s, _ = list.Get(ctx, "full@example.com")
+	assert.Equal(t, SuppressSoftBounce, s.Reason)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), s.ExpiresAt, time.Minute)
+}
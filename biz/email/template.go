@@ -0,0 +1,227 @@
+package email
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+const (
+	// TemplateExt 页面模板后缀
+	TemplateExt = ".html"
+
+	// 公共模板目录，其中的模板可被所有页面引用
+	layoutsDir  = "layouts"
+	partialsDir = "partials"
+
+	// subjectTemplate 页面中可定义的主题模板名
+	subjectTemplate = "subject"
+)
+
+var ErrTemplateNotFound = errors.New("email template not found")
+
+// Content 模板渲染结果
+type Content struct {
+	Subject string        // 页面定义了 {{define "subject"}} 时的主题
+	HTML    string        // 已内联 CSS 的 HTML
+	Text    string        // 由 HTML 生成的纯文本
+	Inlines []*Attachment // 通过 cid 函数引用的内嵌图片
+}
+
+// TemplateSet 邮件模板集合
+// layouts/ 与 partials/ 目录下的 .html 为公共模板，其余 .html 为页面模板，页面名为去掉后缀的相对路径。
+// 页面通常写作 {{template "layout" .}}{{define "content"}}...{{end}}，图片通过 {{cid "images/logo.png"}} 内嵌
+type TemplateSet struct {
+	InlineCSS bool // 是否内联 CSS，默认开启
+
+	mu    sync.RWMutex
+	fsys  fs.FS
+	funcs template.FuncMap
+	pages map[string]*template.Template
+}
+
+// NewTemplateSet 创建模板集合
+func NewTemplateSet() *TemplateSet {
+	return &TemplateSet{
+		InlineCSS: true,
+		funcs: template.FuncMap{
+			// cid 在渲染时替换为实际实现
+			"cid": func(interface{}) (template.URL, error) { return "", nil },
+		},
+		pages: map[string]*template.Template{},
+	}
+}
+
+// Funcs 添加模板函数，需在 ParseFS 之前调用
+func (t *TemplateSet) Funcs(funcs template.FuncMap) *TemplateSet {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k, v := range funcs {
+		t.funcs[k] = v
+	}
+	return t
+}
+
+// ParseFS 从 fsys 加载模板，内嵌图片同样从 fsys 读取
+func (t *TemplateSet) ParseFS(fsys fs.FS) error {
+	var shared, pages []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != TemplateExt {
+			return nil
+		}
+		dir := strings.SplitN(p, "/", 2)[0]
+		if dir == layoutsDir || dir == partialsDir {
+			shared = append(shared, p)
+		} else {
+			pages = append(pages, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	base := template.New("").Funcs(t.funcs)
+	if len(shared) > 0 {
+		if base, err = base.ParseFS(fsys, shared...); err != nil {
+			return err
+		}
+	}
+	for _, p := range pages {
+		page, err := base.Clone()
+		if err != nil {
+			return err
+		}
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(p, TemplateExt)
+		if _, err := page.New(name).Parse(string(b)); err != nil {
+			return fmt.Errorf("parse email template %s: %w", p, err)
+		}
+		t.pages[name] = page
+	}
+	t.fsys = fsys
+	return nil
+}
+
+// Render 渲染页面模板
+func (t *TemplateSet) Render(name string, data interface{}) (*Content, error) {
+	t.mu.RLock()
+	page, ok := t.pages[name]
+	fsys := t.fsys
+	t.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	tmpl, err := page.Clone()
+	if err != nil {
+		return nil, err
+	}
+	embed := &inlineImages{fsys: fsys, ids: map[string]string{}}
+	tmpl.Funcs(template.FuncMap{"cid": embed.cid})
+
+	content := &Content{}
+	if sub := tmpl.Lookup(subjectTemplate); sub != nil {
+		buf := &bytes.Buffer{}
+		if err := sub.Execute(buf, data); err != nil {
+			return nil, err
+		}
+		content.Subject = html.UnescapeString(strings.TrimSpace(buf.String()))
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.ExecuteTemplate(buf, name, data); err != nil {
+		return nil, err
+	}
+	content.HTML = buf.String()
+	if t.InlineCSS {
+		if content.HTML, err = InlineCSS(content.HTML); err != nil {
+			return nil, err
+		}
+	}
+	if content.Text, err = HTMLToText(content.HTML); err != nil {
+		return nil, err
+	}
+	content.Inlines = embed.attachments
+	return content, nil
+}
+
+// Apply 渲染页面模板并填充到邮件：设置 HTML 与内嵌图片，Text、Subject 为空时使用渲染结果
+func (t *TemplateSet) Apply(message *Message, name string, data interface{}) error {
+	content, err := t.Render(name, data)
+	if err != nil {
+		return err
+	}
+	message.HTML = content.HTML
+	if message.Text == "" {
+		message.Text = content.Text
+	}
+	if message.Subject == "" {
+		message.Subject = content.Subject
+	}
+	message.Attachments = append(message.Attachments, content.Inlines...)
+	return nil
+}
+
+// inlineImages 单次渲染中引用的内嵌图片
+type inlineImages struct {
+	fsys        fs.FS
+	ids         map[string]string
+	attachments []*Attachment
+}
+
+// cid 内嵌图片并返回 cid: 地址，参数为模板文件系统中的路径或图片数据（[]byte）
+func (e *inlineImages) cid(src interface{}) (template.URL, error) {
+	var name string
+	var data []byte
+	switch v := src.(type) {
+	case string:
+		if id, ok := e.ids[v]; ok {
+			return template.URL("cid:" + id), nil
+		}
+		if e.fsys == nil {
+			return "", fmt.Errorf("%w: %s", ErrInvalidAttachment, v)
+		}
+		b, err := fs.ReadFile(e.fsys, v)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidAttachment, err)
+		}
+		name, data = path.Base(v), b
+	case []byte:
+		name, data = fmt.Sprintf("image%d", len(e.attachments)+1), v
+	default:
+		return "", fmt.Errorf("%w: unsupported cid source %T", ErrInvalidAttachment, src)
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	id := fmt.Sprintf("%d.%s@inline", len(e.attachments)+1, name)
+	if s, ok := src.(string); ok {
+		e.ids[s] = id
+	}
+	e.attachments = append(e.attachments, &Attachment{
+		Filename:    name,
+		ContentType: contentType,
+		Data:        bytes.NewReader(data),
+		ContentID:   id,
+	})
+	return template.URL("cid:" + id), nil
+}
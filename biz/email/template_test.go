@@ -0,0 +1,103 @@
+package email
+
+import (
+	"embed"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//go:embed testdata/templates
+var testTemplates embed.FS
+
+type reportRow struct {
+	Key   string
+	Value string
+}
+
+func newTestTemplateSet(t *testing.T) *TemplateSet {
+	sub, err := fs.Sub(testTemplates, "testdata/templates")
+	assert.NoError(t, err)
+	set := NewTemplateSet()
+	assert.NoError(t, set.ParseFS(sub))
+	return set
+}
+
+func TestTemplateRender(t *testing.T) {
+	set := newTestTemplateSet(t)
+	content, err := set.Render("report", map[string]interface{}{
+		"Name":        "张三",
+		"Rows":        []reportRow{{"PV", "1024"}, {"UV", "<128>"}},
+		"Unsubscribe": "https://example.com/unsub",
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "张三 日报 & 汇总", content.Subject)
+	// CSS 内联，元素原有样式优先
+	assert.Contains(t, content.HTML, `<div class="header" style="color: #ffffff; background: #4caf50">`)
+	assert.Contains(t, content.HTML, `<td class="num" style="text-align: right; color: blue">`)
+	assert.Contains(t, content.HTML, `font-family: Arial, sans-serif`)
+	// 无法内联的规则保留
+	assert.Contains(t, content.HTML, "@media (max-width: 600px)")
+	assert.Contains(t, content.HTML, "a:hover {color: red}")
+	// 同一图片只内嵌一次
+	assert.Len(t, content.Inlines, 1)
+	assert.Equal(t, "image/png", content.Inlines[0].ContentType)
+	assert.Equal(t, 2, strings.Count(content.HTML, `src="cid:`+content.Inlines[0].ContentID+`"`))
+
+	assert.Equal(t, "[Logo]\n\n你好，张三\n\n指标 | 数值\nPV | 1024\nUV | <128>\n\n[Logo]\n\n退订请点击 这里 (https://example.com/unsub)", content.Text)
+
+	_, err = set.Render("missing", nil)
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
+
+func TestTemplateApplyBuildMessage(t *testing.T) {
+	set := newTestTemplateSet(t)
+	message := &Message{From: "a@example.com", To: []string{"b@example.com"}}
+	err := set.Apply(message, "report", map[string]interface{}{"Name": "李四"})
+	assert.NoError(t, err)
+	assert.NoError(t, message.Validate())
+
+	raw, err := BuildMessage(message)
+	assert.NoError(t, err)
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	assert.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/related", mediaType)
+	assert.Equal(t, "multipart/alternative", params["type"])
+
+	related := multipart.NewReader(msg.Body, params["boundary"])
+	alt, err := related.NextPart()
+	assert.NoError(t, err)
+	_, altParams, _ := mime.ParseMediaType(alt.Header.Get("Content-Type"))
+	altReader := multipart.NewReader(alt, altParams["boundary"])
+	text, err := altReader.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=UTF-8", text.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(text)
+	assert.Contains(t, string(body), "你好，李四")
+	htmlPart, err := altReader.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "text/html; charset=UTF-8", htmlPart.Header.Get("Content-Type"))
+
+	image, err := related.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "<"+message.Attachments[0].ContentID+">", image.Header.Get("Content-Id"))
+	_, err = related.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestHTMLToText(t *testing.T) {
+	text, err := HTMLToText(`<h1>标题</h1><p>第一段<br>换行</p><ul><li>a</li><li><a href="https://x.com">链接</a></li></ul><pre>  code
+  block</pre>`)
+	assert.NoError(t, err)
+	assert.Equal(t, "标题\n\n第一段\n换行\n\n- a\n- 链接 (https://x.com)\n\ncode\nblock", text)
+}
@@ -0,0 +1,118 @@
+package email
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	spaceRe     = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankLineRe = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText 将 HTML 转换为纯文本，用于生成邮件的纯文本备选内容
+// 段落与标题独占一段，列表项以 "- " 开头，链接输出为 "文字 (地址)"，表格单元格以 " | " 分隔
+func HTMLToText(htmlContent string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return "", err
+	}
+	t := &textWriter{}
+	t.node(doc)
+	text := blankLineRe.ReplaceAllString(t.buf.String(), "\n\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(blankLineRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")), nil
+}
+
+type textWriter struct {
+	buf strings.Builder
+	pre int
+}
+
+func (t *textWriter) write(s string) {
+	t.buf.WriteString(s)
+}
+
+// block 在块级元素前后换行
+func (t *textWriter) block(n *html.Node, sep string) {
+	t.write(sep)
+	t.children(n)
+	t.write(sep)
+}
+
+func (t *textWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		t.node(c)
+	}
+}
+
+func (t *textWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if t.pre > 0 {
+			t.write(n.Data)
+		} else {
+			t.write(spaceRe.ReplaceAllString(n.Data, " "))
+		}
+		return
+	case html.ElementNode:
+	default:
+		t.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Head, atom.Style, atom.Script, atom.Title:
+	case atom.Br:
+		t.write("\n")
+	case atom.Hr:
+		t.write("\n--------\n")
+	case atom.P, atom.Div, atom.Table, atom.Blockquote, atom.Ul, atom.Ol, atom.Section, atom.Header, atom.Footer,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		t.block(n, "\n\n")
+	case atom.Tr:
+		t.write("\n")
+		t.children(n)
+	case atom.Td, atom.Th:
+		if hasPrevCell(n) {
+			t.write(" | ")
+		}
+		t.children(n)
+	case atom.Li:
+		t.write("\n- ")
+		t.children(n)
+	case atom.Pre:
+		t.pre++
+		t.block(n, "\n\n")
+		t.pre--
+	case atom.A:
+		start := t.buf.Len()
+		t.children(n)
+		label := strings.TrimSpace(t.buf.String()[start:])
+		href := getAttr(n, "href")
+		if href != "" && href != label && !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "mailto:"+label) {
+			t.write(" (" + href + ")")
+		}
+	case atom.Img:
+		if alt := getAttr(n, "alt"); alt != "" {
+			t.write("[" + alt + "]")
+		}
+	default:
+		t.children(n)
+	}
+}
+
+func hasPrevCell(n *html.Node) bool {
+	for p := n.PrevSibling; p != nil; p = p.PrevSibling {
+		if p.Type == html.ElementNode && (p.DataAtom == atom.Td || p.DataAtom == atom.Th) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,331 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RecipientStatus 收件人发送结果
+type RecipientStatus string
+
+const (
+	RecipientSent       RecipientStatus = "sent"       // 已交给底层 Sender
+	RecipientSuppressed RecipientStatus = "suppressed" // 在抑制名单中，未发送
+	RecipientFailed     RecipientStatus = "failed"     // 发送失败
+)
+
+// RecipientResult 单个收件人的发送结果
+type RecipientResult struct {
+	Address     string
+	Status      RecipientStatus
+	Suppression *Suppression // Status 为 suppressed 时的抑制记录
+	Err         error        // Status 为 failed 时的失败原因
+}
+
+// SendReport 一封邮件按收件人的发送结果，顺序与 To、Cc、Bcc 中首次出现的顺序一致
+type SendReport struct {
+	Results []*RecipientResult
+	Batches int // 实际发送的批次数
+}
+
+// Sent 已发送的收件人
+func (r *SendReport) Sent() []string {
+	return r.addresses(RecipientSent)
+}
+
+// Suppressed 被抑制的收件人
+func (r *SendReport) Suppressed() []string {
+	return r.addresses(RecipientSuppressed)
+}
+
+// Failed 发送失败的收件人结果
+func (r *SendReport) Failed() []*RecipientResult {
+	var failed []*RecipientResult
+	for _, result := range r.Results {
+		if result.Status == RecipientFailed {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err 存在失败收件人时返回错误；全部被抑制时返回 ErrRecipientsSuppressed
+func (r *SendReport) Err() error {
+	if failed := r.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%w: %d of %d recipients failed: %w", ErrSendFailed, len(failed), len(r.Results), failed[0].Err)
+	}
+	if len(r.Sent()) == 0 {
+		return ErrRecipientsSuppressed
+	}
+	return nil
+}
+
+func (r *SendReport) addresses(status RecipientStatus) []string {
+	var addrs []string
+	for _, result := range r.Results {
+		if result.Status == status {
+			addrs = append(addrs, result.Address)
+		}
+	}
+	return addrs
+}
+
+// domainLimit 每 interval 最多 n 个收件人
+type domainLimit struct {
+	n        int
+	interval time.Duration
+}
+
+// ThrottledSender 发送中间件：过滤抑制名单中的收件人，按收件人域名限速，
+// 收件人过多时拆分为多封邮件。拆分后每批收件人只能在邮件头中看到本批的 To、Cc
+type ThrottledSender struct {
+	sender        Sender
+	suppression   SuppressionList
+	maxRecipients int
+	defaultLimit  *domainLimit
+	limits        map[string]*domainLimit
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewThrottledSender 包装任意 Sender，默认不限速、不拆分
+func NewThrottledSender(sender Sender) *ThrottledSender {
+	return &ThrottledSender{
+		sender:   sender,
+		limits:   map[string]*domainLimit{},
+		limiters: map[string]*rate.Limiter{},
+	}
+}
+
+// SetSuppressionList 设置抑制名单
+func (t *ThrottledSender) SetSuppressionList(list SuppressionList) *ThrottledSender {
+	t.suppression = list
+	return t
+}
+
+// SetDomainLimit 限制发往 domain 的收件人数，每 interval 最多 n 个，n 同时是单批发往该域名的上限
+func (t *ThrottledSender) SetDomainLimit(domain string, n int, interval time.Duration) *ThrottledSender {
+	t.limits[strings.ToLower(domain)] = &domainLimit{n: n, interval: interval}
+	return t
+}
+
+// SetDefaultLimit 未单独配置的域名各自按该速率限速
+func (t *ThrottledSender) SetDefaultLimit(n int, interval time.Duration) *ThrottledSender {
+	t.defaultLimit = &domainLimit{n: n, interval: interval}
+	return t
+}
+
+// SetMaxRecipients 单封邮件的最大收件人数（To、Cc、Bcc 合计），超出时拆分发送
+func (t *ThrottledSender) SetMaxRecipients(n int) *ThrottledSender {
+	t.maxRecipients = n
+	return t
+}
+
+// Send 发送邮件，存在失败收件人或全部被抑制时返回错误
+func (t *ThrottledSender) Send(ctx context.Context, message *Message) error {
+	report, err := t.SendWithReport(ctx, message)
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
+
+// SendBatch 逐封发送，部分失败时返回 *BatchError
+func (t *ThrottledSender) SendBatch(ctx context.Context, messages []*Message) error {
+	return sendEach(ctx, t, messages)
+}
+
+// Close 关闭底层 Sender
+func (t *ThrottledSender) Close() error {
+	return t.sender.Close()
+}
+
+// throttledRecipient 待发送的收件人
+type throttledRecipient struct {
+	addr   string // 原始写法，保留显示名称
+	field  int    // 0 To，1 Cc，2 Bcc
+	domain string
+	result *RecipientResult
+}
+
+// SendWithReport 发送邮件并返回每个收件人的结果；查询抑制名单失败时不发送任何邮件
+func (t *ThrottledSender) SendWithReport(ctx context.Context, message *Message) (*SendReport, error) {
+	report := &SendReport{}
+	var pending []*throttledRecipient
+	seen := map[string]bool{}
+	for field, addrs := range [][]string{message.To, message.Cc, message.Bcc} {
+		for _, addr := range addrs {
+			normalized := normalizeAddress(addr)
+			if seen[normalized] {
+				continue
+			}
+			seen[normalized] = true
+
+			result := &RecipientResult{Address: normalized}
+			report.Results = append(report.Results, result)
+			if t.suppression != nil {
+				s, err := t.suppression.Get(ctx, normalized)
+				if err != nil {
+					return nil, fmt.Errorf("failed to check suppression list: %w", err)
+				}
+				if s != nil {
+					result.Status, result.Suppression = RecipientSuppressed, s
+					continue
+				}
+			}
+			domain := normalized[strings.LastIndex(normalized, "@")+1:]
+			pending = append(pending, &throttledRecipient{addr: addr, field: field, domain: domain, result: result})
+		}
+	}
+	if len(report.Results) == 0 {
+		return nil, ErrNoRecipients
+	}
+	if len(pending) == 0 {
+		return report, nil
+	}
+
+	batches := t.split(pending)
+	unchanged := len(batches) == 1 && len(pending) == len(report.Results) && len(message.To) > 0
+	var stored StoredMessage
+	if !unchanged {
+		var err error
+		if stored, err = toStoredMessage(message); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, batch := range batches {
+		if err := t.wait(ctx, batch); err != nil {
+			for _, r := range batch {
+				r.result.Status, r.result.Err = RecipientFailed, err
+			}
+			continue
+		}
+		if unchanged {
+			t.deliver(ctx, message, batch)
+			report.Batches++
+			continue
+		}
+		for _, group := range regroup(batch) {
+			clone := stored.Message()
+			clone.SendAt = message.SendAt
+			clone.To, clone.Cc, clone.Bcc = nil, nil, nil
+			for _, r := range group {
+				switch r.field {
+				case 0:
+					clone.To = append(clone.To, r.addr)
+				case 1:
+					clone.Cc = append(clone.Cc, r.addr)
+				default:
+					clone.Bcc = append(clone.Bcc, r.addr)
+				}
+			}
+			if len(clone.To) == 0 {
+				clone.To, clone.Cc = clone.Cc, nil
+			}
+			if len(clone.To) == 0 {
+				clone.To, clone.Bcc = clone.Bcc, nil
+			}
+			t.deliver(ctx, clone, group)
+			report.Batches++
+		}
+	}
+	return report, nil
+}
+
+// deliver 发送一批收件人并记录结果
+func (t *ThrottledSender) deliver(ctx context.Context, message *Message, batch []*throttledRecipient) {
+	err := t.sender.Send(ctx, message)
+	for _, r := range batch {
+		if err != nil {
+			r.result.Status, r.result.Err = RecipientFailed, err
+		} else {
+			r.result.Status = RecipientSent
+		}
+	}
+}
+
+// regroup 没有 To、Cc 的批次中，密送收件人逐个单独发送，避免互相可见
+func regroup(batch []*throttledRecipient) [][]*throttledRecipient {
+	for _, r := range batch {
+		if r.field != 2 {
+			return [][]*throttledRecipient{batch}
+		}
+	}
+	groups := make([][]*throttledRecipient, len(batch))
+	for i, r := range batch {
+		groups[i] = []*throttledRecipient{r}
+	}
+	return groups
+}
+
+// split 按原顺序拆分收件人，每批不超过 maxRecipients，且发往每个域名的人数不超过该域名的突发上限
+func (t *ThrottledSender) split(recipients []*throttledRecipient) [][]*throttledRecipient {
+	var batches [][]*throttledRecipient
+	var current []*throttledRecipient
+	counts := map[string]int{}
+	for _, r := range recipients {
+		full := t.maxRecipients > 0 && len(current) >= t.maxRecipients
+		if limit := t.limit(r.domain); limit != nil && limit.n > 0 && counts[r.domain] >= limit.n {
+			full = true
+		}
+		if full && len(current) > 0 {
+			batches = append(batches, current)
+			current, counts = nil, map[string]int{}
+		}
+		current = append(current, r)
+		counts[r.domain]++
+	}
+	return append(batches, current)
+}
+
+// wait 按批次中每个域名的收件人数等待限速令牌
+func (t *ThrottledSender) wait(ctx context.Context, batch []*throttledRecipient) error {
+	counts := map[string]int{}
+	var domains []string
+	for _, r := range batch {
+		if counts[r.domain] == 0 {
+			domains = append(domains, r.domain)
+		}
+		counts[r.domain]++
+	}
+	for _, domain := range domains {
+		limiter := t.limiter(domain)
+		if limiter == nil {
+			continue
+		}
+		if err := limiter.WaitN(ctx, counts[domain]); err != nil {
+			return fmt.Errorf("%w: %v", ErrTimeout, err)
+		}
+	}
+	return nil
+}
+
+func (t *ThrottledSender) limit(domain string) *domainLimit {
+	if limit, ok := t.limits[domain]; ok {
+		return limit
+	}
+	return t.defaultLimit
+}
+
+// limiter 每个域名独立的令牌桶，未配置限速时返回 nil
+func (t *ThrottledSender) limiter(domain string) *rate.Limiter {
+	limit := t.limit(domain)
+	if limit == nil || limit.n <= 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	limiter, ok := t.limiters[domain]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Every(limit.interval/time.Duration(limit.n)), limit.n)
+		t.limiters[domain] = limiter
+	}
+	return limiter
+}
@@ -0,0 +1,107 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func throttleMessage() *Message {
+	return &Message{
+		From:        "news@example.com",
+		To:          []string{"A <a@foo.com>", "b@foo.com", "c@bar.com"},
+		Cc:          []string{"d@foo.com"},
+		Bcc:         []string{"e@bar.com", "gone@bar.com"},
+		Subject:     "周报",
+		Text:        "body",
+		Attachments: []*Attachment{{Filename: "report.csv", Data: strings.NewReader("a,b")}},
+	}
+}
+
+func TestThrottledSenderSuppression(t *testing.T) {
+	ctx := context.Background()
+	list := NewMemorySuppressionList()
+	list.Add(ctx, &Suppression{Address: "gone@bar.com", Reason: SuppressHardBounce})
+	inner := NewMemorySender()
+	sender := NewThrottledSender(inner).SetSuppressionList(list)
+
+	report, err := sender.SendWithReport(ctx, throttleMessage())
+	assert.NoError(t, err)
+	assert.NoError(t, report.Err())
+	assert.Equal(t, []string{"a@foo.com", "b@foo.com", "c@bar.com", "d@foo.com", "e@bar.com"}, report.Sent())
+	assert.Equal(t, []string{"gone@bar.com"}, report.Suppressed())
+	assert.Equal(t, SuppressHardBounce, report.Results[5].Suppression.Reason)
+	assert.Equal(t, 1, report.Batches)
+
+	sent := inner.Last()
+	assert.Equal(t, []string{"A <a@foo.com>", "b@foo.com", "c@bar.com"}, sent.To)
+	assert.Equal(t, []string{"e@bar.com"}, sent.Bcc)
+	assert.Len(t, sent.Attachments, 1)
+
+	// 全部被抑制时不发送
+	inner.Reset()
+	message := &Message{From: "news@example.com", To: []string{"gone@bar.com"}, Subject: "s", Text: "t"}
+	assert.ErrorIs(t, sender.Send(ctx, message), ErrRecipientsSuppressed)
+	assert.Empty(t, inner.Messages())
+}
+
+func TestThrottledSenderSplit(t *testing.T) {
+	ctx := context.Background()
+	inner := NewMemorySender()
+	sender := NewThrottledSender(inner).SetMaxRecipients(2)
+
+	report, err := sender.SendWithReport(ctx, throttleMessage())
+	assert.NoError(t, err)
+	assert.Len(t, report.Sent(), 6)
+	// [a b] [c d] [e gone]，密送批次逐个发送
+	assert.Equal(t, 4, report.Batches)
+	messages := inner.Messages()
+	assert.Len(t, messages, 4)
+	assert.Equal(t, []string{"A <a@foo.com>", "b@foo.com"}, messages[0].To)
+	assert.Equal(t, []string{"c@bar.com"}, messages[1].To)
+	assert.Equal(t, []string{"d@foo.com"}, messages[1].Cc)
+	assert.Equal(t, []string{"e@bar.com"}, messages[2].To)
+	assert.Empty(t, messages[2].Bcc)
+	assert.Equal(t, []string{"gone@bar.com"}, messages[3].To)
+	for _, msg := range messages {
+		assert.Len(t, msg.Attachments, 1)
+		data, _ := readAttachment(msg.Attachments[0])
+		assert.Equal(t, "a,b", string(data))
+	}
+
+	// 部分批次失败时按收件人报告
+	inner.Reset()
+	inner.Err = errors.New("relay rejected")
+	err = sender.Send(ctx, throttleMessage())
+	assert.ErrorIs(t, err, ErrSendFailed)
+	assert.Contains(t, err.Error(), "relay rejected")
+}
+
+func TestThrottledSenderDomainLimit(t *testing.T) {
+	ctx := context.Background()
+	inner := NewMemorySender()
+	sender := NewThrottledSender(inner).SetDomainLimit("FOO.com", 2, 200*time.Millisecond)
+
+	start := time.Now()
+	report, err := sender.SendWithReport(ctx, throttleMessage())
+	assert.NoError(t, err)
+	assert.Len(t, report.Sent(), 6)
+	// foo.com 共 3 人，每批至多 2 人，第二批需等待令牌恢复
+	assert.Equal(t, 2, report.Batches)
+	assert.Equal(t, []string{"A <a@foo.com>", "b@foo.com", "c@bar.com"}, inner.Messages()[0].To)
+	assert.Equal(t, []string{"d@foo.com"}, inner.Messages()[1].To)
+	assert.Equal(t, []string{"e@bar.com", "gone@bar.com"}, inner.Messages()[1].Bcc)
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+
+	// 等待超出 context 期限时收件人标记为失败
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	report, err = sender.SendWithReport(timeout, &Message{From: "news@example.com", To: []string{"x@foo.com", "y@foo.com"}, Subject: "s", Text: "t"})
+	assert.NoError(t, err)
+	assert.Len(t, report.Failed(), 2)
+	assert.ErrorIs(t, report.Err(), ErrTimeout)
+}
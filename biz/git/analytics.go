@@ -0,0 +1,329 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/yi-nology/common/utils/xjson"
+)
+
+// AnalyticsOptions 统计范围
+type AnalyticsOptions struct {
+	From          string    // 起始修订，默认 HEAD
+	Since         time.Time // 提交时间下限，零值不限制
+	Until         time.Time // 提交时间上限，零值不限制
+	Paths         []string  // 只统计这些文件或目录
+	IncludeMerges bool      // 统计合并提交，默认跳过以免重复计算合并进来的变更
+}
+
+// AuthorStats 作者贡献统计，按邮箱归并
+type AuthorStats struct {
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Commits     int       `json:"commits"`
+	Additions   int       `json:"additions"`
+	Deletions   int       `json:"deletions"`
+	FirstCommit time.Time `json:"first_commit"`
+	LastCommit  time.Time `json:"last_commit"`
+}
+
+// FileChurn 文件变更热度
+type FileChurn struct {
+	Path      string `json:"path"`
+	Commits   int    `json:"commits"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Authors   int    `json:"authors"`
+}
+
+// Churn 增删行数之和
+func (f *FileChurn) Churn() int {
+	return f.Additions + f.Deletions
+}
+
+// Report 一段时间内的仓库统计
+type Report struct {
+	Commits      int            `json:"commits"`
+	Additions    int            `json:"additions"`
+	Deletions    int            `json:"deletions"`
+	Contributors []*AuthorStats `json:"contributors"` // 按提交数倒序
+	Files        []*FileChurn   `json:"files"`        // 按增删行数倒序
+}
+
+// JSON 通过 xjson 导出
+func (r *Report) JSON() ([]byte, error) {
+	return xjson.Marshal(r)
+}
+
+// HotSpots 变更最频繁的 limit 个文件，limit 为 0 时返回全部
+func (r *Report) HotSpots(limit int) []*FileChurn {
+	if limit > 0 && len(r.Files) > limit {
+		return r.Files[:limit]
+	}
+	return r.Files
+}
+
+// BlameLine 文件中一行的最后修改信息
+type BlameLine struct {
+	Line   int       `json:"line"` // 从 1 开始
+	Hash   string    `json:"hash"`
+	Author string    `json:"author"`
+	Email  string    `json:"email"`
+	When   time.Time `json:"when"`
+	Text   string    `json:"text"`
+}
+
+// FileBlame 文件在某个修订的逐行归属
+type FileBlame struct {
+	Path  string       `json:"path"`
+	Rev   string       `json:"rev"`
+	Lines []*BlameLine `json:"lines"`
+}
+
+// JSON 通过 xjson 导出
+func (b *FileBlame) JSON() ([]byte, error) {
+	return xjson.Marshal(b)
+}
+
+// Analyze 遍历提交历史统计作者贡献与文件热度。按提交时间倒序遍历，指定 Since 时遇到更早的提交即停止，
+// 不会扫描之前的全部历史；逐个提交计算差异，统计结果随作者数、文件数增长，遍历器另需记录已访问的提交，随遍历的提交数增长
+func (i *Info) Analyze(ctx context.Context, opts *AnalyticsOptions) (*Report, error) {
+	if opts == nil {
+		opts = &AnalyticsOptions{}
+	}
+	logOpts := &git.LogOptions{Order: git.LogOrderCommitterTime}
+	if opts.From != "" {
+		hash, err := i.resolve(opts.From)
+		if err != nil {
+			return nil, err
+		}
+		logOpts.From = hash
+	}
+	var match func(string) bool
+	if len(opts.Paths) > 0 {
+		match = pathFilter(opts.Paths)
+		logOpts.PathFilter = match
+	}
+	if !opts.Until.IsZero() {
+		logOpts.Until = &opts.Until
+	}
+	iter, err := i.project.Log(logOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	report := &Report{}
+	authors := map[string]*AuthorStats{}
+	files := map[string]*FileChurn{}
+	fileAuthors := map[string]map[string]bool{}
+	err = iter.ForEach(func(c *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// 按提交时间倒序遍历，之后的提交都早于 Since
+		if !opts.Since.IsZero() && c.Committer.When.Before(opts.Since) {
+			return storer.ErrStop
+		}
+		if c.NumParents() > 1 && !opts.IncludeMerges {
+			return nil
+		}
+		stats, err := commitStats(ctx, c)
+		if err != nil {
+			return fmt.Errorf("stats of %s: %w", c.Hash, err)
+		}
+
+		email := strings.ToLower(c.Author.Email)
+		author := authors[email]
+		if author == nil {
+			author = &AuthorStats{Name: c.Author.Name, Email: email, FirstCommit: c.Author.When, LastCommit: c.Author.When}
+			authors[email] = author
+		}
+		author.Commits++
+		if c.Author.When.Before(author.FirstCommit) {
+			author.FirstCommit = c.Author.When
+		}
+		if c.Author.When.After(author.LastCommit) {
+			author.Name, author.LastCommit = c.Author.Name, c.Author.When
+		}
+		report.Commits++
+
+		for _, stat := range stats {
+			if match != nil && !match(stat.Path) && (stat.From == "" || !match(stat.From)) {
+				continue
+			}
+			author.Additions += stat.Additions
+			author.Deletions += stat.Deletions
+			report.Additions += stat.Additions
+			report.Deletions += stat.Deletions
+
+			file := files[stat.Path]
+			if file == nil {
+				file = &FileChurn{Path: stat.Path}
+				files[stat.Path] = file
+				fileAuthors[stat.Path] = map[string]bool{}
+			}
+			file.Commits++
+			file.Additions += stat.Additions
+			file.Deletions += stat.Deletions
+			fileAuthors[stat.Path][email] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, author := range authors {
+		report.Contributors = append(report.Contributors, author)
+	}
+	sort.Slice(report.Contributors, func(a, b int) bool {
+		x, y := report.Contributors[a], report.Contributors[b]
+		if x.Commits != y.Commits {
+			return x.Commits > y.Commits
+		}
+		return x.Email < y.Email
+	})
+	for path, file := range files {
+		file.Authors = len(fileAuthors[path])
+		report.Files = append(report.Files, file)
+	}
+	sort.Slice(report.Files, func(a, b int) bool {
+		x, y := report.Files[a], report.Files[b]
+		if x.Churn() != y.Churn() {
+			return x.Churn() > y.Churn()
+		}
+		return x.Path < y.Path
+	})
+	return report, nil
+}
+
+// fileStat 单个提交中一个文件的增删行数，重命名时 Path 为新路径、From 为原路径
+type fileStat struct {
+	Path      string
+	From      string
+	Additions int
+	Deletions int
+}
+
+// commitStats 对比提交与第一个父提交的树（检测重命名），统计各文件的增删行数；二进制文件与子模块不计入
+func commitStats(ctx context.Context, c *object.Commit) ([]fileStat, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	changes, err := object.DiffTreeWithOptions(ctx, parentTree, tree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := changes.PatchContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []fileStat
+	for _, fp := range patch.FilePatches() {
+		if len(fp.Chunks()) == 0 {
+			continue
+		}
+		stat := fileStat{}
+		from, to := fp.Files()
+		switch {
+		case to == nil:
+			stat.Path = from.Path()
+		case from != nil && from.Path() != to.Path():
+			stat.Path, stat.From = to.Path(), from.Path()
+		default:
+			stat.Path = to.Path()
+		}
+		for _, chunk := range fp.Chunks() {
+			switch chunk.Type() {
+			case diff.Add:
+				stat.Additions += countLines(chunk.Content())
+			case diff.Delete:
+				stat.Deletions += countLines(chunk.Content())
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// countLines 统计行数，末尾没有换行的最后一行同样计入
+func countLines(s string) int {
+	if s == "" {
+		return 0
+	}
+	n := strings.Count(s, "\n")
+	if !strings.HasSuffix(s, "\n") {
+		n++
+	}
+	return n
+}
+
+// Contributors 作者贡献统计，按提交数倒序；每次调用都完整执行一次 Analyze，同时需要文件热度时直接使用 Analyze 返回的 Report
+func (i *Info) Contributors(ctx context.Context, opts *AnalyticsOptions) ([]*AuthorStats, error) {
+	report, err := i.Analyze(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return report.Contributors, nil
+}
+
+// HotSpots 变更最频繁的 limit 个文件，limit 为 0 时返回全部；每次调用都完整执行一次 Analyze，
+// 同时需要作者统计时使用 Analyze 返回的 Report 及其 HotSpots 方法
+func (i *Info) HotSpots(ctx context.Context, opts *AnalyticsOptions, limit int) ([]*FileChurn, error) {
+	report, err := i.Analyze(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return report.HotSpots(limit), nil
+}
+
+// Blame 文件在修订 rev 的逐行归属，rev 为空时使用 HEAD
+func (i *Info) Blame(rev, path string) (*FileBlame, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+	hash, err := i.resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+	c, err := i.project.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	result, err := git.Blame(c, path)
+	if err != nil {
+		return nil, err
+	}
+	blame := &FileBlame{Path: path, Rev: hash.String(), Lines: make([]*BlameLine, 0, len(result.Lines))}
+	for n, line := range result.Lines {
+		blame.Lines = append(blame.Lines, &BlameLine{
+			Line:   n + 1,
+			Hash:   line.Hash.String(),
+			Author: line.AuthorName,
+			Email:  line.Author,
+			When:   line.Date,
+			Text:   line.Text,
+		})
+	}
+	return blame, nil
+}
@@ -0,0 +1,150 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/yi-nology/common/utils/xjson"
+)
+
+func commitAs(t *testing.T, repo *Info, dir, path, content, name string, when time.Time) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0o644))
+	assert.NoError(t, repo.Add(path))
+	author := &object.Signature{Name: name, Email: name + "@example.com", When: when}
+	_, err := repo.Commit("update "+path, &CommitOptions{Author: author})
+	assert.NoError(t, err)
+}
+
+func TestAnalyze(t *testing.T) {
+	ctx := context.Background()
+	repo, dir := newTestRepo(t)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	commitAs(t, repo, dir, "main.go", "a\nb\nc\n", "alice", day)
+	commitAs(t, repo, dir, "main.go", "a\nB\nc\nd\n", "bob", day.Add(24*time.Hour))
+	commitAs(t, repo, dir, "docs/readme.md", "hi\n", "alice", day.Add(48*time.Hour))
+	commitAs(t, repo, dir, "main.go", "a\nB\n", "alice", day.Add(72*time.Hour))
+
+	report, err := repo.Analyze(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.Commits)
+	assert.Equal(t, 3+2+1+0, report.Additions)
+	assert.Equal(t, 0+1+0+2, report.Deletions)
+
+	alice := report.Contributors[0]
+	assert.Equal(t, "alice@example.com", alice.Email)
+	assert.Equal(t, 3, alice.Commits)
+	assert.Equal(t, 4, alice.Additions)
+	assert.Equal(t, 2, alice.Deletions)
+	assert.Equal(t, day, alice.FirstCommit.UTC())
+	assert.Equal(t, day.Add(72*time.Hour), alice.LastCommit.UTC())
+	assert.Equal(t, 1, report.Contributors[1].Commits)
+
+	hot := report.Files[0]
+	assert.Equal(t, "main.go", hot.Path)
+	assert.Equal(t, 3, hot.Commits)
+	assert.Equal(t, 8, hot.Churn())
+	assert.Equal(t, 2, hot.Authors)
+
+	// 时间范围与路径过滤
+	contributors, err := repo.Contributors(ctx, &AnalyticsOptions{Since: day.Add(12 * time.Hour), Until: day.Add(60 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Len(t, contributors, 2)
+	assert.Equal(t, 1, contributors[0].Commits)
+	files, err := repo.HotSpots(ctx, &AnalyticsOptions{Paths: []string{"docs"}}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "docs/readme.md", files[0].Path)
+	files, err = repo.HotSpots(ctx, nil, 1)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, report.HotSpots(1), files)
+
+	// 只指定 Since 时遇到更早的提交即停止遍历
+	recent, err := repo.Analyze(ctx, &AnalyticsOptions{Since: day.Add(36 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, recent.Commits)
+	assert.Equal(t, 1, recent.Additions)
+
+	data, err := report.JSON()
+	assert.NoError(t, err)
+	var decoded Report
+	assert.NoError(t, xjson.Unmarshal(data, &decoded))
+	assert.Equal(t, report.Commits, decoded.Commits)
+	assert.Equal(t, "main.go", decoded.Files[0].Path)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = repo.Analyze(canceled, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestBlame(t *testing.T) {
+	repo, dir := newTestRepo(t)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	commitAs(t, repo, dir, "main.go", "a\nb\nc\n", "alice", day)
+	commitAs(t, repo, dir, "main.go", "a\nB\nc\nd\n", "bob", day.Add(time.Hour))
+
+	blame, err := repo.Blame("", "main.go")
+	assert.NoError(t, err)
+	assert.Len(t, blame.Lines, 4)
+	authors := []string{}
+	for _, line := range blame.Lines {
+		authors = append(authors, line.Author)
+	}
+	assert.Equal(t, []string{"alice", "bob", "alice", "bob"}, authors)
+	assert.Equal(t, "B", blame.Lines[1].Text)
+	assert.Equal(t, 2, blame.Lines[1].Line)
+	assert.Equal(t, "bob@example.com", blame.Lines[3].Email)
+
+	// 指定历史修订
+	blame, err = repo.Blame("HEAD~1", "main.go")
+	assert.NoError(t, err)
+	assert.Len(t, blame.Lines, 3)
+
+	_, err = repo.Blame("", "missing.go")
+	assert.Error(t, err)
+	data, err := blame.JSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"author":"alice"`)
+}
+
+func TestAnalyzeRename(t *testing.T) {
+	ctx := context.Background()
+	repo, dir := newTestRepo(t)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	commitAs(t, repo, dir, "old/main.go", "a\nb\nc\nd\ne\n", "alice", day)
+
+	// 重命名并修改一行，按新路径计入
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0o755))
+	assert.NoError(t, os.Rename(filepath.Join(dir, "old/main.go"), filepath.Join(dir, "src/main.go")))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "src/main.go"), []byte("a\nb\nc\nd\nE\n"), 0o644))
+	w, _ := repo.Repository().Worktree()
+	_, err := w.Remove("old/main.go")
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Add("src/main.go"))
+	_, err = repo.Commit("move main.go", &CommitOptions{Author: &object.Signature{Name: "bob", Email: "bob@example.com", When: day.Add(time.Hour)}})
+	assert.NoError(t, err)
+
+	files, err := repo.HotSpots(ctx, &AnalyticsOptions{Paths: []string{"src"}}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "src/main.go", files[0].Path)
+	assert.Equal(t, 1, files[0].Commits)
+	assert.Equal(t, 1, files[0].Additions)
+	assert.Equal(t, 1, files[0].Deletions)
+
+	report, err := repo.Analyze(ctx, nil)
+	assert.NoError(t, err)
+	var paths []string
+	for _, file := range report.Files {
+		paths = append(paths, file.Path)
+	}
+	assert.ElementsMatch(t, []string{"old/main.go", "src/main.go"}, paths)
+	assert.Equal(t, 5+1, report.Additions)
+}
@@ -0,0 +1,119 @@
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// AuthOptions 认证选项，按仓库地址的协议选择：
+// HTTPS 依次尝试 Token、Username/Password；SSH 依次尝试 SSHKey、SSHKeyFile、SSHAgent
+type AuthOptions struct {
+	// HTTPS
+	Token    string // 访问令牌，以 Basic 认证发送
+	Username string // 用户名，Token 认证时默认 git（GitLab 可使用 oauth2）
+	Password string
+
+	// SSH
+	SSHUser          string // SSH 用户，默认 git
+	SSHKey           []byte // PEM 格式私钥内容
+	SSHKeyFile       string // 私钥文件路径
+	SSHKeyPassphrase string // 私钥密码
+	SSHAgent         bool   // 使用 SSH_AUTH_SOCK 指向的 ssh-agent
+
+	// SSH 主机校验，默认严格校验 ~/.ssh/known_hosts 与 /etc/ssh/ssh_known_hosts
+	KnownHostsFiles       []string              // 自定义 known_hosts 文件
+	HostKeyCallback       gossh.HostKeyCallback // 自定义校验函数，优先于 KnownHostsFiles
+	InsecureIgnoreHostKey bool                  // 跳过主机校验，仅用于测试环境
+}
+
+// NewAuth 按仓库地址创建认证方式，本地路径、file:// 或未配置对应协议的认证时返回 nil
+func NewAuth(url string, opts *AuthOptions) (transport.AuthMethod, error) {
+	if opts == nil {
+		return nil, nil
+	}
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAuth, err)
+	}
+	switch endpoint.Protocol {
+	case "http", "https":
+		return opts.httpAuth(), nil
+	case "ssh":
+		return opts.sshAuth()
+	default:
+		return nil, nil
+	}
+}
+
+func (o *AuthOptions) httpAuth() transport.AuthMethod {
+	switch {
+	case o.Token != "":
+		username := o.Username
+		if username == "" {
+			username = "git"
+		}
+		return &http.BasicAuth{Username: username, Password: o.Token}
+	case o.Username != "":
+		return &http.BasicAuth{Username: o.Username, Password: o.Password}
+	default:
+		return nil
+	}
+}
+
+func (o *AuthOptions) sshAuth() (transport.AuthMethod, error) {
+	user := o.SSHUser
+	if user == "" {
+		user = "git"
+	}
+
+	var auth transport.AuthMethod
+	var helper *ssh.HostKeyCallbackHelper
+	switch {
+	case len(o.SSHKey) > 0:
+		keys, err := ssh.NewPublicKeys(user, o.SSHKey, o.SSHKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("%w: parse ssh key: %v", ErrInvalidAuth, err)
+		}
+		auth, helper = keys, &keys.HostKeyCallbackHelper
+	case o.SSHKeyFile != "":
+		keys, err := ssh.NewPublicKeysFromFile(user, o.SSHKeyFile, o.SSHKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("%w: read ssh key %s: %v", ErrInvalidAuth, o.SSHKeyFile, err)
+		}
+		auth, helper = keys, &keys.HostKeyCallbackHelper
+	case o.SSHAgent:
+		agent, err := ssh.NewSSHAgentAuth(user)
+		if err != nil {
+			return nil, fmt.Errorf("%w: connect ssh agent: %v", ErrInvalidAuth, err)
+		}
+		auth, helper = agent, &agent.HostKeyCallbackHelper
+	default:
+		return nil, nil
+	}
+
+	callback, err := o.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+	helper.HostKeyCallback = callback
+	return auth, nil
+}
+
+// hostKeyCallback 主机校验函数，known_hosts 文件不存在时返回错误而不是放行
+func (o *AuthOptions) hostKeyCallback() (gossh.HostKeyCallback, error) {
+	switch {
+	case o.HostKeyCallback != nil:
+		return o.HostKeyCallback, nil
+	case o.InsecureIgnoreHostKey:
+		return gossh.InsecureIgnoreHostKey(), nil
+	}
+	callback, err := ssh.NewKnownHostsCallback(o.KnownHostsFiles...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: load known_hosts: %v", ErrInvalidAuth, err)
+	}
+	return callback, nil
+}
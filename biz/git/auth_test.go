@@ -0,0 +1,292 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/format/pktline"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/stretchr/testify/assert"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// newServedRepo 创建带一个提交的仓库，返回可供 server.DefaultServer 加载的 .git 目录
+func newServedRepo(t *testing.T) string {
+	repo, dir := newTestRepo(t)
+	commitFile(t, repo, dir, "a.txt", "one\n", "init")
+	return filepath.Join(dir, ".git")
+}
+
+// uploadPack 以 git-upload-pack 协议提供仓库，advertise 时先发送引用列表，r 为空时只发送引用列表
+func uploadPack(ctx context.Context, repoPath string, r io.Reader, w io.Writer, advertise bool, prefix ...[]byte) error {
+	endpoint, err := transport.NewEndpoint(repoPath)
+	if err != nil {
+		return err
+	}
+	session, err := server.DefaultServer.NewUploadPackSession(endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if advertise {
+		refs, err := session.AdvertisedReferencesContext(ctx)
+		if err != nil {
+			return err
+		}
+		refs.Prefix = prefix
+		if err := refs.Encode(w); err != nil {
+			return err
+		}
+	}
+	if r == nil {
+		return nil
+	}
+	req := packp.NewUploadPackRequest()
+	if err := req.Decode(r); err != nil {
+		return err
+	}
+	resp, err := session.UploadPack(ctx, req)
+	if err != nil {
+		return err
+	}
+	return resp.Encode(w)
+}
+
+// newTestHTTPServer 只读的 smart HTTP 服务，要求 Basic 认证
+func newTestHTTPServer(t *testing.T, repoPath, username, password string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != username || p != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		out := &bytes.Buffer{}
+		var err error
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/info/refs"):
+			w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+			err = uploadPack(r.Context(), repoPath, nil, out, true, []byte("# service=git-upload-pack"), pktline.Flush)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/git-upload-pack"):
+			w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
+			err = uploadPack(r.Context(), repoPath, r.Body, out, false)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(out.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newTestSSHServer 只读的 SSH 服务，只接受 authorized 公钥，返回监听地址与主机公钥
+func newTestSSHServer(t *testing.T, authorized gossh.PublicKey) (string, gossh.PublicKey) {
+	_, hostKey, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, err := gossh.NewSignerFromKey(hostKey)
+	assert.NoError(t, err)
+	config := &gossh.ServerConfig{
+		PublicKeyCallback: func(_ gossh.ConnMetadata, key gossh.PublicKey) (*gossh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unauthorized key")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSH(conn, config)
+		}
+	}()
+	return listener.Addr().String(), hostSigner.PublicKey()
+}
+
+func serveSSH(conn net.Conn, config *gossh.ServerConfig) {
+	defer conn.Close()
+	_, chans, reqs, err := gossh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go gossh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(gossh.UnknownChannelType, "unsupported")
+			continue
+		}
+		ch, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer ch.Close()
+			for req := range requests {
+				if req.Type != "exec" {
+					req.Reply(false, nil)
+					continue
+				}
+				req.Reply(true, nil)
+				// 命令形如 git-upload-pack '/path/to/repo'
+				command := string(req.Payload[4 : 4+binary.BigEndian.Uint32(req.Payload)])
+				path := strings.Trim(strings.TrimPrefix(command, "git-upload-pack "), "'")
+				status := uint32(0)
+				if err := uploadPack(context.Background(), path, ch, ch, true); err != nil {
+					status = 1
+				}
+				ch.SendRequest("exit-status", false, binary.BigEndian.AppendUint32(nil, status))
+				return
+			}
+		}()
+	}
+}
+
+func TestNewAuth(t *testing.T) {
+	auth, err := NewAuth("https://github.com/org/repo.git", &AuthOptions{Token: "secret"})
+	assert.NoError(t, err)
+	assert.Equal(t, &githttp.BasicAuth{Username: "git", Password: "secret"}, auth)
+	auth, err = NewAuth("https://gitlab.com/org/repo.git", &AuthOptions{Username: "oauth2", Token: "secret"})
+	assert.NoError(t, err)
+	assert.Equal(t, "oauth2", auth.(*githttp.BasicAuth).Username)
+	auth, err = NewAuth("https://example.com/repo.git", &AuthOptions{Username: "u", Password: "p"})
+	assert.NoError(t, err)
+	assert.Equal(t, &githttp.BasicAuth{Username: "u", Password: "p"}, auth)
+
+	// 协议与认证方式不匹配或本地路径时不认证
+	auth, err = NewAuth("/tmp/repo", &AuthOptions{Token: "secret"})
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+	auth, err = NewAuth("git@example.com:org/repo.git", &AuthOptions{Token: "secret"})
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+
+	_, err = NewAuth("git@example.com:org/repo.git", &AuthOptions{SSHKey: []byte("not a key")})
+	assert.ErrorIs(t, err, ErrInvalidAuth)
+	_, err = NewAuth("git@example.com:org/repo.git", &AuthOptions{SSHKeyFile: filepath.Join(t.TempDir(), "missing")})
+	assert.ErrorIs(t, err, ErrInvalidAuth)
+
+	// known_hosts 不存在时拒绝，而不是跳过校验
+	_, clientKey, _ := ed25519.GenerateKey(rand.Reader)
+	block, _ := gossh.MarshalPrivateKey(clientKey, "")
+	_, err = NewAuth("ssh://git@example.com/org/repo.git", &AuthOptions{
+		SSHKey: pem.EncodeToMemory(block), KnownHostsFiles: []string{filepath.Join(t.TempDir(), "known_hosts")},
+	})
+	assert.ErrorIs(t, err, ErrInvalidAuth)
+}
+
+func TestHTTPAuth(t *testing.T) {
+	srv := newTestHTTPServer(t, newServedRepo(t), "git", "secret")
+	url := srv.URL + "/repo.git"
+
+	repo, err := NewMemory(url, &AuthOptions{Token: "secret"})
+	assert.NoError(t, err)
+	commits, err := repo.Log(nil)
+	assert.NoError(t, err)
+	assert.Len(t, commits, 1)
+
+	_, err = NewMemory(url, &AuthOptions{Token: "wrong"})
+	assert.Error(t, err)
+	_, err = NewMemory(url, nil)
+	assert.ErrorIs(t, err, transport.ErrAuthenticationRequired)
+}
+
+func TestSSHAuth(t *testing.T) {
+	_, clientKey, _ := ed25519.GenerateKey(rand.Reader)
+	clientSigner, _ := gossh.NewSignerFromKey(clientKey)
+	block, _ := gossh.MarshalPrivateKey(clientKey, "")
+	keyPEM := pem.EncodeToMemory(block)
+
+	addr, hostKey := newTestSSHServer(t, clientSigner.PublicKey())
+	url := "ssh://git@" + addr + newServedRepo(t)
+
+	dir := t.TempDir()
+	knownHosts := filepath.Join(dir, "known_hosts")
+	assert.NoError(t, os.WriteFile(knownHosts, []byte(knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey)+"\n"), 0o600))
+	keyFile := filepath.Join(dir, "id_ed25519")
+	assert.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+
+	for name, opts := range map[string]*AuthOptions{
+		"key":      {SSHKey: keyPEM, KnownHostsFiles: []string{knownHosts}},
+		"key file": {SSHKeyFile: keyFile, KnownHostsFiles: []string{knownHosts}},
+		"callback": {SSHKey: keyPEM, HostKeyCallback: gossh.FixedHostKey(hostKey)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			repo, err := NewMemory(url, opts)
+			assert.NoError(t, err)
+			commits, err := repo.Log(nil)
+			assert.NoError(t, err)
+			assert.Len(t, commits, 1)
+		})
+	}
+
+	// 主机公钥与 known_hosts 不一致时拒绝连接
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherSigner, _ := gossh.NewSignerFromKey(otherKey)
+	mismatched := filepath.Join(dir, "known_hosts_other")
+	assert.NoError(t, os.WriteFile(mismatched, []byte(knownhosts.Line([]string{knownhosts.Normalize(addr)}, otherSigner.PublicKey())+"\n"), 0o600))
+	_, err := NewMemory(url, &AuthOptions{SSHKey: keyPEM, KnownHostsFiles: []string{mismatched}})
+	assert.Error(t, err)
+
+	// 未授权的私钥
+	_, err = NewMemory(url, &AuthOptions{SSHKey: pem.EncodeToMemory(mustMarshalKey(t, otherKey)), KnownHostsFiles: []string{knownHosts}})
+	assert.Error(t, err)
+
+	// ssh-agent
+	sockDir, err := os.MkdirTemp("", "agent")
+	assert.NoError(t, err)
+	defer os.RemoveAll(sockDir)
+	sock := filepath.Join(sockDir, "agent.sock")
+	listener, err := net.Listen("unix", sock)
+	assert.NoError(t, err)
+	defer listener.Close()
+	keyring := agent.NewKeyring()
+	assert.NoError(t, keyring.Add(agent.AddedKey{PrivateKey: clientKey}))
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+	repo, err := NewMemory(url, &AuthOptions{SSHAgent: true, KnownHostsFiles: []string{knownHosts}})
+	assert.NoError(t, err)
+	commits, err := repo.Log(nil)
+	assert.NoError(t, err)
+	assert.Len(t, commits, 1)
+}
+
+func mustMarshalKey(t *testing.T, key ed25519.PrivateKey) *pem.Block {
+	block, err := gossh.MarshalPrivateKey(key, "")
+	assert.NoError(t, err)
+	return block
+}
@@ -0,0 +1,18 @@
+package git
+
+import "errors"
+
+// 预定义错误
+var (
+	ErrNoWorktree       = errors.New("repository has no worktree")
+	ErrRefNotFound      = errors.New("reference not found")
+	ErrBranchExists     = errors.New("branch already exists")
+	ErrBranchCheckedOut = errors.New("branch is checked out")
+	ErrDetachedHead     = errors.New("HEAD is detached")
+	ErrNothingToCommit  = errors.New("nothing to commit")
+	ErrNonFastForward   = errors.New("non fast-forward update")
+	ErrDirtyWorktree    = errors.New("worktree has uncommitted changes")
+	ErrInvalidRefSpec   = errors.New("invalid refspec")
+	ErrInvalidAuth      = errors.New("invalid auth options")
+	ErrNothingToRelease = errors.New("no releasable commits since last version")
+)
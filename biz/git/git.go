@@ -3,17 +3,26 @@ package git
 import (
 	"context"
 	"fmt"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/yi-nology/common/utils/xlogger"
-	"os"
 )
 
+// TagOptions 打标签选项
+type TagOptions struct {
+	Message     string            // 附注标签说明，默认与标签名相同
+	Tagger      *object.Signature // 打标签的人，为空时读取仓库配置
+	Lightweight bool              // 创建轻量标签，忽略 Message、Tagger、SignKey
+	SignKey     *openpgp.Entity   // GPG 签名私钥，需已解密
+	NoPush      bool              // 只在本地创建，不推送到远程
+}
+
 func (i *Info) Branches(log xlogger.Logger) ([]*plumbing.Reference, error) {
-	err := i.project.Fetch(&git.FetchOptions{Auth: i.publicKey})
-	if err != nil && err != git.NoErrAlreadyUpToDate {
+	err := i.project.Fetch(&git.FetchOptions{Auth: i.auth})
+	if err != nil && err != git.NoErrAlreadyUpToDate && err != git.ErrRemoteNotFound {
 		return nil, err
 	}
 
@@ -23,10 +32,12 @@ func (i *Info) Branches(log xlogger.Logger) ([]*plumbing.Reference, error) {
 		return nil, err
 	}
 	var branchesResult []*plumbing.Reference
-	branches.ForEach(func(ref *plumbing.Reference) error {
-		fmt.Println(ref.Name())
+	if err := branches.ForEach(func(ref *plumbing.Reference) error {
+		branchesResult = append(branchesResult, ref)
 		return nil
-	})
+	}); err != nil {
+		return nil, err
+	}
 
 	return branchesResult, nil
 }
@@ -49,19 +60,16 @@ func (i *Info) Tags(log xlogger.Logger) ([]*plumbing.Reference, error) {
 	return tagsResult, nil
 }
 
-func (i *Info) CreateTag(log xlogger.Logger, ctx context.Context, pl plumbing.Reference, version string, sign *object.Signature) error {
-	_, err := i.project.CreateTag(version, pl.Hash(), &git.CreateTagOptions{
-		Tagger:  sign, // 这里可以填入你的信息
-		Message: "this is my tag",
-	})
-	if err != nil {
+// CreateTag 在提交 hash 上创建标签并只推送该标签
+func (i *Info) CreateTag(log xlogger.Logger, ctx context.Context, hash plumbing.Hash, version string, opts *TagOptions) error {
+	if err := i.createTag(ctx, hash, version, opts); err != nil {
 		log.Errorf("CreateTag err!=nil err:%+v", err)
 		return err
 	}
-
-	return i.push(ctx, log)
+	return nil
 }
 
+// DeleteTag 删除本地标签并删除远程的同名标签
 func (i *Info) DeleteTag(log xlogger.Logger, ctx context.Context, version string) error {
 	err := i.project.DeleteTag(version)
 	if err != nil {
@@ -69,20 +77,70 @@ func (i *Info) DeleteTag(log xlogger.Logger, ctx context.Context, version string
 		return err
 	}
 
-	return i.push(ctx, log)
-}
-
-func (i *Info) push(ctx context.Context, log xlogger.Logger) error {
-	if err := i.project.PushContext(ctx, &git.PushOptions{
-		RemoteName: i.url,
-		Progress:   os.Stdout,
-		RefSpecs: []config.RefSpec{
-			"+refs/tags/*:refs/tags/*",
-		},
-		Auth: i.publicKey,
-	}); err != nil {
+	if err := i.pushTag(ctx, ":"+plumbing.NewTagReferenceName(version).String()); err != nil {
 		log.Errorf("push err:%+v", err)
 		return err
 	}
 	return nil
 }
+
+func (i *Info) createTag(ctx context.Context, hash plumbing.Hash, version string, opts *TagOptions) error {
+	if opts == nil {
+		opts = &TagOptions{}
+	}
+	var createOpts *git.CreateTagOptions
+	if !opts.Lightweight {
+		createOpts = &git.CreateTagOptions{Message: opts.Message, Tagger: opts.Tagger, SignKey: opts.SignKey}
+		if createOpts.Message == "" {
+			createOpts.Message = version
+		}
+	}
+	if _, err := i.project.CreateTag(version, hash, createOpts); err != nil {
+		return err
+	}
+	if opts.NoPush {
+		return nil
+	}
+	ref := plumbing.NewTagReferenceName(version)
+	return i.pushTag(ctx, fmt.Sprintf("%s:%s", ref, ref))
+}
+
+// pushTag 推送单个标签，没有远程仓库时跳过
+func (i *Info) pushTag(ctx context.Context, refSpec string) error {
+	if _, err := i.project.Remote(git.DefaultRemoteName); err == git.ErrRemoteNotFound {
+		return nil
+	}
+	return i.Push(ctx, &PushOptions{RefSpecs: []string{refSpec}})
+}
+
+// ListTags 列出标签，附注标签解析出说明与打标签的人，Hash 均为指向的提交
+func (i *Info) ListTags() ([]*Tag, error) {
+	refs, err := i.project.Tags()
+	if err != nil {
+		return nil, err
+	}
+	var tags []*Tag
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		tag := &Tag{Name: ref.Name().Short(), Hash: ref.Hash().String()}
+		annotated, err := i.project.TagObject(ref.Hash())
+		switch err {
+		case nil:
+			commit, err := annotated.Commit()
+			if err != nil {
+				// 指向非提交对象的标签
+				return nil
+			}
+			tag.Hash = commit.Hash.String()
+			tag.Annotated = true
+			tag.Message = annotated.Message
+			tag.Tagger = &Signature{Name: annotated.Tagger.Name, Email: annotated.Tagger.Email, When: annotated.Tagger.When}
+			tag.Signed = annotated.PGPSignature != ""
+		case plumbing.ErrObjectNotFound:
+		default:
+			return err
+		}
+		tags = append(tags, tag)
+		return nil
+	})
+	return tags, err
+}
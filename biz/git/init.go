@@ -1,34 +1,98 @@
 package git
 
 import (
+	"context"
+	"io"
+
+	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/storage/memory"
-	"os"
 )
 
 type Info struct {
-	project   *git.Repository
-	url       string
-	publicKey *ssh.PublicKeys
+	project *git.Repository
+	url     string
+	auth    transport.AuthMethod
+}
+
+// CloneOptions 克隆选项
+type CloneOptions struct {
+	Auth         transport.AuthMethod // 认证方式，公开仓库可为空
+	RemoteName   string               // 远程名，默认 origin
+	Branch       string               // 检出的分支，默认远程 HEAD 指向的分支
+	Depth        int                  // 浅克隆深度，0 表示完整历史
+	SingleBranch bool                 // 只获取 Branch 指定的分支
+	Bare         bool                 // 不检出工作区，仅克隆到磁盘时有效
+	Progress     io.Writer            // 进度输出，为空时不输出
 }
 
-func NewMemory(url, sshKeyStr string, password ...string) *Info {
-	pw := ""
-	if len(password) != 0 {
-		pw = password[0]
+func (o *CloneOptions) toGit(url string) *git.CloneOptions {
+	opts := &git.CloneOptions{URL: url}
+	if o == nil {
+		return opts
 	}
-	publicKey, err := ssh.NewPublicKeys("git", []byte(sshKeyStr), pw)
-	if err != nil {
+	opts.Auth = o.Auth
+	opts.RemoteName = o.RemoteName
+	opts.Depth = o.Depth
+	opts.SingleBranch = o.SingleBranch
+	opts.Progress = o.Progress
+	if o.Branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(o.Branch)
+	}
+	return opts
+}
+
+func (o *CloneOptions) authMethod() transport.AuthMethod {
+	if o == nil {
 		return nil
 	}
-	r, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
-		Progress: os.Stdout,
-		URL:      url,
-		Auth:     publicKey,
-	})
+	return o.Auth
+}
+
+// NewMemory 使用认证选项将仓库克隆到内存
+func NewMemory(url string, auth *AuthOptions) (*Info, error) {
+	method, err := NewAuth(url, auth)
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	return &Info{project: r, url: url, publicKey: publicKey}
+	return CloneMemory(context.Background(), url, &CloneOptions{Auth: method})
+}
+
+// Clone 克隆仓库到磁盘目录 dir
+func Clone(ctx context.Context, url, dir string, opts *CloneOptions) (*Info, error) {
+	bare := opts != nil && opts.Bare
+	r, err := git.PlainCloneContext(ctx, dir, bare, opts.toGit(url))
+	if err != nil {
+		return nil, err
+	}
+	return &Info{project: r, url: url, auth: opts.authMethod()}, nil
+}
+
+// CloneMemory 克隆仓库到内存，工作区同样位于内存中，可检出与提交
+func CloneMemory(ctx context.Context, url string, opts *CloneOptions) (*Info, error) {
+	r, err := git.CloneContext(ctx, memory.NewStorage(), memfs.New(), opts.toGit(url))
+	if err != nil {
+		return nil, err
+	}
+	return &Info{project: r, url: url, auth: opts.authMethod()}, nil
+}
+
+// Open 打开已有仓库，dir 可以是工作区内的任意子目录；auth 用于后续 Fetch、Push、Pull
+func Open(dir string, auth transport.AuthMethod) (*Info, error) {
+	r, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{project: r, auth: auth}
+	if remote, err := r.Remote(git.DefaultRemoteName); err == nil && len(remote.Config().URLs) > 0 {
+		info.url = remote.Config().URLs[0]
+	}
+	return info, nil
+}
+
+// Repository 底层 go-git 仓库，用于本包未封装的操作
+func (i *Info) Repository() *git.Repository {
+	return i.project
 }
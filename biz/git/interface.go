@@ -3,7 +3,6 @@ package git
 import (
 	"context"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/yi-nology/common/utils/xlogger"
 )
 
@@ -16,6 +15,30 @@ import (
 type Git interface {
 	Branches(log xlogger.Logger) ([]*plumbing.Reference, error)
 	Tags(log xlogger.Logger) ([]*plumbing.Reference, error)
-	CreateTag(log xlogger.Logger, ctx context.Context, pl plumbing.Reference, version string, sign *object.Signature) error
+	CreateTag(log xlogger.Logger, ctx context.Context, hash plumbing.Hash, version string, opts *TagOptions) error
 	DeleteTag(log xlogger.Logger, ctx context.Context, version string) error
+	ListTags() ([]*Tag, error)
+	LatestVersion() (*Tag, error)
+	NextRelease(ctx context.Context, opts *ReleaseOptions) (*Release, error)
+
+	CurrentBranch() (string, error)
+	ListBranches() ([]*Branch, error)
+	CreateBranch(name, rev string) (*Branch, error)
+	DeleteBranch(name string) error
+	Checkout(opts *CheckoutOptions) error
+	Add(paths ...string) error
+	Commit(message string, opts *CommitOptions) (*Commit, error)
+	Log(opts *LogOptions) ([]*Commit, error)
+	Diff(from, to string) ([]*FileDiff, error)
+	Patch(from, to string) (string, error)
+	Fetch(ctx context.Context, opts *FetchOptions) error
+	Push(ctx context.Context, opts *PushOptions) error
+	Pull(ctx context.Context, opts *PullOptions) error
+
+	Analyze(ctx context.Context, opts *AnalyticsOptions) (*Report, error)
+	Contributors(ctx context.Context, opts *AnalyticsOptions) ([]*AuthorStats, error)
+	HotSpots(ctx context.Context, opts *AnalyticsOptions, limit int) ([]*FileChurn, error)
+	Blame(rev, path string) (*FileBlame, error)
 }
+
+var _ Git = (*Info)(nil)
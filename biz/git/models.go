@@ -0,0 +1,57 @@
+package git
+
+import "time"
+
+// FileChangeType 文件变更类型
+type FileChangeType string
+
+const (
+	FileAdded    FileChangeType = "added"
+	FileModified FileChangeType = "modified"
+	FileDeleted  FileChangeType = "deleted"
+	FileRenamed  FileChangeType = "renamed"
+)
+
+// Branch 分支信息
+type Branch struct {
+	Name    string `json:"name"` // 远程分支带远程名前缀，如 origin/main
+	Hash    string `json:"hash"`
+	Remote  bool   `json:"remote"`
+	Current bool   `json:"current"`
+}
+
+// Signature 作者或提交者
+type Signature struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	When  time.Time `json:"when"`
+}
+
+// Commit 提交信息
+type Commit struct {
+	Hash      string    `json:"hash"`
+	Message   string    `json:"message"`
+	Author    Signature `json:"author"`
+	Committer Signature `json:"committer"`
+	Parents   []string  `json:"parents,omitempty"`
+}
+
+// Tag 标签信息
+type Tag struct {
+	Name      string     `json:"name"`
+	Hash      string     `json:"hash"` // 指向的提交
+	Annotated bool       `json:"annotated"`
+	Signed    bool       `json:"signed"`
+	Message   string     `json:"message,omitempty"`
+	Tagger    *Signature `json:"tagger,omitempty"`
+}
+
+// FileDiff 两个修订之间单个文件的变更
+type FileDiff struct {
+	Path      string         `json:"path"`               // 变更后的路径，删除时为原路径
+	OldPath   string         `json:"old_path,omitempty"` // 重命名前的路径
+	Type      FileChangeType `json:"type"`
+	Additions int            `json:"additions"`
+	Deletions int            `json:"deletions"`
+	Binary    bool           `json:"binary"`
+}
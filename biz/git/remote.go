@@ -0,0 +1,191 @@
+package git
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// FetchOptions 拉取远程引用选项
+type FetchOptions struct {
+	RemoteName string   // 远程名，默认 origin
+	RefSpecs   []string // 如 "+refs/heads/main:refs/remotes/origin/main"，为空时使用远程配置
+	Tags       bool     // 同时拉取所有标签
+	Force      bool     // 允许非快进更新本地引用
+	Depth      int
+}
+
+// PushOptions 推送选项
+type PushOptions struct {
+	RemoteName string   // 远程名，默认 origin
+	RefSpecs   []string // 如 "refs/heads/main:refs/heads/main"，删除远程引用使用 ":refs/heads/feature"；为空时推送当前分支
+	Force      bool     // 强制推送
+}
+
+// PullOptions 拉取并快进合并选项
+type PullOptions struct {
+	RemoteName string   // 远程名，默认 origin
+	Branch     string   // 远程分支，默认与当前分支同名
+	RefSpecs   []string // 为空时使用 "+refs/heads/<Branch>:refs/remotes/<RemoteName>/<Branch>"
+}
+
+func remoteName(name string) string {
+	if name == "" {
+		return git.DefaultRemoteName
+	}
+	return name
+}
+
+func parseRefSpecs(specs []string) ([]config.RefSpec, error) {
+	refSpecs := make([]config.RefSpec, 0, len(specs))
+	for _, spec := range specs {
+		refSpec := config.RefSpec(spec)
+		if err := refSpec.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRefSpec, spec, err)
+		}
+		refSpecs = append(refSpecs, refSpec)
+	}
+	return refSpecs, nil
+}
+
+// Fetch 拉取远程引用，已是最新时返回 nil
+func (i *Info) Fetch(ctx context.Context, opts *FetchOptions) error {
+	if opts == nil {
+		opts = &FetchOptions{}
+	}
+	refSpecs, err := parseRefSpecs(opts.RefSpecs)
+	if err != nil {
+		return err
+	}
+	fetch := &git.FetchOptions{
+		RemoteName: remoteName(opts.RemoteName),
+		RefSpecs:   refSpecs,
+		Force:      opts.Force,
+		Depth:      opts.Depth,
+		Auth:       i.auth,
+	}
+	if opts.Tags {
+		fetch.Tags = git.AllTags
+	}
+	if err := i.project.FetchContext(ctx, fetch); err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
+	return nil
+}
+
+// Push 按 RefSpecs 推送，已是最新时返回 nil
+func (i *Info) Push(ctx context.Context, opts *PushOptions) error {
+	if opts == nil {
+		opts = &PushOptions{}
+	}
+	specs := opts.RefSpecs
+	if len(specs) == 0 {
+		branch, err := i.CurrentBranch()
+		if err != nil {
+			return err
+		}
+		ref := plumbing.NewBranchReferenceName(branch)
+		specs = []string{fmt.Sprintf("%s:%s", ref, ref)}
+	}
+	refSpecs, err := parseRefSpecs(specs)
+	if err != nil {
+		return err
+	}
+	err = i.project.PushContext(ctx, &git.PushOptions{
+		RemoteName: remoteName(opts.RemoteName),
+		RefSpecs:   refSpecs,
+		Force:      opts.Force,
+		Auth:       i.auth,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
+	return nil
+}
+
+// Pull 拉取远程分支并快进合并到当前分支，无法快进时返回 ErrNonFastForward，工作区有未提交修改时返回 ErrDirtyWorktree
+func (i *Info) Pull(ctx context.Context, opts *PullOptions) error {
+	if opts == nil {
+		opts = &PullOptions{}
+	}
+	current, err := i.CurrentBranch()
+	if err != nil {
+		return err
+	}
+	remote, branch := remoteName(opts.RemoteName), opts.Branch
+	if branch == "" {
+		branch = current
+	}
+	specs := opts.RefSpecs
+	if len(specs) == 0 {
+		specs = []string{fmt.Sprintf("+%s:%s", plumbing.NewBranchReferenceName(branch), plumbing.NewRemoteReferenceName(remote, branch))}
+	}
+	if err := i.Fetch(ctx, &FetchOptions{RemoteName: remote, RefSpecs: specs}); err != nil {
+		return err
+	}
+
+	// 合并来源为远程分支在本地的映射目标
+	refSpecs, _ := parseRefSpecs(specs)
+	src := plumbing.NewBranchReferenceName(branch)
+	var target plumbing.ReferenceName
+	for _, refSpec := range refSpecs {
+		if !refSpec.IsDelete() && refSpec.Match(src) {
+			target = refSpec.Dst(src)
+			break
+		}
+	}
+	if target == "" {
+		return fmt.Errorf("%w: no refspec fetches %s", ErrInvalidRefSpec, src)
+	}
+	return i.fastForward(current, target)
+}
+
+// fastForward 将当前分支快进到 target 并更新工作区
+func (i *Info) fastForward(branch string, target plumbing.ReferenceName) error {
+	targetRef, err := i.project.Reference(target, true)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrRefNotFound, target)
+	}
+	head, err := i.project.Head()
+	if err != nil {
+		return err
+	}
+	if head.Hash() == targetRef.Hash() {
+		return nil
+	}
+	headCommit, err := i.project.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+	targetCommit, err := i.project.CommitObject(targetRef.Hash())
+	if err != nil {
+		return err
+	}
+	if behind, err := targetCommit.IsAncestor(headCommit); err != nil {
+		return err
+	} else if behind {
+		return nil
+	}
+	if ff, err := headCommit.IsAncestor(targetCommit); err != nil {
+		return err
+	} else if !ff {
+		return fmt.Errorf("%w: %s cannot fast-forward to %s", ErrNonFastForward, branch, target.Short())
+	}
+
+	w, err := i.worktree()
+	if err != nil {
+		return err
+	}
+	// 先检查工作区，避免分支已移动而文件未更新；Reset 会同时移动 HEAD 指向的分支
+	status, err := w.Status()
+	if err != nil {
+		return err
+	}
+	if hasChanges(status, true) {
+		return ErrDirtyWorktree
+	}
+	return w.Reset(&git.ResetOptions{Mode: git.MergeReset, Commit: targetRef.Hash()})
+}
@@ -0,0 +1,362 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// CheckoutOptions 检出选项
+type CheckoutOptions struct {
+	Branch string // 切换到的本地分支
+	Rev    string // 切换到的修订（提交、标签等），Branch 为空时分离 HEAD；Create 时作为新分支的起点
+	Create bool   // 基于 Rev（默认 HEAD）创建 Branch
+	Force  bool   // 丢弃工作区中未提交的修改
+}
+
+// CommitOptions 提交选项
+type CommitOptions struct {
+	Author     *object.Signature // 作者，为空时读取仓库配置中的 user.name、user.email
+	Committer  *object.Signature // 提交者，默认与作者相同
+	All        bool              // 提交前暂存所有已跟踪文件的修改与删除
+	AllowEmpty bool              // 允许没有变更的提交
+}
+
+// LogOptions 提交历史查询选项
+type LogOptions struct {
+	From  string    // 起始修订，默认 HEAD
+	Paths []string  // 只返回修改了这些文件或目录的提交
+	Since time.Time // 提交时间下限，零值不限制
+	Until time.Time // 提交时间上限，零值不限制
+	Limit int       // 最多返回的提交数，0 不限制
+}
+
+// worktree 获取工作区，裸仓库返回 ErrNoWorktree
+func (i *Info) worktree() (*git.Worktree, error) {
+	w, err := i.project.Worktree()
+	if err == git.ErrIsBareRepository {
+		return nil, ErrNoWorktree
+	}
+	return w, err
+}
+
+// resolve 将分支、标签或提交哈希解析为提交哈希
+func (i *Info) resolve(rev string) (plumbing.Hash, error) {
+	hash, err := i.project.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("%w: %s: %v", ErrRefNotFound, rev, err)
+	}
+	return *hash, nil
+}
+
+// CurrentBranch 当前分支名，HEAD 分离时返回 ErrDetachedHead
+func (i *Info) CurrentBranch() (string, error) {
+	head, err := i.project.Head()
+	if err != nil {
+		return "", err
+	}
+	if !head.Name().IsBranch() {
+		return "", ErrDetachedHead
+	}
+	return head.Name().Short(), nil
+}
+
+// ListBranches 列出本地分支与远程跟踪分支
+func (i *Info) ListBranches() ([]*Branch, error) {
+	current, _ := i.CurrentBranch()
+	refs, err := i.project.References()
+	if err != nil {
+		return nil, err
+	}
+	var branches []*Branch
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		switch {
+		case ref.Name().IsBranch():
+			name := ref.Name().Short()
+			branches = append(branches, &Branch{Name: name, Hash: ref.Hash().String(), Current: name == current})
+		case ref.Name().IsRemote():
+			branches = append(branches, &Branch{Name: ref.Name().Short(), Hash: ref.Hash().String(), Remote: true})
+		}
+		return nil
+	})
+	return branches, err
+}
+
+// CreateBranch 基于修订 rev 创建本地分支，rev 为空时基于 HEAD，不切换当前分支
+func (i *Info) CreateBranch(name, rev string) (*Branch, error) {
+	if rev == "" {
+		rev = string(plumbing.HEAD)
+	}
+	refName := plumbing.NewBranchReferenceName(name)
+	if _, err := i.project.Reference(refName, false); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBranchExists, name)
+	}
+	hash, err := i.resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.project.Storer.SetReference(plumbing.NewHashReference(refName, hash)); err != nil {
+		return nil, err
+	}
+	return &Branch{Name: name, Hash: hash.String()}, nil
+}
+
+// DeleteBranch 删除本地分支及其跟踪配置，不能删除当前分支；删除远程分支使用 Push 推送 ":refs/heads/<name>"
+func (i *Info) DeleteBranch(name string) error {
+	refName := plumbing.NewBranchReferenceName(name)
+	if _, err := i.project.Reference(refName, false); err != nil {
+		return fmt.Errorf("%w: %s", ErrRefNotFound, name)
+	}
+	if current, _ := i.CurrentBranch(); current == name {
+		return fmt.Errorf("%w: %s", ErrBranchCheckedOut, name)
+	}
+	if err := i.project.DeleteBranch(name); err != nil && err != git.ErrBranchNotFound {
+		return err
+	}
+	return i.project.Storer.RemoveReference(refName)
+}
+
+// Checkout 检出分支或修订，未指定 Branch 与 Rev 时重新检出当前 HEAD
+func (i *Info) Checkout(opts *CheckoutOptions) error {
+	w, err := i.worktree()
+	if err != nil {
+		return err
+	}
+	if opts == nil {
+		opts = &CheckoutOptions{}
+	}
+	branch, rev := opts.Branch, opts.Rev
+	if branch == "" && rev == "" {
+		if branch, err = i.CurrentBranch(); err != nil {
+			branch, rev = "", "HEAD"
+		}
+	}
+	checkout := &git.CheckoutOptions{Create: opts.Create, Force: opts.Force}
+	if branch != "" {
+		checkout.Branch = plumbing.NewBranchReferenceName(branch)
+	}
+	if rev != "" && (opts.Create || branch == "") {
+		if checkout.Hash, err = i.resolve(rev); err != nil {
+			return err
+		}
+	}
+	return w.Checkout(checkout)
+}
+
+// Add 暂存文件或目录，不传参数时暂存工作区所有变更
+func (i *Info) Add(paths ...string) error {
+	w, err := i.worktree()
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return w.AddWithOptions(&git.AddOptions{All: true})
+	}
+	for _, path := range paths {
+		if _, err := w.Add(path); err != nil {
+			return fmt.Errorf("add %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Commit 提交已暂存的变更
+func (i *Info) Commit(message string, opts *CommitOptions) (*Commit, error) {
+	w, err := i.worktree()
+	if err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &CommitOptions{}
+	}
+	if !opts.AllowEmpty {
+		status, err := w.Status()
+		if err != nil {
+			return nil, err
+		}
+		if !hasChanges(status, opts.All) {
+			return nil, ErrNothingToCommit
+		}
+	}
+	hash, err := w.Commit(message, &git.CommitOptions{
+		All:               opts.All,
+		AllowEmptyCommits: opts.AllowEmpty,
+		Author:            opts.Author,
+		Committer:         opts.Committer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c, err := i.project.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	return toCommit(c), nil
+}
+
+// hasChanges 暂存区是否有待提交的变更，all 时同时检查已跟踪文件在工作区中的修改
+func hasChanges(status git.Status, all bool) bool {
+	for _, file := range status {
+		if file.Staging != git.Unmodified && file.Staging != git.Untracked {
+			return true
+		}
+		if all && file.Worktree != git.Unmodified && file.Worktree != git.Untracked {
+			return true
+		}
+	}
+	return false
+}
+
+// Log 按提交时间倒序查询提交历史
+func (i *Info) Log(opts *LogOptions) ([]*Commit, error) {
+	if opts == nil {
+		opts = &LogOptions{}
+	}
+	logOpts := &git.LogOptions{Order: git.LogOrderCommitterTime}
+	if opts.From != "" {
+		hash, err := i.resolve(opts.From)
+		if err != nil {
+			return nil, err
+		}
+		logOpts.From = hash
+	}
+	if len(opts.Paths) > 0 {
+		logOpts.PathFilter = pathFilter(opts.Paths)
+	}
+	if !opts.Since.IsZero() {
+		logOpts.Since = &opts.Since
+	}
+	if !opts.Until.IsZero() {
+		logOpts.Until = &opts.Until
+	}
+
+	iter, err := i.project.Log(logOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var commits []*Commit
+	err = iter.ForEach(func(c *object.Commit) error {
+		commits = append(commits, toCommit(c))
+		if opts.Limit > 0 && len(commits) >= opts.Limit {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	return commits, err
+}
+
+// Diff 比较两个修订的文件变更，from 为空时与空树比较
+func (i *Info) Diff(from, to string) ([]*FileDiff, error) {
+	patch, err := i.patch(from, to)
+	if err != nil {
+		return nil, err
+	}
+	var files []*FileDiff
+	for _, fp := range patch.FilePatches() {
+		fromFile, toFile := fp.Files()
+		d := &FileDiff{Binary: fp.IsBinary()}
+		switch {
+		case fromFile == nil:
+			d.Type, d.Path = FileAdded, toFile.Path()
+		case toFile == nil:
+			d.Type, d.Path = FileDeleted, fromFile.Path()
+		case fromFile.Path() != toFile.Path():
+			d.Type, d.Path, d.OldPath = FileRenamed, toFile.Path(), fromFile.Path()
+		default:
+			d.Type, d.Path = FileModified, toFile.Path()
+		}
+		for _, chunk := range fp.Chunks() {
+			content := chunk.Content()
+			lines := strings.Count(content, "\n")
+			if content != "" && !strings.HasSuffix(content, "\n") {
+				lines++
+			}
+			switch chunk.Type() {
+			case diff.Add:
+				d.Additions += lines
+			case diff.Delete:
+				d.Deletions += lines
+			}
+		}
+		files = append(files, d)
+	}
+	return files, nil
+}
+
+// Patch 两个修订之间的统一格式差异
+func (i *Info) Patch(from, to string) (string, error) {
+	patch, err := i.patch(from, to)
+	if err != nil {
+		return "", err
+	}
+	return patch.String(), nil
+}
+
+func (i *Info) patch(from, to string) (*object.Patch, error) {
+	fromTree, err := i.tree(from)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := i.tree(to)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTreeWithOptions(context.Background(), fromTree, toTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, err
+	}
+	return changes.Patch()
+}
+
+// tree 修订对应的目录树，rev 为空时返回 nil 表示空树
+func (i *Info) tree(rev string) (*object.Tree, error) {
+	if rev == "" {
+		return nil, nil
+	}
+	hash, err := i.resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+	c, err := i.project.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	return c.Tree()
+}
+
+// pathFilter 匹配文件本身或目录下的文件
+func pathFilter(paths []string) func(string) bool {
+	return func(file string) bool {
+		for _, p := range paths {
+			p = strings.TrimSuffix(p, "/")
+			if file == p || strings.HasPrefix(file, p+"/") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func toCommit(c *object.Commit) *Commit {
+	commit := &Commit{
+		Hash:      c.Hash.String(),
+		Message:   c.Message,
+		Author:    Signature{Name: c.Author.Name, Email: c.Author.Email, When: c.Author.When},
+		Committer: Signature{Name: c.Committer.Name, Email: c.Committer.Email, When: c.Committer.When},
+	}
+	for _, parent := range c.ParentHashes {
+		commit.Parents = append(commit.Parents, parent.String())
+	}
+	return commit
+}
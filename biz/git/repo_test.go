@@ -0,0 +1,246 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+var testAuthor = &object.Signature{Name: "Dev", Email: "dev@example.com", When: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+// newTestRepo 在临时目录初始化仓库
+func newTestRepo(t *testing.T) (*Info, string) {
+	dir := t.TempDir()
+	_, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	repo, err := Open(dir, nil)
+	assert.NoError(t, err)
+	return repo, dir
+}
+
+// commitFile 写入文件并提交，提交时间依次递增
+func commitFile(t *testing.T, repo *Info, dir, path, content, message string) *Commit {
+	full := filepath.Join(dir, path)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+	assert.NoError(t, os.WriteFile(full, []byte(content), 0o644))
+	assert.NoError(t, repo.Add(path))
+	author := *testAuthor
+	testAuthor.When = testAuthor.When.Add(time.Hour)
+	commit, err := repo.Commit(message, &CommitOptions{Author: &author})
+	assert.NoError(t, err)
+	return commit
+}
+
+func TestCommitLogDiff(t *testing.T) {
+	repo, dir := newTestRepo(t)
+	first := commitFile(t, repo, dir, "a.txt", "one\n", "feat: add a")
+	commitFile(t, repo, dir, "docs/b.md", "# b\n", "docs: add b")
+	third := commitFile(t, repo, dir, "a.txt", "one\ntwo\nthree\n", "fix: update a")
+	assert.Equal(t, []string{first.Hash}, logHashes(t, repo, &LogOptions{From: first.Hash}))
+	assert.Equal(t, "Dev", third.Author.Name)
+
+	commits, err := repo.Log(nil)
+	assert.NoError(t, err)
+	assert.Len(t, commits, 3)
+	assert.Equal(t, "fix: update a", commits[0].Message)
+	assert.Equal(t, []string{commits[1].Hash}, commits[0].Parents)
+
+	assert.Len(t, logHashes(t, repo, &LogOptions{Paths: []string{"a.txt"}}), 2)
+	assert.Len(t, logHashes(t, repo, &LogOptions{Paths: []string{"docs/"}}), 1)
+	assert.Len(t, logHashes(t, repo, &LogOptions{Limit: 2}), 2)
+	assert.Len(t, logHashes(t, repo, &LogOptions{Since: commits[1].Committer.When}), 2)
+
+	files, err := repo.Diff(first.Hash, "HEAD")
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+	for _, f := range files {
+		switch f.Path {
+		case "a.txt":
+			assert.Equal(t, FileModified, f.Type)
+			assert.Equal(t, 2, f.Additions)
+			assert.Equal(t, 0, f.Deletions)
+		case "docs/b.md":
+			assert.Equal(t, FileAdded, f.Type)
+			assert.Equal(t, 1, f.Additions)
+		default:
+			t.Errorf("unexpected file %s", f.Path)
+		}
+	}
+	patch, err := repo.Patch(first.Hash, third.Hash)
+	assert.NoError(t, err)
+	assert.Contains(t, patch, "+three")
+
+	// from 为空时与空树比较
+	files, err = repo.Diff("", first.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, FileAdded, files[0].Type)
+
+	// 重命名与删除
+	assert.NoError(t, os.Rename(filepath.Join(dir, "docs/b.md"), filepath.Join(dir, "docs/c.md")))
+	assert.NoError(t, os.Remove(filepath.Join(dir, "a.txt")))
+	assert.NoError(t, repo.Add())
+	_, err = repo.Commit("chore: move", &CommitOptions{Author: testAuthor})
+	assert.NoError(t, err)
+	files, err = repo.Diff(third.Hash, "HEAD")
+	assert.NoError(t, err)
+	types := map[FileChangeType]*FileDiff{}
+	for _, f := range files {
+		types[f.Type] = f
+	}
+	assert.Equal(t, "docs/b.md", types[FileRenamed].OldPath)
+	assert.Equal(t, 3, types[FileDeleted].Deletions)
+
+	_, err = repo.Commit("empty", &CommitOptions{Author: testAuthor})
+	assert.ErrorIs(t, err, ErrNothingToCommit)
+	_, err = repo.Diff("missing", "HEAD")
+	assert.ErrorIs(t, err, ErrRefNotFound)
+}
+
+func logHashes(t *testing.T, repo *Info, opts *LogOptions) []string {
+	commits, err := repo.Log(opts)
+	assert.NoError(t, err)
+	var hashes []string
+	for _, c := range commits {
+		hashes = append(hashes, c.Hash)
+	}
+	return hashes
+}
+
+func TestBranchCheckout(t *testing.T) {
+	repo, dir := newTestRepo(t)
+	first := commitFile(t, repo, dir, "a.txt", "one\n", "init")
+
+	branch, err := repo.CreateBranch("feature", "")
+	assert.NoError(t, err)
+	assert.Equal(t, first.Hash, branch.Hash)
+	_, err = repo.CreateBranch("feature", "")
+	assert.ErrorIs(t, err, ErrBranchExists)
+
+	assert.NoError(t, repo.Checkout(&CheckoutOptions{Branch: "feature"}))
+	commitFile(t, repo, dir, "a.txt", "two\n", "change on feature")
+	current, err := repo.CurrentBranch()
+	assert.NoError(t, err)
+	assert.Equal(t, "feature", current)
+	assert.ErrorIs(t, repo.DeleteBranch("feature"), ErrBranchCheckedOut)
+
+	// 基于指定修订创建并切换
+	assert.NoError(t, repo.Checkout(&CheckoutOptions{Branch: "hotfix", Rev: first.Hash, Create: true}))
+	data, _ := os.ReadFile(filepath.Join(dir, "a.txt"))
+	assert.Equal(t, "one\n", string(data))
+
+	branches, err := repo.ListBranches()
+	assert.NoError(t, err)
+	names := map[string]bool{}
+	for _, b := range branches {
+		names[b.Name] = b.Current
+	}
+	assert.Equal(t, map[string]bool{"master": false, "feature": false, "hotfix": true}, names)
+
+	// 未指定选项时重新检出当前分支，丢弃工作区修改需 Force
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("dirty\n"), 0o644)
+	assert.NoError(t, repo.Checkout(&CheckoutOptions{Force: true}))
+	data, _ = os.ReadFile(filepath.Join(dir, "a.txt"))
+	assert.Equal(t, "one\n", string(data))
+	assert.NoError(t, repo.Checkout(nil))
+	current, _ = repo.CurrentBranch()
+	assert.Equal(t, "hotfix", current)
+
+	// 分离 HEAD
+	assert.NoError(t, repo.Checkout(&CheckoutOptions{Rev: "feature"}))
+	_, err = repo.CurrentBranch()
+	assert.ErrorIs(t, err, ErrDetachedHead)
+
+	assert.NoError(t, repo.DeleteBranch("hotfix"))
+	assert.ErrorIs(t, repo.DeleteBranch("hotfix"), ErrRefNotFound)
+}
+
+func TestRemoteOperations(t *testing.T) {
+	ctx := context.Background()
+	remoteDir := t.TempDir()
+	_, err := git.PlainInit(remoteDir, true)
+	assert.NoError(t, err)
+
+	local, localDir := newTestRepo(t)
+	commitFile(t, local, localDir, "a.txt", "one\n", "init")
+	_, err = local.Repository().CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}})
+	assert.NoError(t, err)
+	assert.NoError(t, local.Push(ctx, nil))
+	assert.NoError(t, local.Push(ctx, nil))
+
+	cloneDir := t.TempDir()
+	clone, err := Clone(ctx, remoteDir, cloneDir, &CloneOptions{Branch: "master"})
+	assert.NoError(t, err)
+	memClone, err := CloneMemory(ctx, remoteDir, nil)
+	assert.NoError(t, err)
+	for _, repo := range []*Info{clone, memClone} {
+		commits, err := repo.Log(nil)
+		assert.NoError(t, err)
+		assert.Len(t, commits, 1)
+	}
+
+	// 推送新提交后快进拉取
+	second := commitFile(t, local, localDir, "a.txt", "two\n", "update")
+	assert.NoError(t, local.Push(ctx, &PushOptions{RefSpecs: []string{"refs/heads/master:refs/heads/master"}}))
+	assert.NoError(t, clone.Pull(ctx, nil))
+	data, _ := os.ReadFile(filepath.Join(cloneDir, "a.txt"))
+	assert.Equal(t, "two\n", string(data))
+	commits, _ := clone.Log(&LogOptions{Limit: 1})
+	assert.Equal(t, second.Hash, commits[0].Hash)
+	assert.NoError(t, clone.Pull(ctx, nil))
+
+	// 工作区有未提交修改时拒绝拉取，分支与文件均保持不变
+	third := commitFile(t, local, localDir, "a.txt", "three\n", "update again")
+	assert.NoError(t, local.Push(ctx, &PushOptions{RefSpecs: []string{"refs/heads/master:refs/heads/master"}}))
+	assert.NoError(t, os.WriteFile(filepath.Join(cloneDir, "a.txt"), []byte("dirty\n"), 0644))
+	assert.ErrorIs(t, clone.Pull(ctx, nil), ErrDirtyWorktree)
+	commits, _ = clone.Log(&LogOptions{Limit: 1})
+	assert.Equal(t, second.Hash, commits[0].Hash)
+	data, _ = os.ReadFile(filepath.Join(cloneDir, "a.txt"))
+	assert.Equal(t, "dirty\n", string(data))
+	assert.NoError(t, os.WriteFile(filepath.Join(cloneDir, "a.txt"), []byte("two\n"), 0644))
+	assert.NoError(t, clone.Pull(ctx, nil))
+	commits, _ = clone.Log(&LogOptions{Limit: 1})
+	assert.Equal(t, third.Hash, commits[0].Hash)
+	data, _ = os.ReadFile(filepath.Join(cloneDir, "a.txt"))
+	assert.Equal(t, "three\n", string(data))
+
+	// 内存克隆同样可以提交和推送
+	w, _ := memClone.Repository().Worktree()
+	assert.NoError(t, memClone.Pull(ctx, nil))
+	f, _ := w.Filesystem.Create("b.txt")
+	f.Write([]byte("b\n"))
+	f.Close()
+	assert.NoError(t, memClone.Add("b.txt"))
+	_, err = memClone.Commit("add b", &CommitOptions{Author: testAuthor})
+	assert.NoError(t, err)
+	assert.NoError(t, memClone.Push(ctx, &PushOptions{RefSpecs: []string{"refs/heads/master:refs/heads/feature"}}))
+	assert.NoError(t, local.Fetch(ctx, &FetchOptions{RefSpecs: []string{"+refs/heads/*:refs/remotes/origin/*"}}))
+	branches, _ := local.ListBranches()
+	var remotes []string
+	for _, b := range branches {
+		if b.Remote {
+			remotes = append(remotes, b.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{"origin/master", "origin/feature"}, remotes)
+
+	// 分叉后无法快进
+	commitFile(t, clone, cloneDir, "c.txt", "c\n", "diverge")
+	assert.NoError(t, memClone.Push(ctx, &PushOptions{RefSpecs: []string{"refs/heads/master:refs/heads/master"}}))
+	assert.ErrorIs(t, clone.Pull(ctx, nil), ErrNonFastForward)
+
+	// 删除远程分支
+	assert.NoError(t, local.Push(ctx, &PushOptions{RefSpecs: []string{":refs/heads/feature"}}))
+	remote, _ := git.PlainOpen(remoteDir)
+	_, err = remote.Reference("refs/heads/feature", false)
+	assert.Error(t, err)
+
+	assert.ErrorIs(t, local.Push(ctx, &PushOptions{RefSpecs: []string{"bad"}}), ErrInvalidRefSpec)
+}
@@ -0,0 +1,233 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var (
+	versionPattern      = regexp.MustCompile(`^v(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)$`)
+	conventionalPattern = regexp.MustCompile(`^(\w+)(\([^)]*\))?(!)?: \S`)
+)
+
+// Bump 版本号递增级别
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// MarshalText 以级别名称输出
+func (b Bump) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// Version 语义化版本号 vX.Y.Z
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion 解析 vX.Y.Z 格式的版本号，不支持预发布与构建元数据
+func ParseVersion(s string) (Version, bool) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, false
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	return Version{Major: major, Minor: minor, Patch: patch}, true
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less 版本号是否小于 other
+func (v Version) Less(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	return v.Patch < other.Patch
+}
+
+// Next 按级别递增版本号
+func (v Version) Next(bump Bump) Version {
+	switch bump {
+	case BumpMajor:
+		return Version{Major: v.Major + 1}
+	case BumpMinor:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	case BumpPatch:
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	default:
+		return v
+	}
+}
+
+// CommitBump 按 Conventional Commits 判断提交的递增级别：
+// 带 ! 或 BREAKING CHANGE 脚注为 major，feat 为 minor，fix、perf 为 patch，其余不触发发布
+func CommitBump(message string) Bump {
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	m := conventionalPattern.FindStringSubmatch(lines[0])
+	if m == nil {
+		return BumpNone
+	}
+	if m[3] == "!" {
+		return BumpMajor
+	}
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			return BumpMajor
+		}
+	}
+	switch strings.ToLower(m[1]) {
+	case "feat":
+		return BumpMinor
+	case "fix", "perf":
+		return BumpPatch
+	default:
+		return BumpNone
+	}
+}
+
+// ReleaseOptions 自动发布选项
+type ReleaseOptions struct {
+	Rev    string     // 发布的修订，默认 HEAD
+	Tag    TagOptions // 新标签的选项，Message 默认为 "Release vX.Y.Z"
+	DryRun bool       // 只计算版本号，不创建标签
+}
+
+// Release 自动发布结果
+type Release struct {
+	Previous string    `json:"previous,omitempty"` // 上一个版本标签，没有时为空
+	Version  string    `json:"version"`
+	Bump     Bump      `json:"bump"`
+	Hash     string    `json:"hash"`    // 打标签的提交
+	Commits  []*Commit `json:"commits"` // 上一个版本之后的提交
+}
+
+// LatestVersion 最大的 vX.Y.Z 版本标签，没有时返回 nil
+func (i *Info) LatestVersion() (*Tag, error) {
+	tags, err := i.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	var latest *Tag
+	var latestVersion Version
+	for _, tag := range tags {
+		v, ok := ParseVersion(tag.Name)
+		if ok && (latest == nil || latestVersion.Less(v)) {
+			latest, latestVersion = tag, v
+		}
+	}
+	return latest, nil
+}
+
+// NextRelease 根据上一个版本标签之后的提交计算下一个版本号并创建、推送标签；
+// 没有需要发布的提交时返回 ErrNothingToRelease，首个版本基于 v0.0.0 递增
+func (i *Info) NextRelease(ctx context.Context, opts *ReleaseOptions) (*Release, error) {
+	if opts == nil {
+		opts = &ReleaseOptions{}
+	}
+	rev := opts.Rev
+	if rev == "" {
+		rev = string(plumbing.HEAD)
+	}
+	hash, err := i.resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := i.LatestVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	release := &Release{Hash: hash.String()}
+	var previous Version
+	var released map[plumbing.Hash]bool
+	if latest != nil {
+		previous, _ = ParseVersion(latest.Name)
+		release.Previous = latest.Name
+		if released, err = i.ancestors(plumbing.NewHash(latest.Hash)); err != nil {
+			return nil, err
+		}
+	}
+
+	head, err := i.project.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	iter := object.NewCommitPreorderIter(head, released, nil)
+	defer iter.Close()
+	err = iter.ForEach(func(c *object.Commit) error {
+		release.Commits = append(release.Commits, toCommit(c))
+		if bump := CommitBump(c.Message); bump > release.Bump {
+			release.Bump = bump
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if release.Bump == BumpNone {
+		return release, ErrNothingToRelease
+	}
+	release.Version = previous.Next(release.Bump).String()
+	if opts.DryRun {
+		return release, nil
+	}
+
+	tagOpts := opts.Tag
+	if tagOpts.Message == "" {
+		tagOpts.Message = "Release " + release.Version
+	}
+	if err := i.createTag(ctx, hash, release.Version, &tagOpts); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// ancestors 提交及其所有祖先的哈希
+func (i *Info) ancestors(hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	c, err := i.project.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[plumbing.Hash]bool{}
+	iter := object.NewCommitPreorderIter(c, nil, nil)
+	defer iter.Close()
+	err = iter.ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return seen, nil
+}
@@ -0,0 +1,166 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Errorf(string, ...interface{}) {}
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Infof(string, ...interface{})  {}
+
+// newTestRepoWithRemote 创建带裸远程仓库 origin 的本地仓库
+func newTestRepoWithRemote(t *testing.T) (*Info, string, *git.Repository) {
+	remoteDir := t.TempDir()
+	remote, err := git.PlainInit(remoteDir, true)
+	assert.NoError(t, err)
+	repo, dir := newTestRepo(t)
+	_, err = repo.Repository().CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}})
+	assert.NoError(t, err)
+	return repo, dir, remote
+}
+
+func remoteTags(t *testing.T, remote *git.Repository) []string {
+	iter, err := remote.Tags()
+	assert.NoError(t, err)
+	var names []string
+	iter.ForEach(func(ref *plumbing.Reference) error {
+		names = append(names, ref.Name().Short())
+		return nil
+	})
+	return names
+}
+
+func TestCreateTag(t *testing.T) {
+	ctx := context.Background()
+	repo, dir, remote := newTestRepoWithRemote(t)
+	commit := commitFile(t, repo, dir, "a.txt", "one\n", "init")
+	hash := plumbing.NewHash(commit.Hash)
+
+	// 只推送新建的标签
+	assert.NoError(t, repo.CreateTag(nopLogger{}, ctx, hash, "local-only", &TagOptions{NoPush: true, Tagger: testAuthor}))
+	assert.NoError(t, repo.CreateTag(nopLogger{}, ctx, hash, "v1.0.0", &TagOptions{Message: "first release", Tagger: testAuthor}))
+	assert.NoError(t, repo.CreateTag(nopLogger{}, ctx, hash, "light", &TagOptions{Lightweight: true}))
+	assert.ElementsMatch(t, []string{"v1.0.0", "light"}, remoteTags(t, remote))
+	assert.ErrorIs(t, repo.CreateTag(nopLogger{}, ctx, hash, "v1.0.0", &TagOptions{Tagger: testAuthor}), git.ErrTagExists)
+
+	entity, err := openpgp.NewEntity("Release Bot", "", "release@example.com", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.CreateTag(nopLogger{}, ctx, hash, "v1.0.1", &TagOptions{Tagger: testAuthor, SignKey: entity}))
+
+	tags, err := repo.ListTags()
+	assert.NoError(t, err)
+	byName := map[string]*Tag{}
+	for _, tag := range tags {
+		byName[tag.Name] = tag
+		assert.Equal(t, commit.Hash, tag.Hash)
+	}
+	assert.True(t, byName["v1.0.0"].Annotated)
+	assert.Equal(t, "first release\n", byName["v1.0.0"].Message)
+	assert.Equal(t, "Dev", byName["v1.0.0"].Tagger.Name)
+	assert.False(t, byName["light"].Annotated)
+	assert.Equal(t, "local-only\n", byName["local-only"].Message)
+	assert.True(t, byName["v1.0.1"].Signed)
+
+	// 签名可用公钥校验
+	ref, _ := repo.Repository().Tag("v1.0.1")
+	tagObject, _ := repo.Repository().TagObject(ref.Hash())
+	buf := &bytes.Buffer{}
+	w, _ := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	entity.Serialize(w)
+	w.Close()
+	_, err = tagObject.Verify(buf.String())
+	assert.NoError(t, err)
+
+	// 删除时同步删除远程标签
+	assert.NoError(t, repo.DeleteTag(nopLogger{}, ctx, "v1.0.0"))
+	assert.ElementsMatch(t, []string{"light", "v1.0.1"}, remoteTags(t, remote))
+}
+
+func TestCommitBump(t *testing.T) {
+	cases := map[string]Bump{
+		"feat: add login":                         BumpMinor,
+		"feat(api): add login":                    BumpMinor,
+		"fix: typo":                               BumpPatch,
+		"perf(db): cache":                         BumpPatch,
+		"feat!: drop v1 api":                      BumpMajor,
+		"refactor(core)!: rename":                 BumpMajor,
+		"chore: bump deps\n\nBREAKING CHANGE: go": BumpMajor,
+		"docs: readme":                            BumpNone,
+		"update readme":                           BumpNone,
+		"feat:missing space":                      BumpNone,
+	}
+	for message, want := range cases {
+		assert.Equal(t, want, CommitBump(message), message)
+	}
+
+	v, ok := ParseVersion("v1.2.3")
+	assert.True(t, ok)
+	assert.Equal(t, "v2.0.0", v.Next(BumpMajor).String())
+	assert.Equal(t, "v1.3.0", v.Next(BumpMinor).String())
+	assert.Equal(t, "v1.2.4", v.Next(BumpPatch).String())
+	for _, s := range []string{"1.2.3", "v1.2", "v01.2.3", "v1.2.3-rc.1"} {
+		_, ok := ParseVersion(s)
+		assert.False(t, ok, s)
+	}
+}
+
+func TestNextRelease(t *testing.T) {
+	ctx := context.Background()
+	repo, dir, remote := newTestRepoWithRemote(t)
+	commitFile(t, repo, dir, "a.txt", "1\n", "chore: init")
+	_, err := repo.NextRelease(ctx, nil)
+	assert.ErrorIs(t, err, ErrNothingToRelease)
+
+	commitFile(t, repo, dir, "a.txt", "2\n", "feat: first feature")
+	commitFile(t, repo, dir, "a.txt", "3\n", "fix: first fix")
+	release, err := repo.NextRelease(ctx, &ReleaseOptions{DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.1.0", release.Version)
+	assert.Equal(t, BumpMinor, release.Bump)
+	assert.Empty(t, release.Previous)
+	assert.Len(t, release.Commits, 3)
+	assert.Empty(t, remoteTags(t, remote))
+
+	release, err = repo.NextRelease(ctx, &ReleaseOptions{Tag: TagOptions{Tagger: testAuthor}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v0.1.0"}, remoteTags(t, remote))
+	_, err = repo.NextRelease(ctx, nil)
+	assert.ErrorIs(t, err, ErrNothingToRelease)
+
+	// 只统计上一个版本之后的提交
+	commitFile(t, repo, dir, "a.txt", "4\n", "fix: second fix")
+	release, err = repo.NextRelease(ctx, &ReleaseOptions{Tag: TagOptions{Tagger: testAuthor}})
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.1.1", release.Version)
+	assert.Equal(t, "v0.1.0", release.Previous)
+	assert.Len(t, release.Commits, 1)
+
+	// 非版本格式的标签不影响计算
+	head, _ := repo.Repository().Head()
+	assert.NoError(t, repo.CreateTag(nopLogger{}, ctx, head.Hash(), "nightly", &TagOptions{Lightweight: true, NoPush: true}))
+	commitFile(t, repo, dir, "a.txt", "5\n", "refactor: api\n\nBREAKING CHANGE: removed v0 endpoints")
+	release, err = repo.NextRelease(ctx, &ReleaseOptions{Tag: TagOptions{Tagger: testAuthor}})
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.0", release.Version)
+
+	latest, err := repo.LatestVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.0", latest.Name)
+	tags, _ := repo.ListTags()
+	for _, tag := range tags {
+		if tag.Name == "v1.0.0" {
+			assert.Equal(t, "Release v1.0.0\n", tag.Message)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package lock
+
+import "errors"
+
+// 预定义错误
+var (
+	ErrNotAcquired = errors.New("lock is held by another owner")
+	ErrLockLost    = errors.New("lock is no longer held by this owner")
+)
@@ -8,6 +8,9 @@ import (
 	"time"
 )
 
+// Lock 简单的 Redis 锁，不校验持有者。
+//
+// Deprecated: Down 会删除他人持有的锁，使用 Locker。
 type Lock struct {
 	rds redis.Client
 	log xlogger.Logger
@@ -33,15 +36,18 @@ func (l *Lock) Down(ctx context.Context, key string) {
 	}
 }
 
+// UpWait 每 100ms 重试加锁，直到成功或 ctx 结束
 func (l *Lock) UpWait(ctx context.Context, function string, key string, aliveSeconds int64) error {
 	for {
 		success := l.Up(ctx, key, aliveSeconds)
 		if success {
 			l.log.Infof("Tag=%v; func=%v; key=%+v; info=%s;", "UpWait", function, key, "加锁成功")
 			return nil
-		} else {
-			l.log.Infof("Tag=%v; func=%v; key=%+v; info=%s;", "UpWait", function, key, "有业务在执行,休眠100ms")
-			time.Sleep(time.Millisecond * 100)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Millisecond * 100):
 		}
 	}
 }
@@ -0,0 +1,273 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mrand "math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yi-nology/common/utils/xlogger"
+)
+
+const (
+	LOCK_FENCE = "{%s}.fence" // 栅栏令牌计数器，STRING 与锁 key 位于同一个集群槽位
+
+	minTTL     = time.Millisecond      // Redis PX 以毫秒为单位，不足 1ms 时为 0
+	minBackoff = 10 * time.Millisecond // Acquire 重试间隔下限，避免忙等
+)
+
+var (
+	// acquireScript 加锁成功时递增栅栏计数器并返回，已被占用时返回 0
+	acquireScript = redis.NewScript(`
+if redis.call('set', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return redis.call('incr', KEYS[2])
+end
+return 0
+`)
+	// releaseScript 仅持有者可以删除
+	releaseScript = redis.NewScript(`
+if redis.call('get', KEYS[1]) == ARGV[1] then
+	return redis.call('del', KEYS[1])
+end
+return 0
+`)
+	// extendScript 仅持有者可以续期
+	extendScript = redis.NewScript(`
+if redis.call('get', KEYS[1]) == ARGV[1] then
+	return redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return 0
+`)
+)
+
+// Locker 基于 Redis 的互斥锁：每次加锁生成唯一的持有者令牌，释放与续期通过 Lua 脚本校验令牌，
+// 并返回单调递增的栅栏令牌，供下游存储拒绝过期持有者的写入
+type Locker struct {
+	client     redis.UniversalClient
+	log        xlogger.Logger
+	ttl        time.Duration
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	watchdog   bool
+}
+
+// NewLocker 创建锁，client 可为单节点、哨兵或集群客户端；默认有效期 30s、开启看门狗续期、重试间隔 50ms 到 1s 指数退避
+func NewLocker(client redis.UniversalClient, log xlogger.Logger) *Locker {
+	return &Locker{
+		client:     client,
+		log:        log,
+		ttl:        30 * time.Second,
+		minBackoff: 50 * time.Millisecond,
+		maxBackoff: time.Second,
+		watchdog:   true,
+	}
+}
+
+// SetTTL 设置锁的有效期，开启看门狗时每 ttl/3 续期一次；小于 1ms 时按 1ms 处理
+func (l *Locker) SetTTL(ttl time.Duration) *Locker {
+	l.ttl = maxDuration(ttl, minTTL)
+	return l
+}
+
+// SetBackoff 设置 Acquire 重试的最小、最大间隔；最小间隔不低于 10ms，最大间隔不小于最小间隔
+func (l *Locker) SetBackoff(min, max time.Duration) *Locker {
+	l.minBackoff = maxDuration(min, minBackoff)
+	l.maxBackoff = maxDuration(max, l.minBackoff)
+	return l
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// SetWatchdog 设置是否在持有期间自动续期
+func (l *Locker) SetWatchdog(enabled bool) *Locker {
+	l.watchdog = enabled
+	return l
+}
+
+// TryAcquire 尝试加锁一次，已被占用时返回 ErrNotAcquired
+func (l *Locker) TryAcquire(ctx context.Context, key string) (*Lease, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	fence, err := acquireScript.Run(ctx, l.client, []string{key, fmt.Sprintf(LOCK_FENCE, key)}, token, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if fence == 0 {
+		return nil, ErrNotAcquired
+	}
+	return l.newLease(key, token, fence, []string{key}, extendScript, releaseScript), nil
+}
+
+// Acquire 加锁，被占用时按指数退避重试，直到成功或 ctx 结束
+func (l *Locker) Acquire(ctx context.Context, key string) (*Lease, error) {
+	return l.retry(ctx, func() (*Lease, error) { return l.TryAcquire(ctx, key) })
+}
+
+// newLease 创建持有记录，extend 脚本参数为 (token, ttl 毫秒)，release 脚本参数为 (token)，仍持有时返回非 0
+func (l *Locker) newLease(key, token string, fence int64, keys []string, extend, release *redis.Script) *Lease {
+	lease := &Lease{
+		locker:  l,
+		key:     key,
+		token:   token,
+		fence:   fence,
+		keys:    keys,
+		extend:  extend,
+		release: release,
+		lost:    make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+	if l.watchdog {
+		lease.wg.Add(1)
+		go lease.renew()
+	}
+	return lease
+}
+
+// retry 按指数退避重复 try，直到不再返回 ErrNotAcquired 或 ctx 结束
+func (l *Locker) retry(ctx context.Context, try func() (*Lease, error)) (*Lease, error) {
+	backoff := l.minBackoff
+	for {
+		lease, err := try()
+		if err == nil {
+			return lease, nil
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNotAcquired, ctx.Err())
+		}
+		if err != ErrNotAcquired {
+			return nil, err
+		}
+
+		// 随机抖动避免多个等待者同时重试
+		wait := backoff/2 + time.Duration(mrand.Int63n(int64(backoff/2)+1))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %w", ErrNotAcquired, ctx.Err())
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > l.maxBackoff {
+			backoff = l.maxBackoff
+		}
+	}
+}
+
+// Lease 一次成功的加锁
+type Lease struct {
+	locker  *Locker
+	key     string
+	token   string
+	fence   int64
+	keys    []string
+	extend  *redis.Script
+	release *redis.Script
+
+	once     sync.Once
+	lostOnce sync.Once
+	released atomic.Bool
+	lost     chan struct{}
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// Key 锁的 key
+func (lease *Lease) Key() string {
+	return lease.key
+}
+
+// Token 持有者令牌
+func (lease *Lease) Token() string {
+	return lease.token
+}
+
+// Fence 栅栏令牌，同一个 key 每次加锁严格递增；读锁、可重入锁与信号量为 0
+func (lease *Lease) Fence() int64 {
+	return lease.fence
+}
+
+// Lost 看门狗发现锁已被他人持有或已过期时关闭，持有者应停止受保护的操作
+func (lease *Lease) Lost() <-chan struct{} {
+	return lease.lost
+}
+
+// Extend 将有效期重置为 ttl，锁已不属于当前持有者时返回 ErrLockLost
+func (lease *Lease) Extend(ctx context.Context, ttl time.Duration) error {
+	ok, err := lease.extend.Run(ctx, lease.locker.client, lease.keys, lease.token, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		lease.markLost()
+		return ErrLockLost
+	}
+	return nil
+}
+
+// Release 释放锁并停止看门狗，锁已过期或被他人持有时返回 ErrLockLost 且不影响他人的锁
+func (lease *Lease) Release(ctx context.Context) error {
+	lease.once.Do(func() { close(lease.stop) })
+	lease.wg.Wait()
+	// 可重入锁的释放是计数递减，并发或重复调用只能执行一次；脚本执行失败时恢复标记以便重试
+	if !lease.released.CompareAndSwap(false, true) {
+		return ErrLockLost
+	}
+	ok, err := lease.release.Run(ctx, lease.locker.client, lease.keys, lease.token).Int64()
+	if err != nil {
+		lease.released.Store(false)
+		return err
+	}
+	if ok == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+func (lease *Lease) markLost() {
+	lease.lostOnce.Do(func() { close(lease.lost) })
+}
+
+// renew 看门狗，每 ttl/3 续期；网络错误时下一轮重试，锁丢失时退出
+func (lease *Lease) renew() {
+	defer lease.wg.Done()
+	ttl := lease.locker.ttl
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lease.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+			err := lease.Extend(ctx, ttl)
+			cancel()
+			if err == ErrLockLost {
+				lease.locker.log.Errorf("Lease|renew|key=%s lock lost", lease.key)
+				return
+			}
+			if err != nil {
+				lease.locker.log.Errorf("Lease|renew|key=%s err=%v", lease.key, err)
+			}
+		}
+	}
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
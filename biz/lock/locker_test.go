@@ -0,0 +1,176 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Errorf(string, ...interface{}) {}
+func (nopLogger) Debugf(string, ...interface{}) {}
+func (nopLogger) Infof(string, ...interface{})  {}
+
+func newTestClient(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	return redis.NewClient(&redis.Options{Addr: mr.Addr()}), mr
+}
+
+func TestLockerOwnership(t *testing.T) {
+	ctx := context.Background()
+	client, mr := newTestClient(t)
+	locker := NewLocker(client, nopLogger{}).SetTTL(time.Second).SetWatchdog(false)
+
+	first, err := locker.TryAcquire(ctx, "job")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), first.Fence())
+	_, err = locker.TryAcquire(ctx, "job")
+	assert.ErrorIs(t, err, ErrNotAcquired)
+
+	// 过期后被他人获取，旧持有者不能续期或删除新锁
+	mr.FastForward(2 * time.Second)
+	second, err := locker.TryAcquire(ctx, "job")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), second.Fence())
+	assert.NotEqual(t, first.Token(), second.Token())
+	assert.ErrorIs(t, first.Extend(ctx, time.Second), ErrLockLost)
+	assert.ErrorIs(t, first.Release(ctx), ErrLockLost)
+	assert.True(t, mr.Exists("job"))
+	select {
+	case <-first.Lost():
+	default:
+		t.Fatal("lost channel should be closed")
+	}
+
+	assert.NoError(t, second.Extend(ctx, 5*time.Second))
+	assert.Equal(t, 5*time.Second, mr.TTL("job"))
+	assert.NoError(t, second.Release(ctx))
+	assert.False(t, mr.Exists("job"))
+
+	// 栅栏令牌在释放后继续递增
+	third, err := locker.TryAcquire(ctx, "job")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), third.Fence())
+	assert.Equal(t, "3", mustGet(t, mr, "{job}.fence"))
+}
+
+func mustGet(t *testing.T, mr *miniredis.Miniredis, key string) string {
+	v, err := mr.Get(key)
+	assert.NoError(t, err)
+	return v
+}
+
+func TestLockerSettersClamp(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestClient(t)
+
+	// 过小的有效期按 1ms 处理，看门狗不会因 ttl/3 为 0 而 panic
+	locker := NewLocker(client, nopLogger{}).SetTTL(time.Nanosecond)
+	assert.Equal(t, time.Millisecond, locker.ttl)
+	lease, err := locker.TryAcquire(ctx, "tiny")
+	assert.NoError(t, err)
+	assert.NoError(t, lease.Release(ctx))
+	assert.Equal(t, time.Millisecond, NewLocker(client, nopLogger{}).SetTTL(-time.Second).ttl)
+
+	// 重试间隔有下限，Acquire 不会忙等；最大间隔不小于最小间隔
+	locker = NewLocker(client, nopLogger{}).SetTTL(time.Minute).SetWatchdog(false).SetBackoff(0, 0)
+	assert.Equal(t, minBackoff, locker.minBackoff)
+	assert.Equal(t, minBackoff, locker.maxBackoff)
+	assert.Equal(t, time.Second, locker.SetBackoff(time.Second, time.Millisecond).maxBackoff)
+}
+
+func TestLockerAcquire(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestClient(t)
+	locker := NewLocker(client, nopLogger{}).SetTTL(time.Second).SetWatchdog(false).SetBackoff(5*time.Millisecond, 20*time.Millisecond)
+
+	held, err := locker.Acquire(ctx, "job")
+	assert.NoError(t, err)
+
+	// 超过 ctx 期限时返回，而不是无限等待
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = locker.Acquire(timeout, "job")
+	assert.ErrorIs(t, err, ErrNotAcquired)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// 持有者释放后等待者获得锁
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		held.Release(ctx)
+	}()
+	next, err := locker.Acquire(ctx, "job")
+	assert.NoError(t, err)
+	assert.Greater(t, next.Fence(), held.Fence())
+
+	// 并发竞争时同一时刻只有一个持有者
+	assert.NoError(t, next.Release(ctx))
+	var holders maxCounter
+	var total int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lease, err := locker.Acquire(ctx, "race")
+			if !assert.NoError(t, err) {
+				return
+			}
+			holders.enter()
+			time.Sleep(time.Millisecond)
+			holders.leave()
+			atomic.AddInt32(&total, 1)
+			assert.NoError(t, lease.Release(ctx))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), holders.max)
+	assert.Equal(t, int32(20), total)
+}
+
+func TestLockerWatchdog(t *testing.T) {
+	ctx := context.Background()
+	client, mr := newTestClient(t)
+	locker := NewLocker(client, nopLogger{}).SetTTL(150 * time.Millisecond)
+
+	lease, err := locker.TryAcquire(ctx, "job")
+	assert.NoError(t, err)
+	mr.FastForward(100 * time.Millisecond)
+	assert.Eventually(t, func() bool { return mr.TTL("job") > 100*time.Millisecond }, time.Second, 10*time.Millisecond)
+
+	// 锁被他人占用后看门狗通知持有者
+	mr.Set("job", "someone-else")
+	select {
+	case <-lease.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not report lost lock")
+	}
+	assert.ErrorIs(t, lease.Release(ctx), ErrLockLost)
+	assert.Equal(t, "someone-else", mustGet(t, mr, "job"))
+}
+
+func TestLockerClusterClient(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{mr.Addr()}})
+	defer client.Close()
+	locker := NewLocker(client, nopLogger{}).SetWatchdog(false)
+
+	// 锁与派生 key 使用同一个哈希标签，集群中位于同一槽位
+	lease, err := locker.TryAcquire(ctx, "job")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), lease.Fence())
+	assert.NoError(t, lease.Release(ctx))
+	sem, err := locker.TryAcquireSemaphore(ctx, "pool", 2)
+	assert.NoError(t, err)
+	assert.NoError(t, sem.Release(ctx))
+}
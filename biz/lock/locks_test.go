@@ -0,0 +1,230 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// maxCounter 记录同时进入临界区的最大数量
+type maxCounter struct {
+	cur, max int32
+}
+
+func (c *maxCounter) enter() int32 {
+	n := atomic.AddInt32(&c.cur, 1)
+	for {
+		m := atomic.LoadInt32(&c.max)
+		if n <= m || atomic.CompareAndSwapInt32(&c.max, m, n) {
+			return n
+		}
+	}
+}
+
+func (c *maxCounter) leave() {
+	atomic.AddInt32(&c.cur, -1)
+}
+
+func TestReentrantLock(t *testing.T) {
+	ctx := context.Background()
+	client, mr := newTestClient(t)
+	locker := NewLocker(client, nopLogger{}).SetTTL(time.Minute).SetWatchdog(false).SetBackoff(time.Millisecond, 5*time.Millisecond)
+
+	outer, err := locker.TryAcquireReentrant(ctx, "job", "a")
+	assert.NoError(t, err)
+	inner, err := locker.TryAcquireReentrant(ctx, "job", "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", mr.HGet("{job}.reentrant", "a"))
+	_, err = locker.TryAcquireReentrant(ctx, "job", "b")
+	assert.ErrorIs(t, err, ErrNotAcquired)
+
+	// 重复释放同一个 Lease 不会多次递减
+	assert.NoError(t, inner.Release(ctx))
+	assert.ErrorIs(t, inner.Release(ctx), ErrLockLost)
+	assert.Equal(t, "1", mr.HGet("{job}.reentrant", "a"))
+	_, err = locker.TryAcquireReentrant(ctx, "job", "b")
+	assert.ErrorIs(t, err, ErrNotAcquired)
+	assert.NoError(t, outer.Release(ctx))
+	assert.False(t, mr.Exists("{job}.reentrant"))
+
+	// 与同名互斥锁使用不同的 key，混用不会出现 WRONGTYPE
+	mutex, err := locker.TryAcquire(ctx, "job")
+	assert.NoError(t, err)
+	outer, err = locker.TryAcquireReentrant(ctx, "job", "a")
+	assert.NoError(t, err)
+	assert.NoError(t, mutex.Release(ctx))
+
+	// 并发释放同一个 Lease 只递减一次
+	inner, err = locker.TryAcquireReentrant(ctx, "job", "a")
+	assert.NoError(t, err)
+	var released int32
+	var releaseWG sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		releaseWG.Add(1)
+		go func() {
+			defer releaseWG.Done()
+			if inner.Release(ctx) == nil {
+				atomic.AddInt32(&released, 1)
+			}
+		}()
+	}
+	releaseWG.Wait()
+	assert.Equal(t, int32(1), released)
+	assert.Equal(t, "1", mr.HGet("{job}.reentrant", "a"))
+	assert.NoError(t, outer.Release(ctx))
+
+	// 多个 owner 竞争，每个 owner 嵌套加锁
+	var owners maxCounter
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(owner string) {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				outer, err := locker.AcquireReentrant(ctx, "race", owner)
+				if !assert.NoError(t, err) {
+					return
+				}
+				owners.enter()
+				inner, err := locker.AcquireReentrant(ctx, "race", owner)
+				assert.NoError(t, err)
+				assert.NoError(t, inner.Release(ctx))
+				owners.leave()
+				assert.NoError(t, outer.Release(ctx))
+			}
+		}(fmt.Sprintf("owner-%d", i))
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), owners.max)
+	assert.False(t, mr.Exists("{race}.reentrant"))
+}
+
+func TestReadWriteLock(t *testing.T) {
+	ctx := context.Background()
+	client, mr := newTestClient(t)
+	locker := NewLocker(client, nopLogger{}).SetTTL(time.Minute).SetWatchdog(false).SetBackoff(time.Millisecond, 5*time.Millisecond)
+
+	r1, err := locker.TryAcquireRead(ctx, "doc")
+	assert.NoError(t, err)
+	r2, err := locker.TryAcquireRead(ctx, "doc")
+	assert.NoError(t, err)
+	_, err = locker.TryAcquireWrite(ctx, "doc")
+	assert.ErrorIs(t, err, ErrNotAcquired)
+	assert.NoError(t, r1.Release(ctx))
+	assert.NoError(t, r2.Release(ctx))
+
+	w, err := locker.TryAcquireWrite(ctx, "doc")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), w.Fence())
+	_, err = locker.TryAcquireRead(ctx, "doc")
+	assert.ErrorIs(t, err, ErrNotAcquired)
+	assert.NoError(t, w.Release(ctx))
+
+	// 崩溃的读者到期后不再阻塞写者
+	_, err = locker.TryAcquireRead(ctx, "doc")
+	assert.NoError(t, err)
+	mr.SetTime(time.Now().Add(2 * time.Minute))
+	w, err = locker.TryAcquireWrite(ctx, "doc")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), w.Fence())
+	assert.NoError(t, w.Release(ctx))
+	mr.SetTime(time.Time{})
+
+	// 读写并发：写者独占，读者可以并发
+	var readers, writers maxCounter
+	var violations int32
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func(write bool) {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				if write {
+					lease, err := locker.AcquireWrite(ctx, "race")
+					if !assert.NoError(t, err) {
+						return
+					}
+					if writers.enter() != 1 || atomic.LoadInt32(&readers.cur) != 0 {
+						atomic.AddInt32(&violations, 1)
+					}
+					time.Sleep(time.Millisecond)
+					writers.leave()
+					assert.NoError(t, lease.Release(ctx))
+					continue
+				}
+				lease, err := locker.AcquireRead(ctx, "race")
+				if !assert.NoError(t, err) {
+					return
+				}
+				readers.enter()
+				if atomic.LoadInt32(&writers.cur) != 0 {
+					atomic.AddInt32(&violations, 1)
+				}
+				time.Sleep(time.Millisecond)
+				readers.leave()
+				assert.NoError(t, lease.Release(ctx))
+			}
+		}(i%4 == 0)
+	}
+	wg.Wait()
+	assert.Zero(t, violations)
+	assert.Equal(t, int32(1), writers.max)
+}
+
+func TestSemaphore(t *testing.T) {
+	ctx := context.Background()
+	client, mr := newTestClient(t)
+	locker := NewLocker(client, nopLogger{}).SetTTL(time.Minute).SetWatchdog(false).SetBackoff(time.Millisecond, 5*time.Millisecond)
+
+	_, err := locker.TryAcquireSemaphore(ctx, "pool", 0)
+	assert.Error(t, err)
+	var leases []*Lease
+	for i := 0; i < 3; i++ {
+		lease, err := locker.TryAcquireSemaphore(ctx, "pool", 3)
+		assert.NoError(t, err)
+		leases = append(leases, lease)
+	}
+	_, err = locker.TryAcquireSemaphore(ctx, "pool", 3)
+	assert.ErrorIs(t, err, ErrNotAcquired)
+	assert.NoError(t, leases[0].Release(ctx))
+	leases[0], err = locker.TryAcquireSemaphore(ctx, "pool", 3)
+	assert.NoError(t, err)
+
+	// 崩溃的持有者到期后让出名额，到期的持有者不能续期
+	mr.SetTime(time.Now().Add(2 * time.Minute))
+	assert.ErrorIs(t, leases[1].Extend(ctx, time.Minute), ErrLockLost)
+	for i := 0; i < 3; i++ {
+		_, err := locker.TryAcquireSemaphore(ctx, "pool", 3)
+		assert.NoError(t, err)
+	}
+	assert.ErrorIs(t, leases[2].Release(ctx), ErrLockLost)
+	mr.Del("pool")
+	mr.SetTime(time.Time{})
+
+	var holders maxCounter
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 3; j++ {
+				lease, err := locker.AcquireSemaphore(ctx, "race", 3)
+				if !assert.NoError(t, err) {
+					return
+				}
+				holders.enter()
+				time.Sleep(time.Millisecond)
+				holders.leave()
+				assert.NoError(t, lease.Release(ctx))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, holders.max, int32(3))
+	assert.Greater(t, holders.max, int32(1))
+}
@@ -0,0 +1,61 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	LOCK_REENTRANT = "{%s}.reentrant" // 可重入锁持有者，HASH，与互斥锁的 key 相互独立
+)
+
+var (
+	// reentrantAcquireScript 锁空闲或已由 owner 持有时递增 owner 的持有次数并返回，被他人持有时返回 0
+	reentrantAcquireScript = redis.NewScript(`
+if redis.call('exists', KEYS[1]) == 0 or redis.call('hexists', KEYS[1], ARGV[1]) == 1 then
+	local n = redis.call('hincrby', KEYS[1], ARGV[1], 1)
+	redis.call('pexpire', KEYS[1], ARGV[2])
+	return n
+end
+return 0
+`)
+	// reentrantReleaseScript 递减 owner 的持有次数，归零时删除锁
+	reentrantReleaseScript = redis.NewScript(`
+if redis.call('hexists', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+if redis.call('hincrby', KEYS[1], ARGV[1], -1) <= 0 then
+	redis.call('del', KEYS[1])
+end
+return 1
+`)
+	// reentrantExtendScript 仅持有者可以续期
+	reentrantExtendScript = redis.NewScript(`
+if redis.call('hexists', KEYS[1], ARGV[1]) == 1 then
+	return redis.call('pexpire', KEYS[1], ARGV[2])
+end
+return 0
+`)
+)
+
+// TryAcquireReentrant 尝试加可重入锁一次：锁记录为 HASH {owner: 持有次数}，存放在 LOCK_REENTRANT 中，
+// 同一个 owner 可以重复加锁，每个 Lease 释放一次，全部释放后锁才被删除；被他人持有时返回 ErrNotAcquired。
+// 可重入锁与同名的互斥锁、信号量互不排斥，同一资源应只使用一种锁
+func (l *Locker) TryAcquireReentrant(ctx context.Context, key, owner string) (*Lease, error) {
+	hash := fmt.Sprintf(LOCK_REENTRANT, key)
+	n, err := reentrantAcquireScript.Run(ctx, l.client, []string{hash}, owner, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrNotAcquired
+	}
+	return l.newLease(key, owner, 0, []string{hash}, reentrantExtendScript, reentrantReleaseScript), nil
+}
+
+// AcquireReentrant 加可重入锁，被他人持有时按指数退避重试，直到成功或 ctx 结束
+func (l *Locker) AcquireReentrant(ctx context.Context, key, owner string) (*Lease, error) {
+	return l.retry(ctx, func() (*Lease, error) { return l.TryAcquireReentrant(ctx, key, owner) })
+}
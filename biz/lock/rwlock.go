@@ -0,0 +1,83 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	LOCK_READERS = "{%s}.readers" // 读锁持有者，ZSET，score 为到期时间
+	LOCK_WRITER  = "{%s}.writer"  // 写锁持有者令牌，STRING
+)
+
+var (
+	// readAcquireScript 没有写锁时加入读锁持有者，成功返回 1
+	readAcquireScript = redis.NewScript(zsetNow + `
+if redis.call('exists', KEYS[2]) == 1 then
+	return 0
+end
+redis.call('zremrangebyscore', KEYS[1], '-inf', now)
+redis.call('zadd', KEYS[1], now + tonumber(ARGV[2]), ARGV[1])
+` + zsetExpire + `
+return 1
+`)
+	// writeAcquireScript 没有读锁与写锁时加写锁，成功时递增栅栏计数器并返回
+	writeAcquireScript = redis.NewScript(zsetNow + `
+redis.call('zremrangebyscore', KEYS[1], '-inf', now)
+if redis.call('zcard', KEYS[1]) > 0 then
+	return 0
+end
+if redis.call('set', KEYS[2], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return redis.call('incr', KEYS[3])
+end
+return 0
+`)
+)
+
+// TryAcquireRead 尝试加读锁一次，多个读者可以同时持有，有写锁时返回 ErrNotAcquired；
+// 读者优先，持续有读者时写者可能一直等待
+func (l *Locker) TryAcquireRead(ctx context.Context, key string) (*Lease, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	readers := fmt.Sprintf(LOCK_READERS, key)
+	ok, err := readAcquireScript.Run(ctx, l.client, []string{readers, fmt.Sprintf(LOCK_WRITER, key)}, token, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if ok == 0 {
+		return nil, ErrNotAcquired
+	}
+	return l.newLease(key, token, 0, []string{readers}, zsetExtendScript, zsetReleaseScript), nil
+}
+
+// AcquireRead 加读锁，有写锁时按指数退避重试，直到成功或 ctx 结束
+func (l *Locker) AcquireRead(ctx context.Context, key string) (*Lease, error) {
+	return l.retry(ctx, func() (*Lease, error) { return l.TryAcquireRead(ctx, key) })
+}
+
+// TryAcquireWrite 尝试加写锁一次，有读锁或写锁时返回 ErrNotAcquired，成功时返回栅栏令牌
+func (l *Locker) TryAcquireWrite(ctx context.Context, key string) (*Lease, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	writer := fmt.Sprintf(LOCK_WRITER, key)
+	keys := []string{fmt.Sprintf(LOCK_READERS, key), writer, fmt.Sprintf(LOCK_FENCE, key)}
+	fence, err := writeAcquireScript.Run(ctx, l.client, keys, token, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if fence == 0 {
+		return nil, ErrNotAcquired
+	}
+	return l.newLease(key, token, fence, []string{writer}, extendScript, releaseScript), nil
+}
+
+// AcquireWrite 加写锁，被占用时按指数退避重试，直到成功或 ctx 结束
+func (l *Locker) AcquireWrite(ctx context.Context, key string) (*Lease, error) {
+	return l.retry(ctx, func() (*Lease, error) { return l.TryAcquireWrite(ctx, key) })
+}
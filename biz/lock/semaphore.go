@@ -0,0 +1,77 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	LOCK_SEMAPHORE = "{%s}.semaphore" // 信号量持有者，ZSET，与互斥锁的 key 相互独立
+)
+
+// 持有者记录在 ZSET 中，score 为到期时间（毫秒），加锁时先清理到期的持有者，
+// 崩溃的持有者不再续期，到期后自动让出名额；时间取 Redis 服务器时间，不受客户端时钟影响
+const zsetNow = `
+local t = redis.call('time')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+`
+
+// zsetExpire 将 ZSET 的有效期设置为最晚到期的持有者
+const zsetExpire = `
+local last = redis.call('zrange', KEYS[1], -1, -1, 'WITHSCORES')
+redis.call('pexpire', KEYS[1], math.max(1, math.ceil(tonumber(last[2]) - now)))
+`
+
+var (
+	// semaphoreAcquireScript 持有者少于 ARGV[3] 时加入，成功返回 1
+	semaphoreAcquireScript = redis.NewScript(zsetNow + `
+redis.call('zremrangebyscore', KEYS[1], '-inf', now)
+if redis.call('zcard', KEYS[1]) >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call('zadd', KEYS[1], now + tonumber(ARGV[2]), ARGV[1])
+` + zsetExpire + `
+return 1
+`)
+	// zsetReleaseScript 移除持有者
+	zsetReleaseScript = redis.NewScript(`
+return redis.call('zrem', KEYS[1], ARGV[1])
+`)
+	// zsetExtendScript 仅未到期的持有者可以续期
+	zsetExtendScript = redis.NewScript(zsetNow + `
+local score = redis.call('zscore', KEYS[1], ARGV[1])
+if not score or tonumber(score) <= now then
+	return 0
+end
+redis.call('zadd', KEYS[1], 'XX', now + tonumber(ARGV[2]), ARGV[1])
+` + zsetExpire + `
+return 1
+`)
+)
+
+// TryAcquireSemaphore 尝试获取计数信号量一次，最多 limit 个持有者，名额已满时返回 ErrNotAcquired
+func (l *Locker) TryAcquireSemaphore(ctx context.Context, key string, limit int) (*Lease, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("invalid semaphore limit %d", limit)
+	}
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	holders := fmt.Sprintf(LOCK_SEMAPHORE, key)
+	ok, err := semaphoreAcquireScript.Run(ctx, l.client, []string{holders}, token, l.ttl.Milliseconds(), limit).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if ok == 0 {
+		return nil, ErrNotAcquired
+	}
+	return l.newLease(key, token, 0, []string{holders}, zsetExtendScript, zsetReleaseScript), nil
+}
+
+// AcquireSemaphore 获取计数信号量，名额已满时按指数退避重试，直到成功或 ctx 结束
+func (l *Locker) AcquireSemaphore(ctx context.Context, key string, limit int) (*Lease, error) {
+	return l.retry(ctx, func() (*Lease, error) { return l.TryAcquireSemaphore(ctx, key, limit) })
+}